@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"my-social-platform/internal/health"
+	"my-social-platform/internal/pkg/keystore"
+	"my-social-platform/internal/repository"
+	"my-social-platform/internal/repository/migrations"
+	"sort"
+)
+
+// runDoctor 检查运行环境：配置、数据库连接、表结构版本、目录和JWT密钥
+// 与 /readyz 使用相同的检查，另外检查表结构是否已是最新版本
+func runDoctor(args []string) error {
+	fs := newFlagSet("doctor", "")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Println("[fail] config:", err)
+		return errors.New("doctor found problems")
+	}
+	fmt.Println("[ ok ] config")
+
+	checks := []health.Check{
+		health.WritableDir("uploads", cfg.ImageDir()),
+	}
+	// 只读取已有的密钥，不像serve那样在目录为空时生成
+	keys, keysErr := keystore.Load(cfg.Auth.KeysDir)
+	if keysErr != nil {
+		fmt.Println("[fail] jwt_keys:", keysErr)
+	} else {
+		checks = append(checks, health.JWTKeys(keys))
+	}
+	db, err := repository.Open(cfg.Database)
+	if err != nil {
+		fmt.Println("[fail] database:", err)
+	} else {
+		defer repository.CloseDB(db)
+		checks = append(checks, health.Database(db), health.Check{
+			Name: "schema",
+			Run: func(ctx context.Context) error {
+				version, err := migrations.CurrentVersion(db)
+				if err != nil {
+					return err
+				}
+				if latest := migrations.LatestVersion(); version < latest {
+					return fmt.Errorf("schema version %d is behind latest %d, run `migrate up`", version, latest)
+				}
+				return nil
+			},
+		})
+	}
+
+	results, ok := health.Run(context.Background(), checks...)
+	names := make([]string, 0, len(results))
+	for name := range results {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		r := results[name]
+		if r.Status == health.StatusOK {
+			fmt.Printf("[ ok ] %s (%dms)\n", name, r.LatencyMS)
+		} else {
+			fmt.Printf("[fail] %s: %s\n", name, r.Error)
+		}
+	}
+
+	if !ok || err != nil || keysErr != nil {
+		return errors.New("doctor found problems")
+	}
+	return nil
+}
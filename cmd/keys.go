@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"my-social-platform/internal/pkg/keystore"
+	"time"
+)
+
+// runKeys JWT签名密钥管理
+//
+//	keys rotate    生成新的签名密钥并设为active，旧密钥保留用于验签
+//	keys list      列出全部密钥
+//	keys prune     删除退役时间超过访问令牌有效期的密钥
+func runKeys(args []string) error {
+	fs := newFlagSet("keys", "rotate | list | prune")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	switch fs.Arg(0) {
+	case "rotate":
+		keys, err := keystore.LoadOrCreate(cfg.Auth.KeysDir)
+		if err != nil {
+			return err
+		}
+		key, err := keys.Rotate()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("new active signing key %s\n", key.ID)
+		fmt.Printf("running servers switch to it within %s (auth.keys_reload_interval)\n", cfg.Auth.KeysReloadInterval)
+		fmt.Println("tokens signed with previous keys stay valid until they expire; run `keys prune` later to remove old keys")
+		return nil
+	case "list":
+		keys, err := keystore.Load(cfg.Auth.KeysDir)
+		if err != nil {
+			return err
+		}
+		active := keys.Active().ID
+		for _, k := range keys.Keys() {
+			mark := " "
+			if k.ID == active {
+				mark = "*"
+			}
+			fmt.Printf("%s %s  created %s\n", mark, k.ID, k.CreatedAt.Local().Format(time.DateTime))
+		}
+		return nil
+	case "prune":
+		keys, err := keystore.Load(cfg.Auth.KeysDir)
+		if err != nil {
+			return err
+		}
+		// 退役超过访问令牌有效期的密钥签发的令牌都已过期
+		removed, err := keys.Prune(cfg.Auth.AccessTokenTTL)
+		for _, kid := range removed {
+			fmt.Println("removed signing key", kid)
+		}
+		if err != nil {
+			return err
+		}
+		if len(removed) == 0 {
+			fmt.Println("no signing keys to remove")
+		}
+		return nil
+	default:
+		fs.Usage()
+		return fmt.Errorf("unknown subcommand %q", fs.Arg(0))
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"my-social-platform/internal/repository"
+	"my-social-platform/internal/repository/migrations"
+	"strconv"
+)
+
+// runMigrate 数据库迁移命令
+//
+//	migrate up          执行所有未执行的迁移
+//	migrate down [N]    撤销最近的N个迁移（默认1个）
+//	migrate status      查看每个迁移的执行状态
+func runMigrate(args []string) error {
+	fs := newFlagSet("migrate", "up | down [N] | status")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("missing subcommand")
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	// 迁移命令直接打开数据库，不走InitDB中的自动迁移
+	db, err := repository.Open(cfg.Database)
+	if err != nil {
+		return fmt.Errorf("connect to database: %w", err)
+	}
+	defer repository.CloseDB(db)
+
+	switch fs.Arg(0) {
+	case "up":
+		ran, err := migrations.Up(db)
+		for _, m := range ran {
+			fmt.Printf("applied  %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(ran) == 0 {
+			fmt.Println("database is up to date")
+		}
+	case "down":
+		steps := 1
+		if fs.NArg() > 1 {
+			if steps, err = strconv.Atoi(fs.Arg(1)); err != nil || steps <= 0 {
+				return fmt.Errorf("invalid step count %q", fs.Arg(1))
+			}
+		}
+		reverted, err := migrations.Down(db, steps)
+		for _, m := range reverted {
+			fmt.Printf("reverted %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(reverted) == 0 {
+			fmt.Println("no migrations to revert")
+		}
+	case "status":
+		statuses, err := migrations.StatusOf(db)
+		if err != nil {
+			return err
+		}
+		for _, st := range statuses {
+			state := "pending"
+			if st.Applied {
+				state = "applied " + st.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%-30s %s\n", st.Version, st.Name, state)
+		}
+	default:
+		fs.Usage()
+		return fmt.Errorf("unknown subcommand %q", fs.Arg(0))
+	}
+	return nil
+}
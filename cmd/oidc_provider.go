@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"my-social-platform/internal/pkg/oidc/oidctest"
+	"net/http"
+)
+
+// runOIDCProvider 启动测试用的OpenID Connect身份提供方，本地调试统一身份认证登录
+// 授权页可以填写任意 sub 和邮箱，不校验密码，不要在生产环境使用
+//
+//	oidc-provider [-addr :9000] [-issuer http://localhost:9000] [-client-id ID] [-client-secret SECRET]
+func runOIDCProvider(args []string) error {
+	fs := newFlagSet("oidc-provider", "[-addr :9000] [-issuer URL]")
+	addr := fs.String("addr", ":9000", "listen address")
+	issuer := fs.String("issuer", "", "issuer URL (default http://localhost<addr>)")
+	clientID := fs.String("client-id", "", "accepted client_id (default: any)")
+	clientSecret := fs.String("client-secret", "", "client secret required together with -client-id")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *issuer == "" {
+		*issuer = "http://localhost" + *addr
+	}
+
+	p, err := oidctest.New(*issuer)
+	if err != nil {
+		return err
+	}
+	p.ClientID, p.ClientSecret = *clientID, *clientSecret
+
+	log.Printf("OIDC test provider running at %s (issuer %s)", *addr, *issuer)
+	fmt.Println("set oidc.enabled=true and oidc.issuer=" + *issuer + " in config.yaml to log in through it")
+	return http.ListenAndServe(*addr, p)
+}
@@ -0,0 +1,381 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"my-social-platform/frontend"
+	"my-social-platform/internal/app"
+	"my-social-platform/internal/config"
+	"my-social-platform/internal/handler"
+	"my-social-platform/internal/middleware"
+	"my-social-platform/internal/model"
+	"my-social-platform/internal/pkg/certreload"
+	"my-social-platform/internal/pkg/logger"
+	"my-social-platform/internal/service"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// CORS 跨域资源共享 https://blog.csdn.net/leah126/article/details/141624726
+// 跨域资源共享(CORS)是一种安全策略，允许浏览器从不同的源(域)请求资源
+// 浏览器打开网页时 会找前端服务器（3000），得到静态资源、html等信息
+// 然后浏览器会运行前端服务器返回的js代码，js代码请求后端api时，会进行跨域请求
+// 跨域请求会先进行OPTIONS请求（预检请求），询问后端是否允许跨域请求
+// 后端允许跨域请求后，浏览器会进行真正的请求
+
+// 1. 创建默认CORS配置
+// 2. 允许的前端源(这里是本地开发服务器地址)
+// 3. 允许的HTTP请求头(包括认证所需的Authorization头)
+// 4. 允许的HTTP方法(GET查询、POST创建、PUT更新、DELETE删除、OPTIONS预检请求)
+// 5. 允许携带Cookie等身份凭证
+// 6. 缓存预检请求结果
+// 7. 将CORS中间件应用到Gin路由器
+
+// runServe 启动HTTP服务
+func runServe(args []string) error {
+	fs := newFlagSet("serve", "")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	// 初始化日志系统
+	if err := logger.InitLogger(); err != nil {
+		return fmt.Errorf("initialize logger: %w", err)
+	}
+
+	// 初始化数据库连接并组装依赖：仓库 -> 服务 -> 处理器
+	a := app.New(cfg)
+
+	// 从数据库恢复已吊销的访问令牌
+	if err := a.Revocations.Load(); err != nil {
+		a.Close()
+		logger.Close()
+		return fmt.Errorf("load revoked tokens: %w", err)
+	}
+
+	// 加载各角色的权限，签发令牌时写入令牌
+	if err := a.RBAC.Load(); err != nil {
+		a.Close()
+		logger.Close()
+		return fmt.Errorf("load role permissions: %w", err)
+	}
+
+	// 定期从磁盘重新加载签名密钥，keys rotate 后无需重启
+	// 定期从数据库重新加载吊销记录，其他实例上退出登录的令牌在本实例同样失效
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	defer stopWatch()
+	go a.Keys.Watch(watchCtx, cfg.Auth.KeysReloadInterval)
+	go a.Revocations.Watch(watchCtx, service.RevocationReloadInterval)
+
+	servers, err := newServers(cfg, newRouter(a))
+	if err != nil {
+		a.Close()
+		logger.Close()
+		return err
+	}
+	if err := serve(cfg.Server.ShutdownTimeout, servers...); err != nil {
+		log.Println("Server error:", err)
+	}
+
+	// 按顺序释放资源：HTTP请求已全部结束后再关闭数据库连接池，最后刷新并关闭日志
+	a.Close()
+	logger.Close()
+	return nil
+}
+
+// newRouter 创建gin引擎并注册所有路由
+func newRouter(a *app.App) *gin.Engine {
+	cfg := a.Config
+	// 浏览器使用Cookie认证模式时令牌写入HttpOnly Cookie
+	cookies := handler.NewTokenCookies(cfg)
+	userHandler := handler.NewUserHandler(a.AuthService, a.UserService, a.PostService, a.TokenService, a.VerifyService, a.TwoFactor, cookies)
+	twoFactorHandler := handler.NewTwoFactorHandler(a.TwoFactor, a.TokenService, cookies)
+	oidcHandler := handler.NewOIDCHandler(a.OIDC, a.TokenService, a.TwoFactor, cookies, cfg)
+	adminHandler := handler.NewAdminHandler(a.UserService, a.RBAC)
+	sessionHandler := handler.NewSessionHandler(a.SessionService, cookies)
+	tokenHandler := handler.NewTokenHandler(a.TokenService, cookies)
+	personalTokenHandler := handler.NewPersonalTokenHandler(a.PersonalTokenService)
+	resetHandler := handler.NewPasswordResetHandler(a.ResetService)
+	verifyHandler := handler.NewEmailVerificationHandler(a.VerifyService)
+	postHandler := handler.NewPostHandler(a.PostService, cfg)
+	fileHandler := handler.NewFileHandler(cfg)
+	healthHandler := handler.NewHealthHandler(a.DB, cfg, a.Keys)
+	jwksHandler := handler.NewJWKSHandler(a.Keys)
+
+	// 创建gin引擎
+	r := gin.Default()
+
+	// 启用HTTPS时告诉浏览器以后只用HTTPS访问
+	if cfg.TLS.Enabled && cfg.TLS.HSTSMaxAge > 0 {
+		r.Use(middleware.HSTS(cfg.TLS.HSTSMaxAge, cfg.TLS.HSTSIncludeSubdomains))
+	}
+
+	// 配置CORS(跨域资源共享)
+	// 允许的前端源来自配置 cors.allow_origins
+	r.Use(cors.New(cors.Config{
+		AllowOrigins:     cfg.CORS.AllowOrigins,
+		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "Accept", "X-Requested-With", middleware.AuthModeHeader, middleware.CSRFHeader},
+		ExposeHeaders:    []string{"Content-Length", "Retry-After"},
+		AllowCredentials: true,
+		MaxAge:           12 * 60 * 60,
+	}))
+
+	// 静态文件服务
+	// 创建uploads目录（如果不存在）
+	os.MkdirAll(cfg.ImageDir(), 0755)
+	r.Static("/uploads", cfg.Upload.Dir)
+
+	// 前端静态文件服务：优先使用磁盘上的build目录（开发时），其次使用嵌入二进制的编译产物
+	frontendHandler := handler.NewFrontendHandler(frontendFiles(cfg.Server.FrontendDir))
+	if !frontendHandler.Available() {
+		// 开发环境下使用前端开发服务器
+		log.Println("Frontend build not found, using API mode only")
+	}
+
+	// 健康检查和构建信息接口，供负载均衡/编排系统使用
+	r.GET("/healthz", healthHandler.Healthz)
+	r.GET("/readyz", healthHandler.Readyz)
+	r.GET("/version", healthHandler.Version)
+
+	// JWT验签公钥
+	r.GET("/.well-known/jwks.json", jwksHandler.JWKS)
+
+	// 注册和登录接口
+	r.POST("/register", userHandler.Register)
+	r.POST("/login", userHandler.Login)
+	r.POST("/login/2fa", twoFactorHandler.CompleteLogin)
+	r.POST("/token/refresh", tokenHandler.Refresh)
+
+	// 学校统一身份认证登录：是否开启 / 跳转到身份提供方 / 身份提供方回调
+	r.GET("/auth/oidc", oidcHandler.Info)
+	r.GET("/auth/oidc/login", oidcHandler.Login)
+	r.GET("/auth/oidc/callback", oidcHandler.Callback)
+
+	// 忘记密码：发送重置链接 / 使用链接中的令牌设置新密码
+	r.POST("/password/forgot", resetHandler.ForgotPassword)
+	r.POST("/password/reset", resetHandler.ResetPassword)
+
+	// 退出登录：当前设备 / 所有设备
+	r.POST("/logout", a.JWT.Middleware(), tokenHandler.Logout)
+	r.POST("/logout/all", a.JWT.Middleware(), tokenHandler.LogoutAll)
+
+	// 公开的帖子API - 不需要登录也能获取帖子列表
+	r.GET("/api/posts", postHandler.GetAllPosts)
+
+	// 需要认证的路由组，只接受登录得到的访问令牌
+	// 角色要求两步验证但还没有开启的用户只能访问个人资料、开启两步验证和设备管理的接口
+	api := r.Group("/api")
+	api.Use(a.JWT.Middleware())
+	{
+		// 两步验证：状态 / 生成密钥 / 确认开启 / 关闭 / 重新生成恢复码
+		api.GET("/2fa", twoFactorHandler.Status)
+		api.POST("/2fa/setup", twoFactorHandler.Setup)
+		api.POST("/2fa/confirm", twoFactorHandler.Confirm)
+		api.POST("/2fa/disable", twoFactorHandler.Disable)
+		api.POST("/2fa/recovery-codes", twoFactorHandler.RegenerateRecoveryCodes)
+
+		// 登录设备管理：查看会话 / 结束会话（发现可疑登录时，未开启两步验证也可以使用）
+		api.GET("/sessions", sessionHandler.List)
+		api.DELETE("/sessions/:id", sessionHandler.Revoke)
+	}
+
+	authorized := api.Group("")
+	authorized.Use(middleware.RequireMFAEnrollment())
+	{
+		authorized.PUT("/password", userHandler.ChangePassword)
+
+		// 邮箱验证：发送验证码 / 提交验证码
+		authorized.POST("/email/verification", verifyHandler.SendCode)
+		authorized.POST("/email/verify", verifyHandler.Verify)
+
+		// 个人访问令牌：查看 / 创建 / 撤销
+		authorized.GET("/tokens", personalTokenHandler.List)
+		authorized.POST("/tokens", personalTokenHandler.Create)
+		authorized.DELETE("/tokens/:id", personalTokenHandler.Revoke)
+	}
+
+	// 脚本和机器人也可以使用的接口：除了登录得到的访问令牌，还接受带有对应权限范围的个人访问令牌
+	scoped := r.Group("/api")
+	{
+		// 用户资料
+		scoped.GET("/profile", a.JWT.Middleware(model.ScopeProfileRead), userHandler.Profile)
+		scoped.PUT("/profile", a.JWT.Middleware(model.ScopeProfileWrite), middleware.RequireMFAEnrollment(), userHandler.UpdateProfile)
+
+		// 帖子相关API
+		scoped.POST("/posts", a.JWT.Middleware(model.ScopePostsWrite), middleware.RequireMFAEnrollment(), postHandler.CreatePost)
+		scoped.GET("/posts/:id", a.JWT.Middleware(model.ScopePostsRead), middleware.RequireMFAEnrollment(), postHandler.GetPostDetail)
+		scoped.DELETE("/posts/:id", a.JWT.Middleware(model.ScopePostsWrite), middleware.RequireMFAEnrollment(), postHandler.DeletePost)
+		scoped.GET("/user/posts", a.JWT.Middleware(model.ScopePostsRead), middleware.RequireMFAEnrollment(), postHandler.GetUserPosts)
+
+		// 图片上传接口 - 需要登录才能上传图片
+		scoped.POST("/upload/image", a.JWT.Middleware(model.ScopePostsWrite), middleware.RequireMFAEnrollment(), fileHandler.UploadImage)
+	}
+
+	// 管理接口：按令牌中的权限限制访问，版主和管理员必须已开启两步验证
+	admin := authorized.Group("/admin")
+	{
+		// 版主删除违规帖子
+		admin.DELETE("/posts/:id", middleware.RequirePermission(model.PermPostDeleteAny), postHandler.DeletePost)
+
+		// 查看角色 / 授予角色 / 撤销角色（恢复为普通用户）
+		admin.GET("/roles", middleware.RequirePermission(model.PermUserRoleManage), adminHandler.ListRoles)
+		admin.PUT("/users/:username/role", middleware.RequirePermission(model.PermUserRoleManage), adminHandler.GrantRole)
+		admin.DELETE("/users/:username/role", middleware.RequirePermission(model.PermUserRoleManage), adminHandler.RevokeRole)
+	}
+
+	// 公开的图片获取接口 - 不需要登录也能查看图片
+	r.GET("/api/images/:filename", fileHandler.GetImage)
+
+	// 前端路由处理 - 静态文件直接返回，其余未匹配的路由交给前端应用
+	if frontendHandler.Available() {
+		r.NoRoute(frontendHandler.NoRoute)
+	}
+
+	return r
+}
+
+// frontendFiles 选择前端产物来源
+// 磁盘目录存在index.html时使用磁盘（便于开发时重新编译前端后直接生效），否则使用嵌入的产物
+func frontendFiles(dir string) fs.FS {
+	if dir != "" {
+		if _, err := os.Stat(filepath.Join(dir, "index.html")); err == nil {
+			log.Println("Serving frontend from", dir)
+			return os.DirFS(dir)
+		}
+	}
+	if files, ok := frontend.Build(); ok {
+		log.Println("Serving embedded frontend")
+		return files
+	}
+	return nil
+}
+
+// server 一个需要启动并优雅关闭的HTTP服务
+type server struct {
+	*http.Server
+	name   string
+	listen func() error // ListenAndServe 或 ListenAndServeTLS
+}
+
+// newServers 根据配置创建要监听的服务
+// 未启用TLS时只有一个HTTP服务；启用后主服务监听HTTPS，可选地再监听一个HTTP端口跳转到HTTPS
+func newServers(cfg *config.Config, handler http.Handler) ([]server, error) {
+	main := &http.Server{
+		Addr:    cfg.Server.Addr,
+		Handler: handler,
+	}
+	if !cfg.TLS.Enabled {
+		return []server{{Server: main, name: "HTTP", listen: main.ListenAndServe}}, nil
+	}
+
+	// 证书通过GetCertificate提供，文件更新后由Watch自动重新加载
+	reloader, err := certreload.New(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load TLS certificate: %w", err)
+	}
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	go reloader.Watch(watchCtx, cfg.TLS.ReloadInterval)
+	main.RegisterOnShutdown(stopWatch)
+
+	main.TLSConfig = &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.GetCertificate,
+	}
+	servers := []server{{
+		Server: main,
+		name:   "HTTPS",
+		listen: func() error { return main.ListenAndServeTLS("", "") },
+	}}
+
+	if cfg.TLS.RedirectAddr != "" {
+		redirect := &http.Server{
+			Addr:    cfg.TLS.RedirectAddr,
+			Handler: redirectToHTTPS(cfg.Server.Addr),
+		}
+		servers = append(servers, server{Server: redirect, name: "HTTP redirect", listen: redirect.ListenAndServe})
+	}
+	return servers, nil
+}
+
+// redirectToHTTPS 把HTTP请求永久重定向到相同主机的HTTPS地址
+// httpsAddr 为HTTPS监听地址，端口不是443时会带在跳转地址里
+func redirectToHTTPS(httpsAddr string) http.Handler {
+	_, port, _ := net.SplitHostPort(httpsAddr)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+		target := "https://" + host + r.URL.RequestURI()
+		// 308 保留请求方法和请求体，避免POST被浏览器改成GET
+		http.Redirect(w, r, target, http.StatusPermanentRedirect)
+	})
+}
+
+// serve 启动所有服务并在收到SIGINT/SIGTERM时优雅退出
+// 1. 收到信号后停止接受新连接
+// 2. 等待处理中的请求完成，最多等待timeout
+// 3. 超时后强制关闭剩余连接
+// 任一服务监听失败时同样关闭其余服务
+func serve(timeout time.Duration, servers ...server) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errCh := make(chan error, len(servers))
+	var wg sync.WaitGroup
+	for _, srv := range servers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			log.Printf("%s server running at %s", srv.name, srv.Addr)
+			if err := srv.listen(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				errCh <- fmt.Errorf("%s server: %w", srv.name, err)
+			}
+		}()
+	}
+
+	var listenErr error
+	select {
+	case listenErr = <-errCh:
+		// 监听失败（如端口被占用），关闭其余服务
+	case <-ctx.Done():
+	}
+	// 再次收到信号时直接退出
+	stop()
+	log.Println("Shutting down server, draining in-flight requests...")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	var shutdownErr error
+	for _, srv := range servers {
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			// 超时仍有请求未完成，强制关闭
+			srv.Close()
+			shutdownErr = fmt.Errorf("graceful shutdown: %w", err)
+		}
+	}
+	wg.Wait()
+	log.Println("Server stopped.")
+	return errors.Join(listenErr, shutdownErr)
+}
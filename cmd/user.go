@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"my-social-platform/internal/app"
+	"my-social-platform/internal/repository"
+)
+
+// runUser 用户管理命令
+//
+//	user create -username NAME -password PASS [-email EMAIL]
+//	user ban -username NAME
+//	user unban -username NAME
+//	user reset-password -username NAME -password PASS
+//	user role -username NAME -role user|moderator|admin
+//	user reset-2fa -username NAME
+func runUser(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: user create|ban|unban|reset-password|role|reset-2fa -username NAME [-password PASS] [-role ROLE]")
+	}
+	sub, args := args[0], args[1:]
+
+	fs := newFlagSet("user "+sub, "-username NAME [-password PASS]")
+	username := fs.String("username", "", "username")
+	password := fs.String("password", "", "password (create / reset-password)")
+	email := fs.String("email", "", "email address (create, optional)")
+	role := fs.String("role", "", "user, moderator or admin (role)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *username == "" {
+		fs.Usage()
+		return errors.New("-username is required")
+	}
+	needPassword := sub == "create" || sub == "reset-password"
+	if needPassword && *password == "" {
+		fs.Usage()
+		return errors.New("-password is required")
+	}
+	if sub == "role" && *role == "" {
+		fs.Usage()
+		return errors.New("-role is required")
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	a := app.New(cfg)
+	defer a.Close()
+
+	switch sub {
+	case "create":
+		user, err := a.AuthService.Register(*username, *password, *email)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("created user %s (id %d)\n", user.Username, user.ID)
+	case "ban", "unban":
+		if err := a.UserService.SetBanned(*username, sub == "ban"); err != nil {
+			return userError(*username, err)
+		}
+		fmt.Printf("user %s %sned\n", *username, sub)
+	case "reset-password":
+		if err := a.UserService.ResetPassword(*username, *password); err != nil {
+			return userError(*username, err)
+		}
+		fmt.Printf("password of user %s reset\n", *username)
+	case "role":
+		if _, err := a.UserService.SetRole(*username, *role); err != nil {
+			return userError(*username, err)
+		}
+		fmt.Printf("role of user %s set to %s\n", *username, *role)
+	case "reset-2fa":
+		if err := a.TwoFactor.Reset(*username); err != nil {
+			return userError(*username, err)
+		}
+		fmt.Printf("two-factor authentication of user %s reset\n", *username)
+	default:
+		return fmt.Errorf("unknown subcommand %q", sub)
+	}
+	return nil
+}
+
+// userError 把"用户不存在"转换为可读的错误
+func userError(username string, err error) error {
+	if errors.Is(err, repository.ErrNotFound) {
+		return fmt.Errorf("user %q not found", username)
+	}
+	return err
+}
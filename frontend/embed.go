@@ -0,0 +1,22 @@
+//go:build embedfrontend
+
+// Package frontend 把React编译产物嵌入Go二进制
+// 使用 -tags embedfrontend 构建前需要先执行 npm run build 生成build目录
+package frontend
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed all:build
+var build embed.FS
+
+// Build 返回嵌入的前端编译产物（以build目录为根）
+func Build() (fs.FS, bool) {
+	sub, err := fs.Sub(build, "build")
+	if err != nil {
+		return nil, false
+	}
+	return sub, true
+}
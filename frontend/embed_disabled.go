@@ -0,0 +1,12 @@
+//go:build !embedfrontend
+
+// Package frontend 把React编译产物嵌入Go二进制
+// 默认构建不嵌入前端，使用 -tags embedfrontend 构建才会嵌入
+package frontend
+
+import "io/fs"
+
+// Build 未嵌入前端时返回false
+func Build() (fs.FS, bool) {
+	return nil, false
+}
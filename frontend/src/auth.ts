@@ -0,0 +1,17 @@
+import axios from 'axios';
+
+// Cookie认证模式：访问令牌和刷新令牌保存在HttpOnly Cookie中，页面脚本读取不到，XSS也无法窃取
+// csrf_token Cookie可以读取：修改类请求放在 X-CSRF-Token 请求头中带回（双重提交），同时用来判断是否已登录
+// 前端和后端需要部署在同一主机下（开发时都是 localhost），页面才能读到后端设置的Cookie
+
+export const csrfToken = (): string => {
+  const match = document.cookie.match(/(?:^|;\s*)csrf_token=([^;]*)/);
+  return match ? decodeURIComponent(match[1]) : '';
+};
+
+export const isLoggedIn = (): boolean => csrfToken() !== '';
+
+// 退出登录：后端吊销令牌并删除Cookie，失败也不影响本地退出
+export const logout = async (): Promise<void> => {
+  await axios.post('/logout').catch(() => {});
+};
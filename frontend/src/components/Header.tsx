@@ -10,6 +10,7 @@ import {
   LogoutOutlined
 } from '@ant-design/icons';
 import axios from 'axios';
+import { isLoggedIn, logout } from '../auth';
 import './Header.css';
 
 interface User {
@@ -27,17 +28,14 @@ const Header: React.FC = () => {
 
   useEffect(() => {
     // 检查用户是否已登录
-    const token = localStorage.getItem('token');
-    if (token) {
-      fetchUserProfile(token);
+    if (isLoggedIn()) {
+      fetchUserProfile();
     }
   }, []);
 
-  const fetchUserProfile = async (token: string) => {
+  const fetchUserProfile = async () => {
     try {
-      const response = await axios.get('/api/profile', {
-        headers: { Authorization: `Bearer ${token}` }
-      });
+      const response = await axios.get('/api/profile');
       
       setUser({
         id: response.data.user.id,
@@ -47,14 +45,13 @@ const Header: React.FC = () => {
       });
       setIsAuthenticated(true);
     } catch (error) {
-      localStorage.removeItem('token');
       setIsAuthenticated(false);
       setUser(null);
     }
   };
 
   const handleLogout = () => {
-    localStorage.removeItem('token');
+    logout();
     setIsAuthenticated(false);
     setUser(null);
     navigate('/login');
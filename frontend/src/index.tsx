@@ -6,18 +6,24 @@ import './index.css';
 import App from './App';
 import reportWebVitals from './reportWebVitals';
 import axios from 'axios';
+import { csrfToken, isLoggedIn } from './auth';
 
 // 配置axios默认设置
 axios.defaults.baseURL = 'http://localhost:8080';
-// 设置跨域请求允许携带凭证
-axios.defaults.withCredentials = false;
+// 使用Cookie认证模式：跨域请求携带Cookie，登录时请求后端把令牌写入HttpOnly Cookie
+axios.defaults.withCredentials = true;
+axios.defaults.headers.common['X-Auth-Mode'] = 'cookie';
 
-// 添加请求拦截器，自动添加token到请求头
+// 以前的版本把令牌保存在localStorage中，清除残留的令牌
+localStorage.removeItem('token');
+localStorage.removeItem('refresh_token');
+
+// 添加请求拦截器，修改类请求带上CSRF令牌
 axios.interceptors.request.use(
   config => {
-    const token = localStorage.getItem('token');
-    if (token) {
-      config.headers.Authorization = `Bearer ${token}`;
+    const method = (config.method || 'get').toUpperCase();
+    if (!['GET', 'HEAD', 'OPTIONS'].includes(method) && csrfToken()) {
+      config.headers['X-CSRF-Token'] = csrfToken();
     }
     return config;
   },
@@ -26,6 +32,31 @@ axios.interceptors.request.use(
   }
 );
 
+// 添加响应拦截器：访问令牌过期(401)时用刷新令牌Cookie换取新令牌，并重试一次原请求
+let refreshing: Promise<void> | null = null;
+axios.interceptors.response.use(
+  response => response,
+  async error => {
+    const original = error.config;
+    if (error.response?.status !== 401 || !isLoggedIn() || original._retried || original.url === '/token/refresh') {
+      return Promise.reject(error);
+    }
+    original._retried = true;
+    try {
+      // 多个请求同时过期时只刷新一次
+      if (!refreshing) {
+        refreshing = axios.post('/token/refresh').then(() => undefined).finally(() => {
+          refreshing = null;
+        });
+      }
+      await refreshing;
+      return axios(original);
+    } catch (refreshError) {
+      return Promise.reject(error);
+    }
+  }
+);
+
 const root = ReactDOM.createRoot(
   document.getElementById('root') as HTMLElement
 );
@@ -0,0 +1,87 @@
+import React, { useState } from 'react';
+import { useNavigate } from 'react-router-dom';
+import { Form, Input, Button, message } from 'antd';
+import axios from 'axios';
+import './Login.css';
+// @ts-ignore
+import logoImage from '../assets/scut-logo.png';
+
+interface ForgotPasswordForm {
+  email: string;
+}
+
+const ForgotPassword: React.FC = () => {
+  const navigate = useNavigate();
+  const [loading, setLoading] = useState(false);
+  const [sent, setSent] = useState(false);
+
+  const onFinish = async (values: ForgotPasswordForm) => {
+    setLoading(true);
+    try {
+      // 无论邮箱是否注册，后端都返回相同的结果
+      await axios.post('/password/forgot', values);
+      setSent(true);
+    } catch (error) {
+      if (axios.isAxiosError(error) && error.response?.status === 429) {
+        message.error('请求过于频繁，请稍后再试');
+      } else {
+        message.error('发送失败，请稍后重试');
+      }
+      console.error('找回密码错误:', error);
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  return (
+    <div className="login-container">
+      <div className="login-box">
+        <div className="logo-container">
+          <img src={logoImage} alt="华南理工大学校徽" className="school-logo" />
+        </div>
+        <h1>找回密码</h1>
+        {sent ? (
+          <div style={{ textAlign: 'center', marginBottom: '16px' }}>
+            如果该邮箱已注册，重置密码的链接已发送到邮箱，请在有效期内完成重置。
+          </div>
+        ) : (
+          <Form
+            name="forgot-password"
+            onFinish={onFinish}
+            autoComplete="off"
+            layout="vertical"
+          >
+            <Form.Item
+              label="注册时填写的邮箱"
+              name="email"
+              rules={[
+                { required: true, message: '请输入邮箱！' },
+                { type: 'email', message: '邮箱格式不正确！' }
+              ]}
+            >
+              <Input size="large" placeholder="请输入邮箱" />
+            </Form.Item>
+
+            <Form.Item>
+              <Button
+                type="primary"
+                htmlType="submit"
+                size="large"
+                block
+                loading={loading}
+              >
+                发送重置链接
+              </Button>
+            </Form.Item>
+          </Form>
+        )}
+
+        <div className="register-link">
+          想起密码了？<a onClick={() => navigate('/login')}>返回登录</a>
+        </div>
+      </div>
+    </div>
+  );
+};
+
+export default ForgotPassword;
@@ -1,6 +1,7 @@
 import React, { useState, useEffect } from 'react';
 import { useNavigate } from 'react-router-dom';
 import axios from 'axios';
+import { isLoggedIn, logout } from '../auth';
 
 // 定义帖子类型接口
 interface Post {
@@ -38,10 +39,9 @@ const Home: React.FC = () => {
     // 无论是否登录都加载帖子
     fetchPosts();
     
-    const token = localStorage.getItem('token');
-    if (token) {
-      // 已有token，尝试获取用户信息
-      fetchUserProfile(token);
+    if (isLoggedIn()) {
+      // 已登录，获取用户信息
+      fetchUserProfile();
     } else {
       setIsAuthenticated(false);
       setUser(null);
@@ -49,12 +49,10 @@ const Home: React.FC = () => {
   }, []);
 
   // 获取用户资料
-  const fetchUserProfile = async (token: string) => {
+  const fetchUserProfile = async () => {
     try {
       console.log('获取用户资料...');
-      const response = await axios.get('/api/profile', {
-        headers: { Authorization: `Bearer ${token}` }
-      });
+      const response = await axios.get('/api/profile');
       
       console.log('用户资料:', response.data);
       
@@ -67,7 +65,6 @@ const Home: React.FC = () => {
       setIsAuthenticated(true);
     } catch (error) {
       console.error('获取用户资料失败', error);
-      localStorage.removeItem('token'); // 清除无效token
       setIsAuthenticated(false);
       setUser(null);
     }
@@ -302,7 +299,7 @@ const Home: React.FC = () => {
               <button 
                 className="w-full py-1 bg-[#1890ff] text-white rounded hover:bg-[#40a9ff] transition"
                 onClick={() => {
-                  localStorage.removeItem('token');
+                  logout();
                   setIsAuthenticated(false);
                   setUser(null);
                 }}
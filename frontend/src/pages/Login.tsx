@@ -1,5 +1,5 @@
-import React, { useState } from 'react';
-import { useNavigate } from 'react-router-dom';
+import React, { useEffect, useState } from 'react';
+import { useLocation, useNavigate } from 'react-router-dom';
 import { Form, Input, Button, message } from 'antd';
 import axios from 'axios';
 import './Login.css';
@@ -13,27 +13,80 @@ interface LoginForm {
 
 const Login: React.FC = () => {
   const navigate = useNavigate();
+  const location = useLocation();
   const [loading, setLoading] = useState(false);
   const [errorMsg, setErrorMsg] = useState('');
+  // 开启了两步验证时，密码正确后得到的挑战令牌，第二步提交验证码时带上
+  // 统一身份认证登录的用户开启了两步验证时，由 /oidc/callback 页面带过来
+  const [challenge, setChallenge] = useState<string>((location.state as any)?.challenge || '');
+  // 后端开启了统一身份认证时显示对应的登录按钮
+  const [sso, setSso] = useState<{ enabled: boolean; name: string }>({ enabled: false, name: '' });
+
+  useEffect(() => {
+    axios.get('/auth/oidc')
+      .then(response => setSso(response.data))
+      .catch(() => setSso({ enabled: false, name: '' }));
+  }, []);
+
+  // 令牌由后端写入HttpOnly Cookie
+  const onLoggedIn = () => {
+    message.success('登录成功！');
+    navigate('/');
+  };
+
+  // 失败次数过多，Retry-After为需要等待的秒数
+  const showRetryAfter = (headers: any) => {
+    const seconds = Number(headers['retry-after']) || 60;
+    const wait = seconds >= 60 ? `${Math.ceil(seconds / 60)} 分钟` : `${seconds} 秒`;
+    setErrorMsg(`登录失败次数过多，请 ${wait} 后再试`);
+  };
 
   const onFinish = async (values: LoginForm) => {
     setLoading(true);
     try {
       const response = await axios.post('/login', values);
-      if (response.data.token) {
-        localStorage.setItem('token', response.data.token);
-        message.success('登录成功！');
-        navigate('/');
+      if (response.data.mfa_required) {
+        setErrorMsg('');
+        setChallenge(response.data.challenge_token);
+      } else {
+        onLoggedIn();
       }
     } catch (error) {
-      message.error('登录失败，请检查用户名和密码');
-      setErrorMsg('用户名或密码错误');
+      if (axios.isAxiosError(error) && error.response?.status === 429) {
+        showRetryAfter(error.response.headers);
+      } else if (axios.isAxiosError(error) && error.response?.status === 403) {
+        setErrorMsg('该账号已被封禁');
+      } else {
+        message.error('登录失败，请检查用户名和密码');
+        setErrorMsg('用户名或密码错误');
+      }
       console.error('登录错误:', error);
     } finally {
       setLoading(false);
     }
   };
 
+  // 第二步：提交认证器应用中的6位验证码，或一个恢复码
+  const onVerifyCode = async (values: { code: string }) => {
+    setLoading(true);
+    try {
+      await axios.post('/login/2fa', { challenge_token: challenge, code: values.code });
+      onLoggedIn();
+    } catch (error) {
+      if (axios.isAxiosError(error) && error.response?.status === 429) {
+        showRetryAfter(error.response.headers);
+      } else if (axios.isAxiosError(error) && error.response?.data?.error === 'invalid or expired challenge token') {
+        // 挑战令牌过期，回到第一步重新输入密码
+        setChallenge('');
+        setErrorMsg('验证超时，请重新登录');
+      } else {
+        setErrorMsg('验证码错误');
+      }
+    } finally {
+      setLoading(false);
+    }
+  };
+
   return (
     <div className="login-container">
       <div className="login-box">
@@ -41,50 +94,95 @@ const Login: React.FC = () => {
           <img src={logoImage} alt="华南理工大学校徽" className="school-logo" />
         </div>
         <h1>校园智能社交平台</h1>
-        <Form
-          name="login"
-          onFinish={onFinish}
-          autoComplete="off"
-          layout="vertical"
-        >
-          <Form.Item
-            label="用户名"
-            name="username"
-            rules={[{ required: true, message: '请输入用户名！' }]}
+        {challenge ? (
+          <Form
+            name="login-2fa"
+            onFinish={onVerifyCode}
+            autoComplete="off"
+            layout="vertical"
           >
-            <Input size="large" placeholder="请输入用户名" />
-          </Form.Item>
+            <Form.Item
+              label="两步验证"
+              name="code"
+              extra="输入认证器应用中的6位验证码；手机丢失时可以输入一个恢复码"
+              rules={[{ required: true, message: '请输入验证码！' }]}
+            >
+              <Input size="large" placeholder="6位验证码或恢复码" autoFocus />
+            </Form.Item>
 
-          <Form.Item
-            label="密码"
-            name="password"
-            rules={[{ required: true, message: '请输入密码！' }]}
+            <Form.Item>
+              <Button type="primary" htmlType="submit" size="large" block loading={loading}>
+                验证
+              </Button>
+            </Form.Item>
+
+            {errorMsg && (
+              <div className="error-message" style={{ color: 'red', marginBottom: '10px', textAlign: 'center' }}>
+                {errorMsg}
+              </div>
+            )}
+
+            <div className="register-link">
+              <a onClick={() => { setChallenge(''); setErrorMsg(''); }}>返回</a>
+            </div>
+          </Form>
+        ) : (
+          <Form
+            name="login"
+            onFinish={onFinish}
+            autoComplete="off"
+            layout="vertical"
           >
-            <Input.Password size="large" placeholder="请输入密码" />
-          </Form.Item>
-
-          <Form.Item>
-            <Button
-              type="primary"
-              htmlType="submit"
-              size="large"
-              block
-              loading={loading}
+            <Form.Item
+              label="用户名"
+              name="username"
+              rules={[{ required: true, message: '请输入用户名！' }]}
             >
-              登录
-            </Button>
-          </Form.Item>
+              <Input size="large" placeholder="请输入用户名" />
+            </Form.Item>
 
-          {errorMsg && (
-            <div className="error-message" style={{ color: 'red', marginBottom: '10px', textAlign: 'center' }}>
-              {errorMsg}
-            </div>
-          )}
+            <Form.Item
+              label="密码"
+              name="password"
+              rules={[{ required: true, message: '请输入密码！' }]}
+            >
+              <Input.Password size="large" placeholder="请输入密码" />
+            </Form.Item>
+
+            <Form.Item>
+              <Button
+                type="primary"
+                htmlType="submit"
+                size="large"
+                block
+                loading={loading}
+              >
+                登录
+              </Button>
+            </Form.Item>
 
-          <div className="register-link">
-            还没有账号？<a onClick={() => navigate('/register')}>立即注册</a>
-          </div>
-        </Form>
+            {sso.enabled && (
+              <Form.Item>
+                {/* 整页跳转到后端，由后端重定向到身份提供方 */}
+                <Button size="large" block href={`${axios.defaults.baseURL || ''}/auth/oidc/login?mode=cookie`}>
+                  使用{sso.name}登录
+                </Button>
+              </Form.Item>
+            )}
+
+            {errorMsg && (
+              <div className="error-message" style={{ color: 'red', marginBottom: '10px', textAlign: 'center' }}>
+                {errorMsg}
+              </div>
+            )}
+
+            <div className="register-link">
+              还没有账号？<a onClick={() => navigate('/register')}>立即注册</a>
+              <span style={{ margin: '0 8px' }}>|</span>
+              <a onClick={() => navigate('/forgot-password')}>忘记密码</a>
+            </div>
+          </Form>
+        )}
       </div>
     </div>
   );
@@ -0,0 +1,49 @@
+import React, { useEffect, useState } from 'react';
+import { useNavigate } from 'react-router-dom';
+import { Button, Result, Spin, message } from 'antd';
+
+// 后端回调失败时 #error= 的取值
+const errorMessages: Record<string, string> = {
+  invalid_state: '登录请求已失效，请重新登录',
+  access_denied: '已取消统一身份认证登录',
+  no_account: '该身份还没有关联平台账号，请先注册',
+  banned: '该账号已被封禁',
+  sso_unavailable: '统一身份认证暂时不可用，请使用密码登录',
+  sso_failed: '统一身份认证登录失败，请重试',
+};
+
+// 统一身份认证登录完成后后端跳转到这里，结果在URL的#片段中
+const OidcCallback: React.FC = () => {
+  const navigate = useNavigate();
+  const [error, setError] = useState('');
+
+  useEffect(() => {
+    const params = new URLSearchParams(window.location.hash.slice(1));
+    // 立即清除地址栏中的令牌，避免留在浏览器历史里
+    window.history.replaceState(null, '', window.location.pathname);
+
+    if (params.get('auth_mode') === 'cookie') {
+      // 令牌已由后端写入HttpOnly Cookie
+      message.success('登录成功！');
+      navigate('/', { replace: true });
+    } else if (params.get('challenge_token')) {
+      // 开启了两步验证，回到登录页输入验证码
+      navigate('/login', { replace: true, state: { challenge: params.get('challenge_token') } });
+    } else {
+      setError(errorMessages[params.get('error') || ''] || errorMessages.sso_failed);
+    }
+  }, [navigate]);
+
+  if (!error) {
+    return <Spin size="large" style={{ display: 'block', marginTop: 120 }} />;
+  }
+  return (
+    <Result
+      status="warning"
+      title={error}
+      extra={<Button type="primary" onClick={() => navigate('/login', { replace: true })}>返回登录</Button>}
+    />
+  );
+};
+
+export default OidcCallback;
@@ -47,11 +47,9 @@ const PostCreate = () => {
     formData.append('image', file);
     
     try {
-      const token = localStorage.getItem("token");
       const response = await axios.post('/api/upload/image', formData, {
         headers: {
-          'Content-Type': 'multipart/form-data',
-          'Authorization': `Bearer ${token}`
+          'Content-Type': 'multipart/form-data'
         }
       });
       
@@ -86,16 +84,12 @@ const PostCreate = () => {
         .filter(file => file.status === 'done')
         .map(file => file.response?.url || file.response?.data?.url || '');
       
-      const token = localStorage.getItem("token");
       const res = await axios.post(
         "/api/posts",
         {
           content: values.content,
           images: JSON.stringify(uploadedImageUrls), // 将图片URL数组转为JSON字符串
           tag: values.tag,
-        },
-        {
-          headers: { Authorization: `Bearer ${token}` },
         }
       );
       message.success("发布成功！");
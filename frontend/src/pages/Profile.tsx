@@ -1,8 +1,9 @@
 import React, { useState, useEffect } from 'react';
 import { useNavigate } from 'react-router-dom';
 import axios from 'axios';
-import { Avatar, Card, Row, Col, Typography, Tabs, Statistic, Button, Spin, Empty, message } from 'antd';
-import { EditOutlined, HeartOutlined, TeamOutlined, UserOutlined } from '@ant-design/icons';
+import { isLoggedIn } from '../auth';
+import { Avatar, Card, Row, Col, Typography, Tabs, Statistic, Button, Spin, Empty, Tag, message } from 'antd';
+import { EditOutlined, HeartOutlined, SafetyCertificateOutlined, TeamOutlined, UserOutlined } from '@ant-design/icons';
 import './Profile.css';
 
 const { Title, Paragraph } = Typography;
@@ -18,6 +19,7 @@ interface UserProfile {
   follow_count: number;
   fans_count: number;
   like_count: number;
+  student_verified: boolean; // 认证学生
 }
 
 // 帖子接口
@@ -44,8 +46,7 @@ const Profile: React.FC = () => {
 
   // 获取用户资料和帖子
   const fetchProfile = async () => {
-    const token = localStorage.getItem('token');
-    if (!token) {
+    if (!isLoggedIn()) {
       message.error('您未登录，请先登录');
       navigate('/login');
       return;
@@ -53,9 +54,7 @@ const Profile: React.FC = () => {
 
     try {
       setLoading(true);
-      const response = await axios.get('/api/profile', {
-        headers: { Authorization: `Bearer ${token}` }
-      });
+      const response = await axios.get('/api/profile');
 
       setProfile(response.data.user);
       setPosts(response.data.posts || []);
@@ -67,7 +66,6 @@ const Profile: React.FC = () => {
       
       // 如果是401错误，可能是token过期，重定向到登录页
       if (axios.isAxiosError(error) && error.response?.status === 401) {
-        localStorage.removeItem('token');
         navigate('/login');
       }
     }
@@ -131,7 +129,14 @@ const Profile: React.FC = () => {
             />
           </Col>
           <Col xs={24} sm={18}>
-            <Title level={4}>{profile.nickname || profile.username}</Title>
+            <Title level={4}>
+              {profile.nickname || profile.username}
+              {profile.student_verified && (
+                <Tag color="blue" icon={<SafetyCertificateOutlined />} style={{ marginLeft: 8 }}>
+                  认证学生
+                </Tag>
+              )}
+            </Title>
             <Paragraph type="secondary">ID: {profile.id}</Paragraph>
             <Paragraph>{profile.bio || '这个人很懒，什么都没写~'}</Paragraph>
             
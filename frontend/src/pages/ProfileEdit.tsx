@@ -1,6 +1,7 @@
 import React, { useState, useEffect } from 'react';
 import { useNavigate } from 'react-router-dom';
 import axios from 'axios';
+import { csrfToken, isLoggedIn } from '../auth';
 import { 
   Form, 
   Input, 
@@ -9,7 +10,14 @@ import {
   Card, 
   message, 
   Spin, 
-  Avatar 
+  Avatar,
+  QRCode,
+  Alert,
+  List,
+  Tag,
+  Checkbox,
+  Select,
+  Typography
 } from 'antd';
 import { 
   LoadingOutlined, 
@@ -19,6 +27,37 @@ import {
 } from '@ant-design/icons';
 import './ProfileEdit.css';
 
+// 登录会话（设备），见 GET /api/sessions
+interface Session {
+  id: number;
+  device: string;
+  user_agent: string;
+  ip: string;
+  created_at: string;
+  last_seen_at: string;
+  current: boolean;
+}
+
+// 个人访问令牌，见 GET /api/tokens
+interface PersonalToken {
+  id: number;
+  name: string;
+  prefix: string;
+  scopes: string[];
+  created_at: string;
+  expires_at: string | null;
+  last_used_at: string | null;
+  last_used_ip: string;
+  expired: boolean;
+}
+
+const tokenScopes = [
+  { value: 'profile:read', label: '读取个人资料' },
+  { value: 'profile:write', label: '修改个人资料' },
+  { value: 'posts:read', label: '读取帖子' },
+  { value: 'posts:write', label: '发帖和上传图片' },
+];
+
 interface UserProfile {
   id: number;
   username: string;
@@ -29,6 +68,22 @@ interface UserProfile {
 
 const ProfileEdit: React.FC = () => {
   const [form] = Form.useForm();
+  const [passwordForm] = Form.useForm();
+  const [verifyForm] = Form.useForm();
+  const [emailVerified, setEmailVerified] = useState(false);
+  const [studentVerified, setStudentVerified] = useState(false);
+  const [hasEmail, setHasEmail] = useState(false);
+  const [twoFactorForm] = Form.useForm();
+  const [twoFactor, setTwoFactor] = useState({ enabled: false, required: false, recovery_codes_left: 0 });
+  // 开启两步验证过程中的密钥和 otpauth:// 链接，确认后清空
+  const [totpSetup, setTotpSetup] = useState<{ secret: string; otpauth_uri: string } | null>(null);
+  // 恢复码只在开启或重新生成时显示一次
+  const [recoveryCodes, setRecoveryCodes] = useState<string[]>([]);
+  const [sessions, setSessions] = useState<Session[]>([]);
+  const [tokenForm] = Form.useForm();
+  const [tokens, setTokens] = useState<PersonalToken[]>([]);
+  // 新创建的令牌只显示一次
+  const [newToken, setNewToken] = useState<string | null>(null);
   const [loading, setLoading] = useState(true);
   const [uploadLoading, setUploadLoading] = useState(false);
   const [imageUrl, setImageUrl] = useState<string>();
@@ -36,12 +91,77 @@ const ProfileEdit: React.FC = () => {
 
   useEffect(() => {
     fetchUserProfile();
+    fetchTwoFactor();
+    fetchSessions();
+    fetchTokens();
   }, []);
 
+  const fetchTokens = async () => {
+    try {
+      const response = await axios.get('/api/tokens');
+      setTokens(response.data.tokens);
+    } catch (error) {
+      console.error('获取访问令牌失败', error);
+    }
+  };
+
+  const onCreateToken = async (values: { name: string; scopes: string[]; expires_in_days: number }) => {
+    try {
+      const response = await axios.post('/api/tokens', values);
+      setNewToken(response.data.token);
+      tokenForm.resetFields();
+      fetchTokens();
+    } catch (error: any) {
+      message.error(error.response?.data?.error || '创建失败');
+    }
+  };
+
+  const onRevokeToken = async (token: PersonalToken) => {
+    try {
+      await axios.delete(`/api/tokens/${token.id}`);
+      message.success('令牌已撤销');
+      fetchTokens();
+    } catch (error) {
+      message.error('操作失败，请刷新后重试');
+    }
+  };
+
+  const fetchSessions = async () => {
+    try {
+      const response = await axios.get('/api/sessions');
+      setSessions(response.data.sessions);
+    } catch (error) {
+      console.error('获取登录设备失败', error);
+    }
+  };
+
+  // 下线一个设备；下线当前设备相当于退出登录
+  const onRevokeSession = async (session: Session) => {
+    try {
+      await axios.delete(`/api/sessions/${session.id}`);
+      if (session.current) {
+        navigate('/login');
+        return;
+      }
+      message.success('该设备已下线');
+      fetchSessions();
+    } catch (error) {
+      message.error('操作失败，请刷新后重试');
+    }
+  };
+
+  const fetchTwoFactor = async () => {
+    try {
+      const response = await axios.get('/api/2fa');
+      setTwoFactor(response.data);
+    } catch (error) {
+      console.error('获取两步验证状态失败', error);
+    }
+  };
+
   // 获取用户资料
   const fetchUserProfile = async () => {
-    const token = localStorage.getItem('token');
-    if (!token) {
+    if (!isLoggedIn()) {
       message.error('您未登录，请先登录');
       navigate('/login');
       return;
@@ -49,9 +169,7 @@ const ProfileEdit: React.FC = () => {
 
     try {
       setLoading(true);
-      const response = await axios.get('/api/profile', {
-        headers: { Authorization: `Bearer ${token}` }
-      });
+      const response = await axios.get('/api/profile');
 
       const user = response.data.user;
       setImageUrl(user.avatar);
@@ -59,8 +177,12 @@ const ProfileEdit: React.FC = () => {
       // 设置表单初始值
       form.setFieldsValue({
         nickname: user.nickname,
-        bio: user.bio
+        bio: user.bio,
+        email: user.email
       });
+      setHasEmail(!!user.email);
+      setEmailVerified(user.email_verified);
+      setStudentVerified(user.student_verified);
       
       setLoading(false);
     } catch (error) {
@@ -69,7 +191,6 @@ const ProfileEdit: React.FC = () => {
       setLoading(false);
       
       if (axios.isAxiosError(error) && error.response?.status === 401) {
-        localStorage.removeItem('token');
         navigate('/login');
       }
     }
@@ -77,19 +198,15 @@ const ProfileEdit: React.FC = () => {
 
   // 提交表单
   const onFinish = async (values: any) => {
-    const token = localStorage.getItem('token');
-    if (!token) {
+    if (!isLoggedIn()) {
       message.error('您未登录，请先登录');
       navigate('/login');
       return;
     }
 
     try {
-      const { nickname, bio } = values;
-      await axios.put('/api/profile', 
-        { nickname, bio, avatar: imageUrl },
-        { headers: { Authorization: `Bearer ${token}` } }
-      );
+      const { nickname, bio, email } = values;
+      await axios.put('/api/profile', { nickname, bio, email, avatar: imageUrl });
       
       message.success('个人资料更新成功');
       navigate('/profile');
@@ -99,6 +216,111 @@ const ProfileEdit: React.FC = () => {
     }
   };
 
+  // 修改密码：成功后其他设备的登录失效，当前页面的新令牌由后端写入Cookie
+  const onChangePassword = async (values: any) => {
+    try {
+      await axios.put('/api/password', {
+        current_password: values.currentPassword,
+        new_password: values.newPassword,
+      });
+      passwordForm.resetFields();
+      message.success('密码修改成功，其他设备需要重新登录');
+    } catch (error) {
+      if (axios.isAxiosError(error) && error.response?.status === 403) {
+        message.error('当前密码不正确');
+      } else if (axios.isAxiosError(error) && error.response?.status === 400) {
+        message.error('新密码至少8位，且需同时包含字母和数字');
+      } else {
+        message.error('修改密码失败');
+      }
+    }
+  };
+
+  // 重新发送邮箱验证码
+  const onSendCode = async () => {
+    try {
+      await axios.post('/api/email/verification');
+      message.success('验证码已发送，请查收邮件');
+    } catch (error) {
+      if (axios.isAxiosError(error) && error.response?.status === 429) {
+        message.error('发送太频繁，请1分钟后再试');
+      } else {
+        message.error('发送验证码失败');
+      }
+    }
+  };
+
+  // 提交邮箱验证码
+  const onVerifyEmail = async (values: any) => {
+    try {
+      const response = await axios.post('/api/email/verify', { code: values.code });
+      setEmailVerified(true);
+      setStudentVerified(response.data.user.student_verified);
+      verifyForm.resetFields();
+      message.success(response.data.user.student_verified ? '验证成功，你已成为认证学生' : '邮箱验证成功');
+    } catch (error) {
+      message.error('验证码错误或已过期');
+    }
+  };
+
+  // 两步验证第一步：输入当前密码，生成密钥和二维码
+  const onSetupTwoFactor = async (values: any) => {
+    try {
+      const response = await axios.post('/api/2fa/setup', { password: values.password });
+      setTotpSetup(response.data);
+      twoFactorForm.resetFields();
+    } catch (error) {
+      if (axios.isAxiosError(error) && error.response?.status === 403) {
+        message.error('密码不正确');
+      } else {
+        message.error('开启两步验证失败');
+      }
+    }
+  };
+
+  // 两步验证第二步：输入认证器应用中的验证码确认；成功后其他设备需要重新登录
+  const onConfirmTwoFactor = async (values: any) => {
+    try {
+      const response = await axios.post('/api/2fa/confirm', { code: values.code });
+      setRecoveryCodes(response.data.recovery_codes);
+      setTotpSetup(null);
+      twoFactorForm.resetFields();
+      fetchTwoFactor();
+      message.success('两步验证已开启，其他设备需要重新登录');
+    } catch (error) {
+      message.error('验证码错误，请确认手机时间准确');
+    }
+  };
+
+  const onDisableTwoFactor = async (values: any) => {
+    try {
+      await axios.post('/api/2fa/disable', { password: values.password, code: values.code });
+      twoFactorForm.resetFields();
+      setRecoveryCodes([]);
+      fetchTwoFactor();
+      message.success('两步验证已关闭');
+    } catch (error) {
+      if (axios.isAxiosError(error) && error.response?.data?.code === 'mfa_required_for_role') {
+        message.error('你的角色必须开启两步验证，不能关闭');
+      } else {
+        message.error('密码或验证码错误');
+      }
+    }
+  };
+
+  const onRegenerateRecoveryCodes = async () => {
+    try {
+      const code = twoFactorForm.getFieldValue('code');
+      const response = await axios.post('/api/2fa/recovery-codes', { code });
+      setRecoveryCodes(response.data.recovery_codes);
+      twoFactorForm.resetFields();
+      fetchTwoFactor();
+      message.success('已生成新的恢复码，原有恢复码作废');
+    } catch (error) {
+      message.error('请先在验证码输入框中填写正确的6位验证码');
+    }
+  };
+
   // 上传头像前的检查
   const beforeUpload = (file: File) => {
     const isJpgOrPng = file.type === 'image/jpeg' || file.type === 'image/png';
@@ -192,7 +414,8 @@ const ProfileEdit: React.FC = () => {
             <Upload
               name="image"
               action="/api/upload/image"
-              headers={{ Authorization: `Bearer ${localStorage.getItem('token') || ''}` }}
+              headers={{ 'X-CSRF-Token': csrfToken() }}
+              withCredentials
               showUploadList={false}
               beforeUpload={beforeUpload}
               onChange={handleChange}
@@ -223,6 +446,15 @@ const ProfileEdit: React.FC = () => {
             />
           </Form.Item>
 
+          <Form.Item
+            name="email"
+            label="邮箱"
+            extra="使用学校邮箱验证后可获得认证学生标识，修改邮箱后需要重新验证"
+            rules={[{ type: 'email', message: '邮箱格式不正确' }]}
+          >
+            <Input placeholder="用于找回密码和学生认证" />
+          </Form.Item>
+
           <Form.Item>
             <Button type="primary" htmlType="submit" block>
               保存修改
@@ -230,6 +462,227 @@ const ProfileEdit: React.FC = () => {
           </Form.Item>
         </Form>
       </Card>
+
+      {hasEmail && (
+        <Card title="邮箱验证" style={{ marginTop: 16 }}>
+          {emailVerified ? (
+            <div>{studentVerified ? '邮箱已验证，你是认证学生' : '邮箱已验证（非学校邮箱，未获得学生认证）'}</div>
+          ) : (
+            <Form
+              form={verifyForm}
+              layout="inline"
+              onFinish={onVerifyEmail}
+            >
+              <Form.Item
+                name="code"
+                rules={[{ required: true, message: '请输入验证码' }]}
+              >
+                <Input placeholder="6位验证码" maxLength={6} />
+              </Form.Item>
+              <Form.Item>
+                <Button type="primary" htmlType="submit">验证</Button>
+              </Form.Item>
+              <Form.Item>
+                <Button onClick={onSendCode}>重新发送</Button>
+              </Form.Item>
+            </Form>
+          )}
+        </Card>
+      )}
+
+      <Card title="两步验证" style={{ marginTop: 16 }}>
+        {twoFactor.required && !twoFactor.enabled && (
+          <Alert
+            type="warning"
+            showIcon
+            style={{ marginBottom: 16 }}
+            message="你的账号角色要求开启两步验证，开启前无法使用其他功能"
+          />
+        )}
+
+        {recoveryCodes.length > 0 && (
+          <Alert
+            type="info"
+            style={{ marginBottom: 16 }}
+            message="请妥善保存以下恢复码，每个只能使用一次，关闭本页后不会再显示"
+            description={<pre style={{ margin: 0 }}>{recoveryCodes.join('\n')}</pre>}
+          />
+        )}
+
+        {twoFactor.enabled ? (
+          <Form form={twoFactorForm} layout="vertical" onFinish={onDisableTwoFactor}>
+            <p>两步验证已开启，剩余 {twoFactor.recovery_codes_left} 个恢复码。</p>
+            <Form.Item name="password" label="当前密码">
+              <Input.Password />
+            </Form.Item>
+            <Form.Item name="code" label="验证码" rules={[{ required: true, message: '请输入验证码' }]}>
+              <Input placeholder="6位验证码或恢复码" />
+            </Form.Item>
+            <Form.Item>
+              <Button onClick={onRegenerateRecoveryCodes} style={{ marginRight: 8 }}>重新生成恢复码</Button>
+              {!twoFactor.required && (
+                <Button danger htmlType="submit">关闭两步验证</Button>
+              )}
+            </Form.Item>
+          </Form>
+        ) : totpSetup ? (
+          <Form form={twoFactorForm} layout="vertical" onFinish={onConfirmTwoFactor}>
+            <p>使用 Google Authenticator、Microsoft Authenticator 等应用扫描二维码，或手动输入密钥：</p>
+            <QRCode value={totpSetup.otpauth_uri} style={{ marginBottom: 8 }} />
+            <p><code>{totpSetup.secret}</code></p>
+            <Form.Item name="code" label="验证码" rules={[{ required: true, message: '请输入验证码' }]}>
+              <Input placeholder="应用中显示的6位验证码" maxLength={6} />
+            </Form.Item>
+            <Form.Item>
+              <Button type="primary" htmlType="submit">确认开启</Button>
+            </Form.Item>
+          </Form>
+        ) : (
+          <Form form={twoFactorForm} layout="vertical" onFinish={onSetupTwoFactor}>
+            <p>开启后登录时除了密码还需要输入手机认证器应用中的验证码。</p>
+            <Form.Item name="password" label="当前密码" rules={[{ required: true, message: '请输入当前密码' }]}>
+              <Input.Password />
+            </Form.Item>
+            <Form.Item>
+              <Button type="primary" htmlType="submit">开启两步验证</Button>
+            </Form.Item>
+          </Form>
+        )}
+      </Card>
+
+      <Card title="登录设备" style={{ marginTop: 16 }}>
+        <List
+          dataSource={sessions}
+          renderItem={(session) => (
+            <List.Item
+              actions={[
+                <Button key="revoke" danger size="small" onClick={() => onRevokeSession(session)}>
+                  {session.current ? '退出登录' : '下线'}
+                </Button>,
+              ]}
+            >
+              <List.Item.Meta
+                title={<span title={session.user_agent}>{session.device} {session.current && <Tag color="green">当前设备</Tag>}</span>}
+                description={`IP ${session.ip} · 登录于 ${new Date(session.created_at).toLocaleString()} · 最近活动 ${new Date(session.last_seen_at).toLocaleString()}`}
+              />
+            </List.Item>
+          )}
+        />
+      </Card>
+
+      <Card title="个人访问令牌" style={{ marginTop: 16 }}>
+        <p>供脚本和机器人调用接口，请求时放在 <code>Authorization: Bearer</code> 请求头中。令牌只能访问勾选的权限范围。</p>
+        {newToken && (
+          <Alert
+            type="success"
+            closable
+            onClose={() => setNewToken(null)}
+            style={{ marginBottom: 16 }}
+            message="令牌已创建，请立即复制保存，关闭后将无法再次查看"
+            description={<Typography.Text code copyable>{newToken}</Typography.Text>}
+          />
+        )}
+        <List
+          dataSource={tokens}
+          locale={{ emptyText: '还没有创建令牌' }}
+          renderItem={(token) => (
+            <List.Item
+              actions={[
+                <Button key="revoke" danger size="small" onClick={() => onRevokeToken(token)}>撤销</Button>,
+              ]}
+            >
+              <List.Item.Meta
+                title={<span>{token.name} <Typography.Text code>{token.prefix}…</Typography.Text> {token.expired && <Tag color="red">已过期</Tag>}</span>}
+                description={
+                  <>
+                    <div>{token.scopes.map((scope) => <Tag key={scope}>{scope}</Tag>)}</div>
+                    <div>
+                      {token.expires_at ? `有效期至 ${new Date(token.expires_at).toLocaleString()}` : '永不过期'}
+                      {' · '}
+                      {token.last_used_at ? `最近使用 ${new Date(token.last_used_at).toLocaleString()}（IP ${token.last_used_ip}）` : '从未使用'}
+                    </div>
+                  </>
+                }
+              />
+            </List.Item>
+          )}
+        />
+        <Form form={tokenForm} layout="vertical" onFinish={onCreateToken} initialValues={{ expires_in_days: 90, scopes: ['posts:write'] }}>
+          <Form.Item name="name" label="名称" rules={[{ required: true, message: '请输入令牌名称' }, { max: 64, message: '名称最多64个字符' }]}>
+            <Input placeholder="例如：活动公告机器人" />
+          </Form.Item>
+          <Form.Item name="scopes" label="权限范围" rules={[{ required: true, message: '请至少选择一个权限范围' }]}>
+            <Checkbox.Group options={tokenScopes} />
+          </Form.Item>
+          <Form.Item name="expires_in_days" label="有效期">
+            <Select
+              options={[
+                { value: 7, label: '7天' },
+                { value: 30, label: '30天' },
+                { value: 90, label: '90天' },
+                { value: 365, label: '1年' },
+                { value: 0, label: '永不过期' },
+              ]}
+            />
+          </Form.Item>
+          <Form.Item>
+            <Button type="primary" htmlType="submit">创建令牌</Button>
+          </Form.Item>
+        </Form>
+      </Card>
+
+      <Card title="修改密码" style={{ marginTop: 16 }}>
+        <Form
+          form={passwordForm}
+          layout="vertical"
+          onFinish={onChangePassword}
+        >
+          <Form.Item
+            name="currentPassword"
+            label="当前密码"
+            rules={[{ required: true, message: '请输入当前密码' }]}
+          >
+            <Input.Password />
+          </Form.Item>
+
+          <Form.Item
+            name="newPassword"
+            label="新密码"
+            rules={[
+              { required: true, message: '请输入新密码' },
+              { min: 8, message: '新密码至少8位' },
+              { pattern: /(?=.*[A-Za-z])(?=.*\d)/, message: '新密码需同时包含字母和数字' },
+            ]}
+          >
+            <Input.Password />
+          </Form.Item>
+
+          <Form.Item
+            name="confirmPassword"
+            label="确认新密码"
+            dependencies={['newPassword']}
+            rules={[
+              { required: true, message: '请再次输入新密码' },
+              ({ getFieldValue }) => ({
+                validator(_, value) {
+                  if (!value || getFieldValue('newPassword') === value) {
+                    return Promise.resolve();
+                  }
+                  return Promise.reject(new Error('两次输入的密码不一致'));
+                },
+              }),
+            ]}
+          >
+            <Input.Password />
+          </Form.Item>
+
+          <Form.Item>
+            <Button type="primary" htmlType="submit" block>
+              修改密码
+            </Button>
+          </Form.Item>
+        </Form>
+      </Card>
     </div>
   );
 };
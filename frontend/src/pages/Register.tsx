@@ -8,12 +8,14 @@ import logoImage from '../assets/scut-logo.png';
 
 interface RegisterForm {
   username: string;
+  email?: string;
   password: string;
   confirmPassword: string;
 }
 
 const Register: React.FC = () => {
   const navigate = useNavigate();
+  const [form] = Form.useForm();
   const [loading, setLoading] = useState(false);
 
   const onFinish = async (values: RegisterForm) => {
@@ -26,14 +28,31 @@ const Register: React.FC = () => {
     try {
       const response = await axios.post('http://localhost:8080/register', {
         username: values.username,
-        password: values.password
+        password: values.password,
+        email: values.email
       });
       if (response.data.user) {
         message.success('注册成功！');
         navigate('/');
       }
     } catch (error) {
-      message.error('注册失败，请重试');
+      // 400/409 时后端在fields中返回每个字段的错误，显示在对应输入框下
+      const fields = axios.isAxiosError(error) ? error.response?.data?.fields : undefined;
+      if (fields) {
+        const messages: Record<string, string> = {
+          'username is already taken': '该用户名已被注册',
+          'email is already in use': '该邮箱已被其他账号使用',
+          'is reserved': '该用户名为系统保留，请换一个',
+          'is too common': '密码过于常见，请换一个更安全的密码',
+          'must not contain the username': '密码不能包含用户名',
+        };
+        form.setFields(Object.keys(fields).map((name) => ({
+          name,
+          errors: [messages[fields[name]] || fields[name]],
+        })));
+      } else {
+        message.error('注册失败，请重试');
+      }
     } finally {
       setLoading(false);
     }
@@ -47,6 +66,7 @@ const Register: React.FC = () => {
         </div>
         <h1>校园智能社交平台</h1>
         <Form
+          form={form}
           name="register"
           onFinish={onFinish}
           autoComplete="off"
@@ -55,17 +75,33 @@ const Register: React.FC = () => {
           <Form.Item
             label="用户名"
             name="username"
-            rules={[{ required: true, message: '请输入用户名！' }]}
+            rules={[
+              { required: true, message: '请输入用户名！' },
+              { min: 3, max: 20, message: '用户名长度为3到20个字符！' },
+              { pattern: /^[A-Za-z0-9_]+$/, message: '用户名只能包含字母、数字和下划线！' }
+            ]}
+          >
+            <Input size="large" placeholder="字母、数字或下划线，3到20个字符" />
+          </Form.Item>
+
+          <Form.Item
+            label="邮箱（选填，用于找回密码）"
+            name="email"
+            rules={[{ type: 'email', message: '邮箱格式不正确！' }]}
           >
-            <Input size="large" placeholder="请输入用户名" />
+            <Input size="large" placeholder="请输入邮箱" />
           </Form.Item>
 
           <Form.Item
             label="密码"
             name="password"
-            rules={[{ required: true, message: '请输入密码！' }]}
+            rules={[
+              { required: true, message: '请输入密码！' },
+              { min: 8, message: '密码至少8位！' },
+              { pattern: /(?=.*[A-Za-z])(?=.*\d)/, message: '密码需同时包含字母和数字！' }
+            ]}
           >
-            <Input.Password size="large" placeholder="请输入密码" />
+            <Input.Password size="large" placeholder="至少8位，包含字母和数字" />
           </Form.Item>
 
           <Form.Item
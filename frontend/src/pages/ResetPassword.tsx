@@ -0,0 +1,108 @@
+import React, { useState } from 'react';
+import { useNavigate, useSearchParams } from 'react-router-dom';
+import { Form, Input, Button, message } from 'antd';
+import axios from 'axios';
+import './Login.css';
+// @ts-ignore
+import logoImage from '../assets/scut-logo.png';
+
+interface ResetPasswordForm {
+  new_password: string;
+  confirmPassword: string;
+}
+
+const ResetPassword: React.FC = () => {
+  const navigate = useNavigate();
+  const [searchParams] = useSearchParams();
+  const [loading, setLoading] = useState(false);
+  const token = searchParams.get('token') || '';
+
+  const onFinish = async (values: ResetPasswordForm) => {
+    setLoading(true);
+    try {
+      await axios.post('/password/reset', {
+        token,
+        new_password: values.new_password
+      });
+      // 重置后所有设备都已退出登录
+      message.success('密码已重置，请使用新密码登录');
+      navigate('/login');
+    } catch (error: any) {
+      message.error(error.response?.data?.error || '重置失败，链接可能已失效');
+      console.error('重置密码错误:', error);
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  return (
+    <div className="login-container">
+      <div className="login-box">
+        <div className="logo-container">
+          <img src={logoImage} alt="华南理工大学校徽" className="school-logo" />
+        </div>
+        <h1>重置密码</h1>
+        {!token ? (
+          <div style={{ textAlign: 'center', marginBottom: '16px' }}>
+            重置链接无效，请重新<a onClick={() => navigate('/forgot-password')}>申请找回密码</a>
+          </div>
+        ) : (
+          <Form
+            name="reset-password"
+            onFinish={onFinish}
+            autoComplete="off"
+            layout="vertical"
+          >
+            <Form.Item
+              label="新密码"
+              name="new_password"
+              rules={[
+                { required: true, message: '请输入新密码！' },
+                { min: 8, message: '密码至少8个字符！' }
+              ]}
+            >
+              <Input.Password size="large" placeholder="至少8位，包含字母和数字" />
+            </Form.Item>
+
+            <Form.Item
+              label="确认新密码"
+              name="confirmPassword"
+              dependencies={['new_password']}
+              rules={[
+                { required: true, message: '请确认新密码！' },
+                ({ getFieldValue }) => ({
+                  validator(_, value) {
+                    if (!value || getFieldValue('new_password') === value) {
+                      return Promise.resolve();
+                    }
+                    return Promise.reject(new Error('两次输入的密码不一致！'));
+                  },
+                }),
+              ]}
+            >
+              <Input.Password size="large" placeholder="请再次输入新密码" />
+            </Form.Item>
+
+            <Form.Item>
+              <Button
+                type="primary"
+                htmlType="submit"
+                size="large"
+                block
+                loading={loading}
+              >
+                重置密码
+              </Button>
+            </Form.Item>
+          </Form>
+        )}
+
+        <div className="register-link">
+          <a onClick={() => navigate('/login')}>返回登录</a>
+        </div>
+      </div>
+    </div>
+  );
+};
+
+export default ResetPassword;
@@ -0,0 +1,124 @@
+package app
+
+import (
+	"log"
+	"my-social-platform/internal/config"
+	"my-social-platform/internal/middleware"
+	"my-social-platform/internal/pkg/keystore"
+	"my-social-platform/internal/pkg/mail"
+	"my-social-platform/internal/pkg/oidc"
+	"my-social-platform/internal/repository"
+	"my-social-platform/internal/service"
+
+	"gorm.io/gorm"
+)
+
+// App 应用的依赖集合：配置、数据库连接、仓库和服务
+// serve、seed、user 等命令共用同一套组装逻辑，避免各自初始化
+type App struct {
+	Config *config.Config
+	DB     *gorm.DB
+
+	Users          repository.UserRepository
+	Posts          repository.PostRepository
+	Comments       repository.CommentRepository
+	RefreshTokens  repository.RefreshTokenRepository
+	RevokedTokens  repository.RevokedTokenRepository
+	Resets         repository.PasswordResetRepository
+	Verifications  repository.EmailVerificationRepository
+	RecoveryCodes  repository.RecoveryCodeRepository
+	Identities     repository.UserIdentityRepository
+	Roles          repository.RoleRepository
+	Sessions       repository.SessionRepository
+	PersonalTokens repository.PersonalAccessTokenRepository
+
+	Mailer mail.Mailer
+
+	Keys *keystore.Store
+	JWT  *middleware.JWTManager
+
+	AuthService          *service.AuthService
+	UserService          *service.UserService
+	PostService          *service.PostService
+	Revocations          *service.RevocationService
+	TokenService         *service.TokenService
+	ResetService         *service.PasswordResetService
+	VerifyService        *service.EmailVerificationService
+	TwoFactor            *service.TwoFactorService
+	RBAC                 *service.RBACService
+	SessionService       *service.SessionService
+	PersonalTokenService *service.PersonalTokenService
+	// OIDC 没有开启统一身份认证（oidc.enabled）时为nil
+	OIDC *service.OIDCService
+}
+
+// New 初始化数据库连接（按配置自动迁移）、签名密钥和邮件发送，并组装仓库和服务
+func New(cfg *config.Config) *App {
+	db := repository.InitDB(cfg.Database)
+	keys, err := keystore.LoadOrCreate(cfg.Auth.KeysDir)
+	if err != nil {
+		log.Fatal("Failed to load JWT signing keys:", err)
+	}
+	mailer, err := mail.New(cfg.Mail)
+	if err != nil {
+		log.Fatal("Failed to create mailer:", err)
+	}
+	return Wire(cfg, db, keys, mailer)
+}
+
+// Wire 用已有的数据库连接、密钥和Mailer组装仓库和服务，测试中由 apptest.New 传入SQLite内存数据库
+func Wire(cfg *config.Config, db *gorm.DB, keys *keystore.Store, mailer mail.Mailer) *App {
+	a := &App{
+		Config: cfg,
+		DB:     db,
+		Keys:   keys,
+		Mailer: mailer,
+
+		Users:          repository.NewUserRepository(db),
+		Posts:          repository.NewPostRepository(db),
+		Comments:       repository.NewCommentRepository(db),
+		RefreshTokens:  repository.NewRefreshTokenRepository(db),
+		RevokedTokens:  repository.NewRevokedTokenRepository(db),
+		Resets:         repository.NewPasswordResetRepository(db),
+		Verifications:  repository.NewEmailVerificationRepository(db),
+		RecoveryCodes:  repository.NewRecoveryCodeRepository(db),
+		Identities:     repository.NewUserIdentityRepository(db),
+		Roles:          repository.NewRoleRepository(db),
+		Sessions:       repository.NewSessionRepository(db),
+		PersonalTokens: repository.NewPersonalAccessTokenRepository(db),
+	}
+
+	// 密码和两步验证码共用同一个LoginGuard统计失败次数
+	guard := service.NewLoginGuard(cfg.Auth)
+	usernames := service.NewUsernamePolicy(cfg.Auth)
+	a.AuthService = service.NewAuthService(a.Users, guard, usernames)
+	a.PostService = service.NewPostService(a.Posts, a.Users, cfg.Auth.PostRequires)
+	a.Revocations = service.NewRevocationService(a.RevokedTokens, a.Users)
+	a.RBAC = service.NewRBACService(a.Roles)
+	a.SessionService = service.NewSessionService(a.Sessions, a.RefreshTokens)
+	a.PersonalTokenService = service.NewPersonalTokenService(a.PersonalTokens, a.Users)
+	a.JWT = middleware.NewJWTManager(cfg.Auth, keys, a.Revocations, a.SessionService, a.RBAC, a.PersonalTokenService)
+	a.TokenService = service.NewTokenService(a.RefreshTokens, a.Users, a.JWT, a.Revocations, a.SessionService, a.PersonalTokenService, cfg.Auth.RefreshTokenTTL)
+	a.UserService = service.NewUserService(a.Users, a.Roles, a.TokenService)
+	a.ResetService = service.NewPasswordResetService(a.Users, a.Resets, a.TokenService, mailer, service.ResetLink(cfg.FrontendLink), cfg.Auth.PasswordResetTTL, service.NewLoginGuard(cfg.Auth))
+	a.VerifyService = service.NewEmailVerificationService(a.Users, a.Verifications, mailer, cfg.Auth.EmailVerificationTTL, cfg.Auth.StudentEmailDomains)
+	a.TwoFactor = service.NewTwoFactorService(a.Users, a.RecoveryCodes, a.JWT, guard, a.TokenService, cfg.Auth.TOTPIssuer)
+	if cfg.OIDC.Enabled {
+		// 发现文档和公钥在第一次登录时才获取，身份提供方暂时不可用不影响启动
+		client := oidc.New(oidc.Config{
+			Issuer:       cfg.OIDC.Issuer,
+			ClientID:     cfg.OIDC.ClientID,
+			ClientSecret: cfg.OIDC.ClientSecret,
+			RedirectURL:  cfg.OIDCRedirectURL(),
+			Scopes:       cfg.OIDC.Scopes,
+			ClockSkew:    cfg.Auth.ClockSkew,
+		})
+		a.OIDC = service.NewOIDCService(client, a.Users, a.Identities, usernames, a.VerifyService, cfg.OIDC)
+	}
+	return a
+}
+
+// Close 关闭数据库连接
+func (a *App) Close() {
+	repository.CloseDB(a.DB)
+}
@@ -0,0 +1,121 @@
+// Package apptest 测试用的应用组装：SQLite内存数据库、临时目录中的签名密钥和记录邮件的Mailbox
+//
+// 每次调用New得到一个独立的数据库，测试之间互不影响：
+//
+//	a := apptest.New(t)
+//	alice := a.CreateUser(t, "alice", "Spring2025x")
+//	pair, err := a.TokenService.Issue(alice, service.ClientInfo{IP: "127.0.0.1"})
+package apptest
+
+import (
+	"context"
+	"my-social-platform/internal/app"
+	"my-social-platform/internal/config"
+	"my-social-platform/internal/model"
+	"my-social-platform/internal/pkg/keystore"
+	"my-social-platform/internal/pkg/mail"
+	"my-social-platform/internal/repository"
+	"my-social-platform/internal/repository/migrations"
+	"my-social-platform/internal/service"
+	"sync"
+	"testing"
+
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// App 组装好的应用，Mailbox 记录所有发送的邮件
+type App struct {
+	*app.App
+	Mailbox *Mailbox
+}
+
+// Config 测试使用的配置：默认配置加上SQLite内存数据库，关闭角色的两步验证要求
+func Config(t testing.TB) *config.Config {
+	cfg := config.Default()
+	cfg.Database = config.DatabaseConfig{Driver: config.DriverSQLite, DSN: ":memory:"}
+	cfg.Auth.KeysDir = t.TempDir()
+	cfg.Auth.Require2FARoles = nil
+	return cfg
+}
+
+// New 创建应用并执行全部迁移；configure 可以在组装前修改配置
+// 和serve命令一样在启动时加载吊销记录和角色权限
+func New(t testing.TB, configure ...func(*config.Config)) *App {
+	t.Helper()
+	cfg := Config(t)
+	for _, f := range configure {
+		f(cfg)
+	}
+
+	db, err := repository.Open(cfg.Database)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	// 测试中经常查询不存在的记录，不输出SQL日志
+	db.Logger = gormlogger.Discard
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	if _, err := migrations.Up(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	keys, err := keystore.LoadOrCreate(cfg.Auth.KeysDir)
+	if err != nil {
+		t.Fatalf("create keys: %v", err)
+	}
+
+	mailbox := &Mailbox{}
+	a := app.Wire(cfg, db, keys, mailbox)
+	if err := a.Revocations.Load(); err != nil {
+		t.Fatalf("load revocations: %v", err)
+	}
+	if err := a.RBAC.Load(); err != nil {
+		t.Fatalf("load roles: %v", err)
+	}
+	return &App{App: a, Mailbox: mailbox}
+}
+
+// CreateUser 注册一个用户并返回数据库中的记录
+func (a *App) CreateUser(t testing.TB, username, password string) *model.User {
+	t.Helper()
+	if _, err := a.AuthService.Register(username, password, ""); err != nil {
+		t.Fatalf("register %s: %v", username, err)
+	}
+	return a.User(t, username)
+}
+
+// User 重新从数据库读取用户
+func (a *App) User(t testing.TB, username string) *model.User {
+	t.Helper()
+	user, err := a.Users.GetByUsername(username)
+	if err != nil {
+		t.Fatalf("get user %s: %v", username, err)
+	}
+	return user
+}
+
+// Client 测试请求使用的客户端信息
+var Client = service.ClientInfo{IP: "192.0.2.1", UserAgent: "Mozilla/5.0 (Windows NT 10.0) Chrome/126.0"}
+
+// Mailbox 把邮件保存在内存中的Mailer
+type Mailbox struct {
+	mu       sync.Mutex
+	messages []mail.Message
+}
+
+// Send 记录邮件
+func (m *Mailbox) Send(ctx context.Context, msg mail.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+// Messages 已发送的全部邮件
+func (m *Mailbox) Messages() []mail.Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]mail.Message(nil), m.messages...)
+}
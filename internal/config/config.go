@@ -0,0 +1,662 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultPath 默认配置文件路径（相对于启动目录）
+const DefaultPath = "config.yaml"
+
+// Config 应用的全部配置
+// 先填充默认值，再读取YAML配置文件，最后用环境变量覆盖
+type Config struct {
+	Server   ServerConfig   `yaml:"server"`
+	Database DatabaseConfig `yaml:"database"`
+	CORS     CORSConfig     `yaml:"cors"`
+	Upload   UploadConfig   `yaml:"upload"`
+	TLS      TLSConfig      `yaml:"tls"`
+	Auth     AuthConfig     `yaml:"auth"`
+	Mail     MailConfig     `yaml:"mail"`
+	OIDC     OIDCConfig     `yaml:"oidc"`
+}
+
+// ServerConfig HTTP服务配置
+type ServerConfig struct {
+	Addr        string `yaml:"addr"`         // 监听地址，如 ":8080"
+	BaseURL     string `yaml:"base_url"`     // 对外访问的根地址，用于拼接图片等资源的完整URL
+	FrontendURL string `yaml:"frontend_url"` // 用户在浏览器中打开的前端地址，用于邮件中的链接；为空时与base_url相同
+	FrontendDir string `yaml:"frontend_dir"` // 前端编译产物目录，存在时优先于嵌入二进制的前端；为空则只使用嵌入的前端
+
+	// ShutdownTimeout 收到退出信号后等待处理中请求完成的最长时间，如 "15s"
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+}
+
+// 支持的数据库驱动
+const (
+	DriverMySQL  = "mysql"  // MySQL，生产环境使用
+	DriverSQLite = "sqlite" // SQLite文件数据库，本地开发使用；DSN为 ":memory:" 时为内存数据库，适合测试
+)
+
+// DatabaseConfig 数据库配置
+type DatabaseConfig struct {
+	Driver string `yaml:"driver"` // 数据库驱动：mysql / sqlite
+	// DSN 连接字符串
+	// mysql: user:pass@tcp(host:3306)/dbname?charset=utf8mb4&parseTime=True&loc=Local
+	// sqlite: 数据库文件路径或 "file:" URI，如 data/social.db、file:data/social.db?_pragma=busy_timeout(5000)；
+	//         ":memory:"、"file::memory:?cache=shared" 等表示内存数据库
+	DSN string `yaml:"dsn"`
+
+	// AutoMigrate 启动服务时是否自动执行未执行的迁移；关闭后需要手动运行 migrate up
+	AutoMigrate bool `yaml:"auto_migrate"`
+}
+
+// IsMemory 是否为SQLite内存数据库，包括 ":memory:"、"file::memory:?cache=shared" 和 "file:name?mode=memory" 等形式
+func (d DatabaseConfig) IsMemory() bool {
+	if d.Driver != DriverSQLite {
+		return false
+	}
+	name, query := d.sqliteName()
+	return name == ":memory:" || query.Get("mode") == "memory"
+}
+
+// SQLitePath SQLite数据库文件的路径：去掉 "file:" 前缀和 "?" 之后的参数；内存数据库返回空字符串
+func (d DatabaseConfig) SQLitePath() string {
+	if d.IsMemory() {
+		return ""
+	}
+	name, _ := d.sqliteName()
+	return name
+}
+
+// sqliteName 把SQLite的DSN拆成文件名和查询参数
+// 支持普通路径和 "file:" URI（file:data/social.db、file:///var/lib/social.db）
+func (d DatabaseConfig) sqliteName() (string, url.Values) {
+	name, rawQuery, _ := strings.Cut(d.DSN, "?")
+	query, _ := url.ParseQuery(rawQuery)
+	if rest, ok := strings.CutPrefix(name, "file:"); ok {
+		name = rest
+		// file://host/path 形式：去掉主机部分（SQLite只接受空主机或localhost）
+		if authority, ok := strings.CutPrefix(name, "//"); ok {
+			if i := strings.IndexByte(authority, '/'); i >= 0 {
+				name = authority[i:]
+			} else {
+				name = ""
+			}
+		}
+		if unescaped, err := url.PathUnescape(name); err == nil {
+			name = unescaped
+		}
+	}
+	return name, query
+}
+
+// CORSConfig 跨域配置
+type CORSConfig struct {
+	AllowOrigins []string `yaml:"allow_origins"` // 允许访问的前端源
+}
+
+// UploadConfig 文件上传配置
+type UploadConfig struct {
+	Dir          string `yaml:"dir"`            // 上传文件根目录，图片保存在其下的images目录
+	MaxImageSize int64  `yaml:"max_image_size"` // 单张图片大小上限（字节）
+}
+
+// TLSConfig HTTPS配置
+// 开启后 server.addr 监听HTTPS，证书文件变化时自动重新加载，无需重启
+type TLSConfig struct {
+	Enabled        bool          `yaml:"enabled"`
+	CertFile       string        `yaml:"cert_file"`       // 证书链文件（PEM）
+	KeyFile        string        `yaml:"key_file"`        // 私钥文件（PEM）
+	ReloadInterval time.Duration `yaml:"reload_interval"` // 检查证书文件是否变化的间隔
+	RedirectAddr   string        `yaml:"redirect_addr"`   // HTTP跳转HTTPS的监听地址，如 ":80"，为空则不监听
+	HSTSMaxAge     time.Duration `yaml:"hsts_max_age"`    // Strict-Transport-Security 的有效期，0表示不发送
+	// HSTSIncludeSubdomains HSTS同时作用于所有子域名；开启前确认每个子域名都支持HTTPS，否则这些子域名在有效期内无法访问
+	HSTSIncludeSubdomains bool `yaml:"hsts_include_subdomains"`
+}
+
+// AuthConfig 认证配置
+type AuthConfig struct {
+	// AccessTokenTTL 访问令牌（JWT）有效期，过期后用刷新令牌换取新的访问令牌
+	AccessTokenTTL time.Duration `yaml:"access_token_ttl"`
+	// RefreshTokenTTL 刷新令牌有效期，每次刷新都会轮换并重新计时，超过该时间未使用需要重新登录
+	RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl"`
+	// Issuer 访问令牌的签发方(iss)
+	Issuer string `yaml:"issuer"`
+	// Audience 访问令牌的受众(aud)，只接受受众为该值的令牌
+	Audience string `yaml:"audience"`
+	// ClockSkew 校验exp、nbf、iat时允许的时钟误差
+	ClockSkew time.Duration `yaml:"clock_skew"`
+	// PasswordResetTTL 找回密码邮件中链接的有效期
+	PasswordResetTTL time.Duration `yaml:"password_reset_ttl"`
+	// EmailVerificationTTL 邮箱验证码的有效期
+	EmailVerificationTTL time.Duration `yaml:"email_verification_ttl"`
+	// StudentEmailDomains 学校邮箱域名，验证了这些域名（含子域名）邮箱的用户成为认证学生
+	StudentEmailDomains []string `yaml:"student_email_domains"`
+	// PostRequires 发帖需要的认证级别：none、email（已验证邮箱）或 student（认证学生）
+	PostRequires string `yaml:"post_requires"`
+	// UsernameMinLength、UsernameMaxLength 用户名长度范围（按字符计）
+	UsernameMinLength int `yaml:"username_min_length"`
+	UsernameMaxLength int `yaml:"username_max_length"`
+	// UsernamePattern 用户名必须匹配的正则表达式，用于限制可用字符
+	UsernamePattern string `yaml:"username_pattern"`
+	// ReservedUsernames 不允许注册的用户名，不区分大小写
+	ReservedUsernames []string `yaml:"reserved_usernames"`
+	// LoginFailureWindow 统计登录失败次数的滑动窗口
+	// 登录和找回密码的失败记录保存在每个进程的内存中，多个实例部署时各自统计，实际上限是配置值乘以实例数
+	LoginFailureWindow time.Duration `yaml:"login_failure_window"`
+	// LoginMaxFailures 同一账号在窗口内失败这么多次后锁定；超过一半后每次失败需要等待的时间按指数增长
+	LoginMaxFailures int `yaml:"login_max_failures"`
+	// LoginIPMaxFailures 同一IP在窗口内失败这么多次后暂时禁止登录；校园网出口IP常被很多人共用，应明显大于 login_max_failures
+	LoginIPMaxFailures int `yaml:"login_ip_max_failures"`
+	// LoginBackoff 指数退避的初始等待时间，之后每次失败翻倍，最长 LoginMaxBackoff
+	LoginBackoff    time.Duration `yaml:"login_backoff"`
+	LoginMaxBackoff time.Duration `yaml:"login_max_backoff"`
+	// LoginLockout 第一次锁定的时长，之后再次锁定时翻倍，最长24小时
+	LoginLockout time.Duration `yaml:"login_lockout"`
+	// TOTPIssuer 两步验证的发行方名称，显示在认证器应用中
+	TOTPIssuer string `yaml:"totp_issuer"`
+	// Require2FARoles 必须开启两步验证的角色（user、moderator、admin），这些角色的用户未开启前只能访问开启两步验证所需的接口
+	Require2FARoles []string `yaml:"require_2fa_roles"`
+	// MFAChallengeTTL 密码正确后到提交两步验证码之间的最长时间
+	MFAChallengeTTL time.Duration `yaml:"mfa_challenge_ttl"`
+	// KeysDir JWT签名密钥目录，多个实例应共享同一个目录
+	KeysDir string `yaml:"keys_dir"`
+	// KeysReloadInterval 从磁盘重新加载签名密钥的间隔，keys rotate 后运行中的服务最多这么久开始使用新密钥
+	KeysReloadInterval time.Duration `yaml:"keys_reload_interval"`
+	// CookieSameSite Cookie认证模式下令牌Cookie的SameSite属性：strict、lax 或 none（前端和API不在同一站点时使用，要求HTTPS）
+	CookieSameSite string `yaml:"cookie_same_site"`
+	// CookieDomain 令牌Cookie的Domain属性，为空时只发送给API所在的主机
+	CookieDomain string `yaml:"cookie_domain"`
+}
+
+// 发帖需要的认证级别
+const (
+	VerificationNone    = "none"    // 不需要认证
+	VerificationEmail   = "email"   // 验证过任意邮箱
+	VerificationStudent = "student" // 验证过学校邮箱
+)
+
+// 支持的邮件发送方式
+const (
+	MailDriverLog  = "log"  // 只写入日志，本地开发使用
+	MailDriverFile = "file" // 每封邮件保存为一个.eml文件
+	MailDriverSMTP = "smtp" // 通过SMTP服务器发送
+)
+
+// MailConfig 邮件发送配置
+type MailConfig struct {
+	Driver       string `yaml:"driver"`        // log、file 或 smtp
+	From         string `yaml:"from"`          // 发件人地址
+	Dir          string `yaml:"dir"`           // file方式保存邮件的目录
+	SMTPAddr     string `yaml:"smtp_addr"`     // SMTP服务器地址，如 "smtp.example.com:587"
+	SMTPUsername string `yaml:"smtp_username"` // 为空时不进行SMTP认证
+	SMTPPassword string `yaml:"smtp_password"`
+}
+
+// OIDCConfig 学校统一身份认证（OpenID Connect）登录配置
+// 使用授权码模式和PKCE；身份提供方返回的ID令牌验证通过后，按 sub 找到已关联的用户，
+// 或按已验证的邮箱关联已有用户，都没有时自动创建用户，最后签发本平台自己的令牌
+type OIDCConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Name    string `yaml:"name"` // 登录按钮上显示的名称
+	// Issuer 身份提供方的签发方地址，发现文档位于 Issuer + "/.well-known/openid-configuration"
+	Issuer       string   `yaml:"issuer"`
+	ClientID     string   `yaml:"client_id"`
+	ClientSecret string   `yaml:"client_secret"` // 公共客户端（只使用PKCE）为空
+	Scopes       []string `yaml:"scopes"`        // 必须包含 openid
+	// RedirectURL 身份提供方登录后跳转回的地址，为空时使用 server.base_url + "/auth/oidc/callback"
+	RedirectURL string `yaml:"redirect_url"`
+	// AutoProvision 没有关联用户时自动创建用户，关闭后只能登录已关联或邮箱匹配的用户
+	AutoProvision bool `yaml:"auto_provision"`
+	// LinkByEmail 身份提供方确认过的邮箱与已有用户已验证的邮箱相同时，关联到该用户
+	LinkByEmail bool `yaml:"link_by_email"`
+}
+
+// Default 返回本地开发环境的默认配置
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Addr:        ":8080",
+			BaseURL:     "http://localhost:8080",
+			FrontendDir: "./frontend/build",
+
+			ShutdownTimeout: 15 * time.Second,
+		},
+		Database: DatabaseConfig{
+			Driver:      DriverMySQL,
+			DSN:         "root:123456@tcp(127.0.0.1:3306)/social_platform?charset=utf8mb4&parseTime=True&loc=Local",
+			AutoMigrate: true,
+		},
+		CORS: CORSConfig{
+			AllowOrigins: []string{"http://localhost:3000"},
+		},
+		Upload: UploadConfig{
+			Dir:          "uploads",
+			MaxImageSize: 32 * 1024 * 1024,
+		},
+		TLS: TLSConfig{
+			ReloadInterval: time.Minute,
+			HSTSMaxAge:     180 * 24 * time.Hour,
+		},
+		Auth: AuthConfig{
+			AccessTokenTTL:       15 * time.Minute,
+			RefreshTokenTTL:      30 * 24 * time.Hour,
+			PasswordResetTTL:     time.Hour,
+			EmailVerificationTTL: 15 * time.Minute,
+			PostRequires:         VerificationNone,
+			UsernameMinLength:    3,
+			UsernameMaxLength:    20,
+			UsernamePattern:      `^[A-Za-z0-9_]+$`,
+			ReservedUsernames: []string{
+				"admin", "administrator", "root", "system", "sysadmin", "superuser",
+				"support", "help", "security", "moderator", "mod", "official", "staff",
+				"api", "www", "mail", "postmaster", "webmaster", "hostmaster", "noreply", "no_reply",
+				"null", "undefined", "anonymous", "guest", "me", "self",
+				"login", "logout", "register", "profile", "settings",
+			},
+			LoginFailureWindow: 15 * time.Minute,
+			LoginMaxFailures:   5,
+			LoginIPMaxFailures: 50,
+			LoginBackoff:       time.Second,
+			LoginMaxBackoff:    time.Minute,
+			LoginLockout:       15 * time.Minute,
+			TOTPIssuer:         "校园智能社交平台",
+			Require2FARoles:    []string{"moderator", "admin"},
+			MFAChallengeTTL:    5 * time.Minute,
+			Issuer:             "my-social-platform",
+			Audience:           "web",
+			ClockSkew:          30 * time.Second,
+			KeysDir:            "keys",
+			KeysReloadInterval: time.Minute,
+			CookieSameSite:     "lax",
+		},
+		Mail: MailConfig{
+			Driver: MailDriverLog,
+			From:   "no-reply@localhost",
+			Dir:    "mail",
+		},
+		OIDC: OIDCConfig{
+			Name:          "统一身份认证",
+			Scopes:        []string{"openid", "profile", "email"},
+			AutoProvision: true,
+			LinkByEmail:   false,
+		},
+	}
+}
+
+// Load 加载配置
+// path为空时读取环境变量APP_CONFIG指定的文件，再退回到DefaultPath
+// 使用默认路径且文件不存在时不报错，直接使用默认值
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	explicit := path != ""
+	if !explicit {
+		if path = os.Getenv("APP_CONFIG"); path != "" {
+			explicit = true
+		} else {
+			path = DefaultPath
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := yaml.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("parse config %s: %w", path, err)
+		}
+	case errors.Is(err, os.ErrNotExist) && !explicit:
+		// 没有配置文件时使用默认值
+	default:
+		return nil, fmt.Errorf("read config %s: %w", path, err)
+	}
+
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// applyEnv 使用环境变量覆盖配置项
+// 环境变量名由 APP_ 加上配置路径组成，例如 server.addr 对应 APP_SERVER_ADDR
+func (c *Config) applyEnv() error {
+	stringVars := map[string]*string{
+		"APP_SERVER_ADDR":           &c.Server.Addr,
+		"APP_SERVER_BASE_URL":       &c.Server.BaseURL,
+		"APP_SERVER_FRONTEND_URL":   &c.Server.FrontendURL,
+		"APP_SERVER_FRONTEND_DIR":   &c.Server.FrontendDir,
+		"APP_DATABASE_DRIVER":       &c.Database.Driver,
+		"APP_DATABASE_DSN":          &c.Database.DSN,
+		"APP_UPLOAD_DIR":            &c.Upload.Dir,
+		"APP_TLS_CERT_FILE":         &c.TLS.CertFile,
+		"APP_TLS_KEY_FILE":          &c.TLS.KeyFile,
+		"APP_TLS_REDIRECT_ADDR":     &c.TLS.RedirectAddr,
+		"APP_AUTH_ISSUER":           &c.Auth.Issuer,
+		"APP_AUTH_AUDIENCE":         &c.Auth.Audience,
+		"APP_AUTH_KEYS_DIR":         &c.Auth.KeysDir,
+		"APP_AUTH_POST_REQUIRES":    &c.Auth.PostRequires,
+		"APP_AUTH_USERNAME_PATTERN": &c.Auth.UsernamePattern,
+		"APP_AUTH_TOTP_ISSUER":      &c.Auth.TOTPIssuer,
+		"APP_AUTH_COOKIE_SAME_SITE": &c.Auth.CookieSameSite,
+		"APP_AUTH_COOKIE_DOMAIN":    &c.Auth.CookieDomain,
+		"APP_MAIL_DRIVER":           &c.Mail.Driver,
+		"APP_MAIL_FROM":             &c.Mail.From,
+		"APP_MAIL_DIR":              &c.Mail.Dir,
+		"APP_MAIL_SMTP_ADDR":        &c.Mail.SMTPAddr,
+		"APP_MAIL_SMTP_USERNAME":    &c.Mail.SMTPUsername,
+		"APP_MAIL_SMTP_PASSWORD":    &c.Mail.SMTPPassword,
+		"APP_OIDC_NAME":             &c.OIDC.Name,
+		"APP_OIDC_ISSUER":           &c.OIDC.Issuer,
+		"APP_OIDC_CLIENT_ID":        &c.OIDC.ClientID,
+		"APP_OIDC_CLIENT_SECRET":    &c.OIDC.ClientSecret,
+		"APP_OIDC_REDIRECT_URL":     &c.OIDC.RedirectURL,
+	}
+	boolVars := map[string]*bool{
+		"APP_DATABASE_AUTO_MIGRATE":       &c.Database.AutoMigrate,
+		"APP_TLS_ENABLED":                 &c.TLS.Enabled,
+		"APP_TLS_HSTS_INCLUDE_SUBDOMAINS": &c.TLS.HSTSIncludeSubdomains,
+		"APP_OIDC_ENABLED":                &c.OIDC.Enabled,
+		"APP_OIDC_AUTO_PROVISION":         &c.OIDC.AutoProvision,
+		"APP_OIDC_LINK_BY_EMAIL":          &c.OIDC.LinkByEmail,
+	}
+	intVars := map[string]*int64{
+		"APP_UPLOAD_MAX_IMAGE_SIZE": &c.Upload.MaxImageSize,
+	}
+	countVars := map[string]*int{
+		"APP_AUTH_LOGIN_MAX_FAILURES":    &c.Auth.LoginMaxFailures,
+		"APP_AUTH_USERNAME_MIN_LENGTH":   &c.Auth.UsernameMinLength,
+		"APP_AUTH_USERNAME_MAX_LENGTH":   &c.Auth.UsernameMaxLength,
+		"APP_AUTH_LOGIN_IP_MAX_FAILURES": &c.Auth.LoginIPMaxFailures,
+	}
+	durationVars := map[string]*time.Duration{
+		"APP_SERVER_SHUTDOWN_TIMEOUT":     &c.Server.ShutdownTimeout,
+		"APP_TLS_RELOAD_INTERVAL":         &c.TLS.ReloadInterval,
+		"APP_TLS_HSTS_MAX_AGE":            &c.TLS.HSTSMaxAge,
+		"APP_AUTH_ACCESS_TOKEN_TTL":       &c.Auth.AccessTokenTTL,
+		"APP_AUTH_REFRESH_TOKEN_TTL":      &c.Auth.RefreshTokenTTL,
+		"APP_AUTH_CLOCK_SKEW":             &c.Auth.ClockSkew,
+		"APP_AUTH_KEYS_RELOAD_INTERVAL":   &c.Auth.KeysReloadInterval,
+		"APP_AUTH_PASSWORD_RESET_TTL":     &c.Auth.PasswordResetTTL,
+		"APP_AUTH_EMAIL_VERIFICATION_TTL": &c.Auth.EmailVerificationTTL,
+		"APP_AUTH_LOGIN_FAILURE_WINDOW":   &c.Auth.LoginFailureWindow,
+		"APP_AUTH_LOGIN_BACKOFF":          &c.Auth.LoginBackoff,
+		"APP_AUTH_LOGIN_MAX_BACKOFF":      &c.Auth.LoginMaxBackoff,
+		"APP_AUTH_LOGIN_LOCKOUT":          &c.Auth.LoginLockout,
+		"APP_AUTH_MFA_CHALLENGE_TTL":      &c.Auth.MFAChallengeTTL,
+	}
+	listVars := map[string]*[]string{
+		"APP_CORS_ALLOW_ORIGINS":         &c.CORS.AllowOrigins,
+		"APP_AUTH_STUDENT_EMAIL_DOMAINS": &c.Auth.StudentEmailDomains,
+		"APP_AUTH_RESERVED_USERNAMES":    &c.Auth.ReservedUsernames,
+		"APP_AUTH_REQUIRE_2FA_ROLES":     &c.Auth.Require2FARoles,
+		"APP_OIDC_SCOPES":                &c.OIDC.Scopes,
+	}
+
+	for key, field := range stringVars {
+		if v, ok := os.LookupEnv(key); ok {
+			*field = v
+		}
+	}
+	for key, field := range listVars {
+		if v, ok := os.LookupEnv(key); ok {
+			*field = splitList(v)
+		}
+	}
+	for key, field := range boolVars {
+		if v, ok := os.LookupEnv(key); ok {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			*field = b
+		}
+	}
+	for key, field := range intVars {
+		if v, ok := os.LookupEnv(key); ok {
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			*field = n
+		}
+	}
+	for key, field := range countVars {
+		if v, ok := os.LookupEnv(key); ok {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			*field = n
+		}
+	}
+	for key, field := range durationVars {
+		if v, ok := os.LookupEnv(key); ok {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			*field = d
+		}
+	}
+	return nil
+}
+
+// Validate 校验配置是否完整合法，启动时调用
+func (c *Config) Validate() error {
+	var errs []error
+
+	if c.Server.Addr == "" {
+		errs = append(errs, errors.New("server.addr is required"))
+	}
+	if u, err := url.Parse(c.Server.BaseURL); err != nil || u.Scheme == "" || u.Host == "" {
+		errs = append(errs, fmt.Errorf("server.base_url must be an absolute URL, got %q", c.Server.BaseURL))
+	}
+	if c.Server.FrontendURL != "" {
+		if u, err := url.Parse(c.Server.FrontendURL); err != nil || u.Scheme == "" || u.Host == "" {
+			errs = append(errs, fmt.Errorf("server.frontend_url must be an absolute URL, got %q", c.Server.FrontendURL))
+		}
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		errs = append(errs, errors.New("server.shutdown_timeout must be positive"))
+	}
+	switch c.Database.Driver {
+	case DriverMySQL, DriverSQLite:
+	default:
+		errs = append(errs, fmt.Errorf("database.driver must be %q or %q, got %q", DriverMySQL, DriverSQLite, c.Database.Driver))
+	}
+	if c.Database.DSN == "" {
+		errs = append(errs, errors.New("database.dsn is required"))
+	}
+	if len(c.CORS.AllowOrigins) == 0 {
+		errs = append(errs, errors.New("cors.allow_origins must not be empty"))
+	}
+	if c.Upload.Dir == "" {
+		errs = append(errs, errors.New("upload.dir is required"))
+	}
+	if c.Upload.MaxImageSize <= 0 {
+		errs = append(errs, errors.New("upload.max_image_size must be positive"))
+	}
+
+	if c.Auth.AccessTokenTTL <= 0 || c.Auth.RefreshTokenTTL <= 0 {
+		errs = append(errs, errors.New("auth.access_token_ttl and auth.refresh_token_ttl must be positive"))
+	} else if c.Auth.RefreshTokenTTL <= c.Auth.AccessTokenTTL {
+		errs = append(errs, errors.New("auth.refresh_token_ttl must be longer than auth.access_token_ttl"))
+	}
+	if c.Auth.PasswordResetTTL <= 0 {
+		errs = append(errs, errors.New("auth.password_reset_ttl must be positive"))
+	}
+	if c.Auth.EmailVerificationTTL <= 0 {
+		errs = append(errs, errors.New("auth.email_verification_ttl must be positive"))
+	}
+	switch c.Auth.PostRequires {
+	case VerificationNone, VerificationEmail:
+	case VerificationStudent:
+		if len(c.Auth.StudentEmailDomains) == 0 {
+			errs = append(errs, errors.New("auth.student_email_domains must not be empty when auth.post_requires is student"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("auth.post_requires must be %q, %q or %q, got %q", VerificationNone, VerificationEmail, VerificationStudent, c.Auth.PostRequires))
+	}
+	for _, d := range c.Auth.StudentEmailDomains {
+		if d == "" || strings.ContainsAny(d, "@ ") {
+			errs = append(errs, fmt.Errorf("auth.student_email_domains: invalid domain %q", d))
+		}
+	}
+	if c.Auth.UsernameMinLength <= 0 || c.Auth.UsernameMaxLength < c.Auth.UsernameMinLength {
+		errs = append(errs, errors.New("auth.username_min_length must be positive and not greater than auth.username_max_length"))
+	}
+	if _, err := regexp.Compile(c.Auth.UsernamePattern); err != nil {
+		errs = append(errs, fmt.Errorf("auth.username_pattern: %w", err))
+	}
+	if c.Auth.LoginFailureWindow <= 0 || c.Auth.LoginLockout <= 0 || c.Auth.LoginBackoff <= 0 || c.Auth.LoginMaxBackoff < c.Auth.LoginBackoff {
+		errs = append(errs, errors.New("auth.login_failure_window, auth.login_lockout and auth.login_backoff must be positive, auth.login_max_backoff must not be less than auth.login_backoff"))
+	}
+	if c.Auth.LoginMaxFailures <= 0 || c.Auth.LoginIPMaxFailures <= 0 {
+		errs = append(errs, errors.New("auth.login_max_failures and auth.login_ip_max_failures must be positive"))
+	}
+	if c.Auth.TOTPIssuer == "" {
+		errs = append(errs, errors.New("auth.totp_issuer is required"))
+	}
+	for _, r := range c.Auth.Require2FARoles {
+		switch r {
+		case "user", "moderator", "admin":
+		default:
+			errs = append(errs, fmt.Errorf("auth.require_2fa_roles: unknown role %q", r))
+		}
+	}
+	if c.Auth.MFAChallengeTTL <= 0 {
+		errs = append(errs, errors.New("auth.mfa_challenge_ttl must be positive"))
+	}
+	if c.Auth.Issuer == "" || c.Auth.Audience == "" {
+		errs = append(errs, errors.New("auth.issuer and auth.audience are required"))
+	}
+	if c.Auth.ClockSkew < 0 {
+		errs = append(errs, errors.New("auth.clock_skew must not be negative"))
+	}
+	if c.Auth.KeysDir == "" {
+		errs = append(errs, errors.New("auth.keys_dir is required"))
+	}
+	if c.Auth.KeysReloadInterval <= 0 {
+		errs = append(errs, errors.New("auth.keys_reload_interval must be positive"))
+	}
+	switch c.Auth.CookieSameSite {
+	case "strict", "lax":
+	case "none":
+		if !c.SecureCookies() {
+			errs = append(errs, errors.New("auth.cookie_same_site none requires an https server.base_url"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("auth.cookie_same_site must be strict, lax or none, got %q", c.Auth.CookieSameSite))
+	}
+	switch c.Mail.Driver {
+	case MailDriverLog:
+	case MailDriverFile:
+		if c.Mail.Dir == "" {
+			errs = append(errs, errors.New("mail.dir is required for the file driver"))
+		}
+	case MailDriverSMTP:
+		if c.Mail.SMTPAddr == "" {
+			errs = append(errs, errors.New("mail.smtp_addr is required for the smtp driver"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("mail.driver must be %q, %q or %q, got %q", MailDriverLog, MailDriverFile, MailDriverSMTP, c.Mail.Driver))
+	}
+	if c.Mail.From == "" {
+		errs = append(errs, errors.New("mail.from is required"))
+	}
+	if c.OIDC.Enabled {
+		if u, err := url.Parse(c.OIDC.Issuer); err != nil || u.Scheme == "" || u.Host == "" {
+			errs = append(errs, fmt.Errorf("oidc.issuer must be an absolute URL, got %q", c.OIDC.Issuer))
+		}
+		if c.OIDC.ClientID == "" {
+			errs = append(errs, errors.New("oidc.client_id is required when oidc is enabled"))
+		}
+		hasOpenID := false
+		for _, s := range c.OIDC.Scopes {
+			hasOpenID = hasOpenID || s == "openid"
+		}
+		if !hasOpenID {
+			errs = append(errs, errors.New("oidc.scopes must include openid"))
+		}
+		if c.OIDC.RedirectURL != "" {
+			if u, err := url.Parse(c.OIDC.RedirectURL); err != nil || u.Scheme == "" || u.Host == "" {
+				errs = append(errs, fmt.Errorf("oidc.redirect_url must be an absolute URL, got %q", c.OIDC.RedirectURL))
+			}
+		}
+	}
+	if c.TLS.Enabled {
+		if c.TLS.CertFile == "" || c.TLS.KeyFile == "" {
+			errs = append(errs, errors.New("tls.cert_file and tls.key_file are required when tls is enabled"))
+		}
+		if c.TLS.ReloadInterval <= 0 {
+			errs = append(errs, errors.New("tls.reload_interval must be positive"))
+		}
+		if c.TLS.HSTSMaxAge < 0 {
+			errs = append(errs, errors.New("tls.hsts_max_age must not be negative"))
+		}
+		if c.TLS.RedirectAddr != "" && c.TLS.RedirectAddr == c.Server.Addr {
+			errs = append(errs, errors.New("tls.redirect_addr must differ from server.addr"))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid config: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// PublicURL 将以 / 开头的相对路径拼接为对外访问的完整URL
+func (c *Config) PublicURL(path string) string {
+	return strings.TrimRight(c.Server.BaseURL, "/") + path
+}
+
+// FrontendLink 将以 / 开头的前端路由拼接为用户可以在浏览器中打开的完整URL，用于邮件中的链接
+func (c *Config) FrontendLink(path string) string {
+	base := c.Server.FrontendURL
+	if base == "" {
+		base = c.Server.BaseURL
+	}
+	return strings.TrimRight(base, "/") + path
+}
+
+// OIDCRedirectURL 身份提供方登录后跳转回的地址
+func (c *Config) OIDCRedirectURL() string {
+	if c.OIDC.RedirectURL != "" {
+		return c.OIDC.RedirectURL
+	}
+	return c.PublicURL("/auth/oidc/callback")
+}
+
+// SecureCookies 对外地址是HTTPS时，Cookie带上Secure属性只通过HTTPS发送
+func (c *Config) SecureCookies() bool {
+	return strings.HasPrefix(c.Server.BaseURL, "https://")
+}
+
+// ImageDir 图片保存目录
+func (c *Config) ImageDir() string {
+	return filepath.Join(c.Upload.Dir, "images")
+}
+
+// splitList 解析逗号分隔的列表，忽略空项
+func splitList(s string) []string {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
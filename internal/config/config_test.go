@@ -0,0 +1,38 @@
+package config_test
+
+import (
+	"my-social-platform/internal/config"
+	"testing"
+)
+
+func TestSQLitePath(t *testing.T) {
+	tests := []struct {
+		dsn    string
+		path   string
+		memory bool
+	}{
+		{"data/social.db", "data/social.db", false},
+		{"file:data/social.db?_pragma=busy_timeout(5000)", "data/social.db", false},
+		{"file:///var/lib/social/social.db?cache=shared", "/var/lib/social/social.db", false},
+		{"file://localhost/var/lib/social.db", "/var/lib/social.db", false},
+		{"file:my%20data/social.db", "my data/social.db", false},
+		{":memory:", "", true},
+		{"file::memory:", "", true},
+		{"file::memory:?cache=shared", "", true},
+		{"file:testdb?mode=memory&cache=shared", "", true},
+	}
+	for _, tt := range tests {
+		cfg := config.DatabaseConfig{Driver: config.DriverSQLite, DSN: tt.dsn}
+		if got := cfg.SQLitePath(); got != tt.path {
+			t.Errorf("SQLitePath(%q) = %q, want %q", tt.dsn, got, tt.path)
+		}
+		if got := cfg.IsMemory(); got != tt.memory {
+			t.Errorf("IsMemory(%q) = %v, want %v", tt.dsn, got, tt.memory)
+		}
+	}
+
+	mysql := config.DatabaseConfig{Driver: config.DriverMySQL, DSN: "root@tcp(127.0.0.1:3306)/social?mode=memory"}
+	if mysql.IsMemory() {
+		t.Error("MySQL DSN reported as in-memory")
+	}
+}
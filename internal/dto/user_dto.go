@@ -1,12 +1,17 @@
 package dto
 
 type UserDTO struct {
-	ID          uint   `json:"id"`
-	Username    string `json:"username"`
-	Nickname    string `json:"nickname"`
-	Avatar      string `json:"avatar"`
-	Bio         string `json:"bio"`          // 个性签名
-	FollowCount int    `json:"follow_count"` // 关注数
-	FansCount   int    `json:"fans_count"`   // 粉丝数
-	LikeCount   int    `json:"like_count"`   // 获赞数
+	ID               uint   `json:"id"`
+	Username         string `json:"username"`
+	Email            string `json:"email,omitempty"` // 只返回给用户本人
+	Nickname         string `json:"nickname"`
+	Avatar           string `json:"avatar"`
+	Bio              string `json:"bio"`                // 个性签名
+	FollowCount      int    `json:"follow_count"`       // 关注数
+	FansCount        int    `json:"fans_count"`         // 粉丝数
+	LikeCount        int    `json:"like_count"`         // 获赞数
+	EmailVerified    bool   `json:"email_verified"`     // 邮箱是否已验证
+	StudentVerified  bool   `json:"student_verified"`   // 是否为认证学生
+	Role             string `json:"role"`               // 角色：user、moderator 或 admin
+	TwoFactorEnabled bool   `json:"two_factor_enabled"` // 是否开启了两步验证
 }
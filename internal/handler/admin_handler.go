@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"errors"
+	"my-social-platform/internal/model"
+	"my-social-platform/internal/pkg/logger"
+	"my-social-platform/internal/repository"
+	"my-social-platform/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminHandler 管理接口：查看角色、授予和撤销用户的角色
+// 路由上通过 middleware.RequirePermission(model.PermUserRoleManage) 限制只有管理员可以访问
+type AdminHandler struct {
+	users *service.UserService
+	rbac  *service.RBACService
+}
+
+// NewAdminHandler 创建AdminHandler
+func NewAdminHandler(users *service.UserService, rbac *service.RBACService) *AdminHandler {
+	return &AdminHandler{users: users, rbac: rbac}
+}
+
+// ListRoles 全部角色及其权限
+func (h *AdminHandler) ListRoles(c *gin.Context) {
+	roles, err := h.rbac.Roles()
+	if err != nil {
+		logger.Log(logger.ERROR, "ADMIN_ROLES", c.GetString("username"), c.ClientIP(), "Failed to list roles: "+err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list roles"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"roles": roles})
+}
+
+// GrantRole 授予用户角色（请求体 {"role": "moderator"}），用户原来的角色被替换
+func (h *AdminHandler) GrantRole(c *gin.Context) {
+	var input struct {
+		Role string `json:"role"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil || input.Role == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	h.setRole(c, input.Role)
+}
+
+// RevokeRole 撤销用户的角色，恢复为普通用户
+func (h *AdminHandler) RevokeRole(c *gin.Context) {
+	h.setRole(c, model.RoleUser)
+}
+
+// setRole 修改 :username 的角色，该用户所有设备退出登录
+// 重新登录后得到带有新角色权限的令牌，新角色要求两步验证时登录时即可检查
+// 不允许修改自己的角色，避免管理员误操作后没有人能恢复
+func (h *AdminHandler) setRole(c *gin.Context, role string) {
+	actor := c.GetString("username")
+	clientIP := c.ClientIP()
+	target := c.Param("username")
+	if target == actor {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot change your own role"})
+		return
+	}
+
+	user, err := h.users.SetRole(target, role)
+	switch {
+	case err == nil:
+	case errors.Is(err, service.ErrInvalidRole):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, repository.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	default:
+		logger.Log(logger.ERROR, "ADMIN_ROLE", actor, clientIP, "Failed to set role: "+err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set role"})
+		return
+	}
+
+	logger.Security("ADMIN_ROLE", actor, clientIP, "Set role of "+user.Username+" to "+role)
+	c.JSON(http.StatusOK, gin.H{"username": user.Username, "role": user.Role})
+}
@@ -0,0 +1,121 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"my-social-platform/internal/app/apptest"
+	"my-social-platform/internal/handler"
+	"my-social-platform/internal/middleware"
+	"my-social-platform/internal/model"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newAdminRouter 和serve命令一样注册管理接口，返回路由和管理员alice的访问令牌
+func newAdminRouter(t *testing.T, a *apptest.App) (*gin.Engine, string) {
+	t.Helper()
+	a.CreateUser(t, "alice", "Spring2025x")
+	if _, err := a.UserService.SetRole("alice", model.RoleAdmin); err != nil {
+		t.Fatal(err)
+	}
+	h := handler.NewAdminHandler(a.UserService, a.RBAC)
+	r := gin.New()
+	admin := r.Group("/admin", a.JWT.Middleware(), middleware.RequirePermission(model.PermUserRoleManage))
+	admin.GET("/roles", h.ListRoles)
+	admin.PUT("/users/:username/role", h.GrantRole)
+	admin.DELETE("/users/:username/role", h.RevokeRole)
+
+	pair, err := a.TokenService.Issue(a.User(t, "alice"), apptest.Client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r, pair.AccessToken
+}
+
+// adminRequest 带着访问令牌请求管理接口
+func adminRequest(r http.Handler, token, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestAdminGrantAndRevokeRole(t *testing.T) {
+	a := apptest.New(t)
+	r, token := newAdminRouter(t, a)
+	a.CreateUser(t, "carol", "Winter2025z")
+
+	w := adminRequest(r, token, http.MethodPut, "/admin/users/carol/role", `{"role": "moderator"}`)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"role":"moderator"`) {
+		t.Fatalf("grant: status %d, body %s", w.Code, w.Body)
+	}
+	if role := a.User(t, "carol").Role; role != model.RoleModerator {
+		t.Errorf("role after grant: %s", role)
+	}
+
+	w = adminRequest(r, token, http.MethodDelete, "/admin/users/carol/role", "")
+	if w.Code != http.StatusOK || a.User(t, "carol").Role != model.RoleUser {
+		t.Errorf("revoke: status %d, role %s", w.Code, a.User(t, "carol").Role)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		{"unknown role", http.MethodPut, "/admin/users/carol/role", `{"role": "superuser"}`, http.StatusBadRequest},
+		{"missing role", http.MethodPut, "/admin/users/carol/role", `{}`, http.StatusBadRequest},
+		{"unknown user", http.MethodPut, "/admin/users/nobody/role", `{"role": "moderator"}`, http.StatusNotFound},
+		{"own role", http.MethodDelete, "/admin/users/alice/role", "", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if w := adminRequest(r, token, tt.method, tt.path, tt.body); w.Code != tt.want {
+			t.Errorf("%s: status %d, want %d (%s)", tt.name, w.Code, tt.want, w.Body)
+		}
+	}
+}
+
+func TestAdminListRoles(t *testing.T) {
+	a := apptest.New(t)
+	r, token := newAdminRouter(t, a)
+
+	w := adminRequest(r, token, http.MethodGet, "/admin/roles", "")
+	var body struct {
+		Roles []struct {
+			Name        string   `json:"name"`
+			Permissions []string `json:"permissions"`
+		} `json:"roles"`
+	}
+	if w.Code != http.StatusOK || json.Unmarshal(w.Body.Bytes(), &body) != nil || len(body.Roles) != len(model.Roles) {
+		t.Fatalf("list roles: status %d, body %s", w.Code, w.Body)
+	}
+}
+
+func TestAdminRequiresPermission(t *testing.T) {
+	a := apptest.New(t)
+	r, _ := newAdminRouter(t, a)
+	carol := a.CreateUser(t, "carol", "Winter2025z")
+	if _, err := a.UserService.SetRole("carol", model.RoleModerator); err != nil {
+		t.Fatal(err)
+	}
+	pair, err := a.TokenService.Issue(a.User(t, "carol"), apptest.Client)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 版主可以删除帖子，但不能管理角色
+	w := adminRequest(r, pair.AccessToken, http.MethodPut, "/admin/users/carol/role", `{"role": "admin"}`)
+	if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), `"code":"forbidden"`) {
+		t.Errorf("moderator granting a role: status %d, body %s", w.Code, w.Body)
+	}
+	if role := a.User(t, carol.Username).Role; role != model.RoleModerator {
+		t.Errorf("role changed without permission: %s", role)
+	}
+}
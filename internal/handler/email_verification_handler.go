@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"errors"
+	"my-social-platform/internal/pkg/logger"
+	"my-social-platform/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EmailVerificationHandler 邮箱验证相关的处理器
+type EmailVerificationHandler struct {
+	verify *service.EmailVerificationService
+}
+
+// NewEmailVerificationHandler 创建EmailVerificationHandler
+func NewEmailVerificationHandler(verify *service.EmailVerificationService) *EmailVerificationHandler {
+	return &EmailVerificationHandler{verify: verify}
+}
+
+// SendCode 向当前用户的邮箱发送验证码
+func (h *EmailVerificationHandler) SendCode(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	username := c.GetString("username")
+	clientIP := c.ClientIP()
+
+	err := h.verify.Send(c.Request.Context(), userID)
+	switch {
+	case err == nil:
+	case errors.Is(err, service.ErrNoEmail):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, service.ErrEmailAlreadyVerified):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, service.ErrVerificationTooFrequent):
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return
+	default:
+		logger.Log(logger.ERROR, "EMAIL_VERIFY", username, clientIP, "Failed to send verification code: "+err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send verification code"})
+		return
+	}
+
+	logger.Log(logger.INFO, "EMAIL_VERIFY", username, clientIP, "Verification code sent")
+	c.JSON(http.StatusOK, gin.H{"message": "Verification code sent"})
+}
+
+// Verify 校验邮件中的验证码
+// 成功后返回更新后的用户信息，其中 student_verified 表示是否成为认证学生
+func (h *EmailVerificationHandler) Verify(c *gin.Context) {
+	var input struct {
+		Code string `json:"code"`
+	}
+	userID := c.GetUint("user_id")
+	username := c.GetString("username")
+	clientIP := c.ClientIP()
+
+	if err := c.ShouldBindJSON(&input); err != nil || input.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	user, err := h.verify.Verify(userID, input.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidVerificationCode), errors.Is(err, service.ErrNoEmail):
+			logger.Log(logger.WARNING, "EMAIL_VERIFY", username, clientIP, "Email verification failed: "+err.Error())
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrEmailAlreadyVerified):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			logger.Log(logger.ERROR, "EMAIL_VERIFY", username, clientIP, "Failed to verify email: "+err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+		}
+		return
+	}
+
+	logger.Log(logger.INFO, "EMAIL_VERIFY", username, clientIP, "Email verified")
+	c.JSON(http.StatusOK, gin.H{"user": service.ToUserDTO(user)})
+}
@@ -3,6 +3,7 @@ package handler
 import (
 	"fmt"
 	"io"
+	"my-social-platform/internal/config"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -11,8 +12,18 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// FileUploadImageHandler 处理图片上传请求
-func FileUploadImageHandler(c *gin.Context) {
+// FileHandler 图片上传和获取的处理器
+type FileHandler struct {
+	cfg *config.Config
+}
+
+// NewFileHandler 创建FileHandler
+func NewFileHandler(cfg *config.Config) *FileHandler {
+	return &FileHandler{cfg: cfg}
+}
+
+// UploadImage 处理图片上传请求
+func (h *FileHandler) UploadImage(c *gin.Context) {
 	// 1. 获取上传文件
 	// 从HTTP请求中获取名为"image"的文件字段
 	file, header, err := c.Request.FormFile("image")
@@ -34,15 +45,15 @@ func FileUploadImageHandler(c *gin.Context) {
 		return
 	}
 
-	// 3. 校验文件大小（限制为32MB）
-	if header.Size > 32*1024*1024 {
-		// 如果超过32MB，返回400错误
-		c.JSON(http.StatusBadRequest, gin.H{"error": "图片大小不能超过32MB"})
+	// 3. 校验文件大小（上限由配置 upload.max_image_size 决定，默认32MB）
+	if header.Size > h.cfg.Upload.MaxImageSize {
+		// 如果超过上限，返回400错误
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("图片大小不能超过%dMB", h.cfg.Upload.MaxImageSize/1024/1024)})
 		return
 	}
 
 	// 4. 创建保存目录（如果不存在）
-	uploadDir := "uploads/images"
+	uploadDir := h.cfg.ImageDir()
 	if err := os.MkdirAll(uploadDir, 0755); err != nil {
 		// 如果创建目录失败，返回500错误
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "服务器错误"})
@@ -62,11 +73,15 @@ func FileUploadImageHandler(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "无法保存文件"})
 		return
 	}
-	defer dst.Close() // 确保函数结束时关闭文件
 
 	// 将上传的文件内容复制到目标文件
-	if _, err = io.Copy(dst, file); err != nil {
-		// 如果复制失败，返回500错误
+	_, err = io.Copy(dst, file)
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		// 如果复制失败（例如客户端断开），删除写了一半的文件，返回500错误
+		os.Remove(filePath)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存文件失败"})
 		return
 	}
@@ -75,8 +90,8 @@ func FileUploadImageHandler(c *gin.Context) {
 	// 构建可访问的URL路径
 	fileURL := fmt.Sprintf("/uploads/images/%s", fileName)
 
-	// 构建完整URL（开发环境）
-	fullURL := fmt.Sprintf("http://localhost:8080%s", fileURL)
+	// 构建完整URL（根地址来自配置 server.base_url）
+	fullURL := h.cfg.PublicURL(fileURL)
 
 	// 返回成功响应和文件URL
 	c.JSON(http.StatusOK, gin.H{
@@ -86,8 +101,8 @@ func FileUploadImageHandler(c *gin.Context) {
 	})
 }
 
-// GetImageHandler 获取图片
-func GetImageHandler(c *gin.Context) {
+// GetImage 获取图片
+func (h *FileHandler) GetImage(c *gin.Context) {
 	// 1. 获取文件名
 	// 从URL参数中获取文件名
 	fileName := c.Param("filename")
@@ -102,7 +117,7 @@ func GetImageHandler(c *gin.Context) {
 
 	// 3. 拼接文件路径
 	// 构建完整的文件路径
-	filePath := filepath.Join("uploads/images", fileName)
+	filePath := filepath.Join(h.cfg.ImageDir(), fileName)
 
 	// 4. 检查文件是否存在
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
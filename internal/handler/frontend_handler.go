@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 缓存策略
+// CRA编译出的 static/ 下文件名带内容哈希，内容变化文件名就会变，可以永久缓存；
+// index.html 引用这些带哈希的文件，必须每次向服务器确认；其他根目录文件（favicon等）短期缓存
+const (
+	cacheImmutable = "public, max-age=31536000, immutable"
+	cacheNoCache   = "no-cache"
+	cacheShort     = "public, max-age=3600"
+)
+
+// FrontendHandler 提供React单页应用的静态文件
+// 文件来源可以是嵌入二进制的编译产物，也可以是磁盘上的build目录（开发时使用）
+type FrontendHandler struct {
+	files fs.FS
+}
+
+// NewFrontendHandler 创建FrontendHandler，files以build目录为根
+func NewFrontendHandler(files fs.FS) *FrontendHandler {
+	return &FrontendHandler{files: files}
+}
+
+// Available 前端产物是否可用（存在index.html）
+func (h *FrontendHandler) Available() bool {
+	if h.files == nil {
+		return false
+	}
+	_, err := fs.Stat(h.files, "index.html")
+	return err == nil
+}
+
+// NoRoute 处理所有未匹配的路由
+// 1. 请求的是前端产物中存在的文件：直接返回，并按路径设置缓存头
+// 2. /api/ 和 /uploads/ 下的未知路径：返回JSON 404，避免接口调用方拿到HTML
+// 3. 其他路径：返回index.html，由前端路由处理（SPA回退）
+func (h *FrontendHandler) NoRoute(c *gin.Context) {
+	if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
+
+	name := strings.TrimPrefix(path.Clean(c.Request.URL.Path), "/")
+	if name != "" && name != "index.html" {
+		if info, err := fs.Stat(h.files, name); err == nil && !info.IsDir() {
+			if strings.HasPrefix(name, "static/") {
+				c.Header("Cache-Control", cacheImmutable)
+			} else {
+				c.Header("Cache-Control", cacheShort)
+			}
+			http.ServeFileFS(c.Writer, c.Request, h.files, name)
+			return
+		}
+		if strings.HasPrefix(name, "static/") {
+			// 带哈希的资源不存在时不能回退到index.html，否则浏览器会把HTML当作JS/CSS缓存
+			c.Status(http.StatusNotFound)
+			return
+		}
+	}
+
+	if strings.HasPrefix(name, "api/") || strings.HasPrefix(name, "uploads/") {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
+
+	h.serveIndex(c)
+}
+
+// serveIndex 返回index.html，禁止浏览器不经确认直接使用缓存
+func (h *FrontendHandler) serveIndex(c *gin.Context) {
+	index, err := fs.ReadFile(h.files, "index.html")
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "服务器错误"})
+		return
+	}
+	c.Header("Cache-Control", cacheNoCache)
+	c.Data(http.StatusOK, "text/html; charset=utf-8", index)
+}
@@ -1,23 +1,53 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
-	"my-social-platform/internal/middleware"
-	"my-social-platform/internal/model"
+	"math"
+	"my-social-platform/internal/config"
 	"my-social-platform/internal/pkg/logger"
 	"my-social-platform/internal/service"
 	"net/http"
+	"strconv"
 	"strings"
 
-	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
 )
 
-// RegisterHandler - 处理用户注册请求
-func RegisterHandler(c *gin.Context) {
+// UserHandler 注册、登录和个人资料相关的处理器
+// 依赖的服务通过NewUserHandler注入，在main中统一组装
+type UserHandler struct {
+	auth    *service.AuthService
+	users   *service.UserService
+	posts   *service.PostService
+	tokens  *service.TokenService
+	verify  *service.EmailVerificationService
+	mfa     *service.TwoFactorService
+	cookies *TokenCookies
+}
+
+// NewUserHandler 创建UserHandler
+func NewUserHandler(auth *service.AuthService, users *service.UserService, posts *service.PostService, tokens *service.TokenService, verify *service.EmailVerificationService, mfa *service.TwoFactorService, cookies *TokenCookies) *UserHandler {
+	return &UserHandler{auth: auth, users: users, posts: posts, tokens: tokens, verify: verify, mfa: mfa, cookies: cookies}
+}
+
+// PostHandler 帖子相关的处理器
+type PostHandler struct {
+	posts *service.PostService
+	cfg   *config.Config
+}
+
+// NewPostHandler 创建PostHandler
+func NewPostHandler(posts *service.PostService, cfg *config.Config) *PostHandler {
+	return &PostHandler{posts: posts, cfg: cfg}
+}
+
+// Register - 处理用户注册请求
+func (h *UserHandler) Register(c *gin.Context) {
 	var input struct {
 		Username string `json:"username"`
 		Password string `json:"password"`
+		Email    string `json:"email"` // 可选，用于找回密码和学生认证
 	}
 
 	// 获取客户端IP
@@ -35,7 +65,21 @@ func RegisterHandler(c *gin.Context) {
 
 	// 2. 调用service层的Register方法处理注册逻辑
 	// 类似于SpringBoot中注入Service并调用其方法
-	user, err := service.Register(input.Username, input.Password)
+	// 参数不合法返回400，用户名或邮箱已被使用返回409，fields中是每个字段的错误
+	user, err := h.auth.Register(input.Username, input.Password, input.Email)
+	var verr *service.ValidationError
+	switch {
+	case errors.As(err, &verr):
+		logger.Log(logger.WARNING, "REGISTER", input.Username, clientIP, err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "fields": verr.Fields})
+		return
+	case errors.Is(err, service.ErrUsernameTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "fields": gin.H{"username": err.Error()}})
+		return
+	case errors.Is(err, service.ErrEmailTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "fields": gin.H{"email": err.Error()}})
+		return
+	}
 	if err != nil {
 		logger.Log(logger.ERROR, "REGISTER", input.Username, clientIP, "Failed to register user: "+err.Error())
 		// 如果注册失败,返回500错误
@@ -46,6 +90,13 @@ func RegisterHandler(c *gin.Context) {
 	// 3. 注册成功，记录日志
 	logger.Log(logger.INFO, "REGISTER", input.Username, clientIP, "User registered successfully")
 
+	// 填写了邮箱时发送验证码，发送失败不影响注册，用户可以稍后重新发送
+	if user.Email != "" {
+		if err := h.verify.Send(c.Request.Context(), user.ID); err != nil {
+			logger.Log(logger.ERROR, "REGISTER", input.Username, clientIP, "Failed to send verification code: "+err.Error())
+		}
+	}
+
 	// 3. 注册成功,返回201状态码和用户信息
 	// gin.H相当于Java中的Map或ResponseEntity
 	// StatusCreated(201)表示资源创建成功
@@ -62,7 +113,7 @@ func RegisterHandler(c *gin.Context) {
 	c.JSON(http.StatusCreated, gin.H{"user": user})
 }
 
-// LoginHandler - 处理用户登录请求
+// Login - 处理用户登录请求
 
 // 这个函数处理用户的登录请求,主要做以下几件事:
 //
@@ -72,17 +123,23 @@ func RegisterHandler(c *gin.Context) {
 // - 如果解析失败返回400错误
 //
 // 2. 验证用户身份
-// - 调用service.Login验证用户名和密码
-// - 如果验证失败返回401未授权错误
+// - 调用AuthService.Login验证用户名和密码
+// - 如果验证失败返回401未授权错误，用户名不存在和密码错误的提示相同
+// - 同一IP或账号失败次数过多时返回429，并通过Retry-After告诉客户端多久后重试
+// - 失败和被限制的尝试都记录到安全日志
 //
-// 3. 生成JWT令牌
-// - 使用middleware.GenerateJWT为用户生成JWT令牌
+// 3. 两步验证
+// - 开启了两步验证的用户不直接签发令牌，而是返回 mfa_required 和短期的挑战令牌 challenge_token
+// - 客户端再调用 /login/2fa 提交挑战令牌和验证码，验证通过后才签发令牌
+//
+// 4. 生成令牌
+// - 使用TokenService签发访问令牌(JWT)和刷新令牌
 // - 如果生成失败返回500服务器错误
 //
-// 4. 返回令牌
-// - 登录成功时返回200状态码和JWT令牌
-// - 前端可以保存这个令牌用于后续的认证请求
-func LoginHandler(c *gin.Context) {
+// 5. 返回令牌
+// - 登录成功时返回200状态码、访问令牌和刷新令牌
+// - 前端保存访问令牌用于后续的认证请求，访问令牌过期后调用 /token/refresh 换取新令牌
+func (h *UserHandler) Login(c *gin.Context) {
 	var input struct {
 		Username string `json:"username"`
 		Password string `json:"password"`
@@ -100,21 +157,54 @@ func LoginHandler(c *gin.Context) {
 		return
 	}
 
-	var userModel model.User
-	if err := service.GetUserByUsername(input.Username, &userModel); err != nil {
-		logger.Log(logger.WARNING, "LOGIN", input.Username, clientIP, "Login failed: "+err.Error())
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+	userModel, userDTO, err := h.auth.Login(input.Username, input.Password, clientIP)
+	var blocked *service.LoginBlockedError
+	switch {
+	case err == nil:
+	case errors.As(err, &blocked):
+		// 失败次数过多：告诉客户端多久之后可以重试，提示信息不区分用户名是否存在
+		retryAfter := int(math.Ceil(blocked.RetryAfter.Seconds()))
+		if blocked.Locked {
+			logger.Security("LOGIN_LOCKED", input.Username, clientIP, fmt.Sprintf("Too many failed attempts, %s locked for %ds", blocked.Scope, retryAfter))
+		} else {
+			logger.Security("LOGIN_BLOCKED", input.Username, clientIP, fmt.Sprintf("Attempt rejected, %s blocked for another %ds", blocked.Scope, retryAfter))
+		}
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many failed login attempts, please try again later"})
+		return
+	case errors.Is(err, service.ErrInvalidCredentials):
+		logger.Security("LOGIN_FAILED", input.Username, clientIP, "Invalid username or password")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, service.ErrUserBanned):
+		logger.Security("LOGIN_FAILED", input.Username, clientIP, "Banned user tried to log in")
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	default:
+		logger.Log(logger.ERROR, "LOGIN", input.Username, clientIP, "Login failed: "+err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Login failed"})
 		return
 	}
 
-	userDTO, err := service.Login(input.Username, input.Password)
-	if err != nil {
-		logger.Log(logger.WARNING, "LOGIN", input.Username, clientIP, "Login failed: "+err.Error())
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	// 开启了两步验证：返回挑战令牌，由 /login/2fa 完成登录
+	if userModel.TOTPEnabledAt != nil {
+		challenge, err := h.mfa.Challenge(userModel)
+		if err != nil {
+			logger.Log(logger.ERROR, "LOGIN", input.Username, clientIP, "Failed to generate challenge: "+err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+			return
+		}
+		logger.Log(logger.INFO, "LOGIN", input.Username, clientIP, "Password accepted, waiting for two-factor code")
+		c.JSON(http.StatusOK, gin.H{
+			"mfa_required":    true,
+			"challenge_token": challenge.Token,
+			"expires_in":      int64(challenge.ExpiresIn.Seconds()),
+		})
 		return
 	}
 
-	token, err := middleware.GenerateJWT(userModel)
+	// 签发短期访问令牌和可轮换的刷新令牌
+	tokens, err := h.tokens.Issue(userModel, clientInfo(c))
 	if err != nil {
 		logger.Log(logger.ERROR, "LOGIN", input.Username, clientIP, "Failed to generate token: "+err.Error())
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
@@ -122,30 +212,20 @@ func LoginHandler(c *gin.Context) {
 	}
 
 	logger.Log(logger.INFO, "LOGIN", input.Username, clientIP, "User logged in successfully")
-	c.JSON(http.StatusOK, gin.H{"token": token, "user": userDTO})
+	h.cookies.Respond(c, tokens, gin.H{"user": userDTO})
 }
 
-// ProfileHandler - 获取当前登录用户信息（JWT解析后）
-func ProfileHandler(c *gin.Context) {
+// Profile - 获取当前登录用户信息（JWT解析后）
+func (h *UserHandler) Profile(c *gin.Context) {
 	// 获取客户端IP
 	clientIP := c.ClientIP()
 
-	// 从JWT中获取用户信息
-	authHeader := c.GetHeader("Authorization")
-	token := strings.TrimPrefix(authHeader, "Bearer ")
-
-	parsedToken, err := middleware.ParseJWT(token)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的token"})
-		return
-	}
-
-	claims := parsedToken.Claims.(jwt.MapClaims)
-	username := claims["username"].(string)
-	userID := uint(claims["user_id"].(float64))
+	// 用户信息由JWT中间件验证令牌后注入
+	userID := c.GetUint("user_id")
+	username := c.GetString("username")
 
 	// 获取用户完整信息
-	userProfile, err := service.GetUserProfileByID(userID)
+	userProfile, err := h.users.GetProfileByID(userID)
 	if err != nil {
 		logger.Log(logger.ERROR, "PROFILE", username, clientIP, "获取用户资料失败: "+err.Error())
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取用户资料失败"})
@@ -153,7 +233,7 @@ func ProfileHandler(c *gin.Context) {
 	}
 
 	// 获取用户的帖子
-	posts, err := service.GetPostsByUserIDService(userID)
+	posts, err := h.posts.ListByUserID(userID)
 	if err != nil {
 		logger.Log(logger.ERROR, "PROFILE", username, clientIP, "获取用户帖子失败: "+err.Error())
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取用户帖子失败"})
@@ -170,8 +250,8 @@ func ProfileHandler(c *gin.Context) {
 	})
 }
 
-// GetAllPostsHandler - 获取所有帖子
-func GetAllPostsHandler(c *gin.Context) {
+// GetAllPosts - 获取所有帖子
+func (h *PostHandler) GetAllPosts(c *gin.Context) {
 	// 获取客户端IP
 	clientIP := c.ClientIP()
 
@@ -187,7 +267,7 @@ func GetAllPostsHandler(c *gin.Context) {
 	}
 
 	// 调用服务层获取所有帖子
-	posts, err := service.GetAllPostsService()
+	posts, err := h.posts.List()
 	if err != nil {
 		logger.Log(logger.ERROR, "POSTS", "system", clientIP, "获取帖子失败: "+err.Error())
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取帖子失败"})
@@ -200,10 +280,8 @@ func GetAllPostsHandler(c *gin.Context) {
 		if posts[i].Images != "" && !strings.HasPrefix(posts[i].Images, "http") {
 			// 如果是上传文件路径，添加服务器域名
 			if strings.HasPrefix(posts[i].Images, "/uploads/") {
-				// 在生产环境中应该使用配置的服务器域名
-				// posts[i].Images = "https://your-domain.com" + posts[i].Images
-				// 开发环境使用本地地址
-				posts[i].Images = "http://localhost:8080" + posts[i].Images
+				// 使用配置的服务器根地址（server.base_url）
+				posts[i].Images = h.cfg.PublicURL(posts[i].Images)
 			}
 		}
 	}
@@ -215,8 +293,8 @@ func GetAllPostsHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"posts": posts})
 }
 
-// GetUserPostsHandler - 获取指定用户的所有帖子
-func GetUserPostsHandler(c *gin.Context) {
+// GetUserPosts - 获取指定用户的所有帖子
+func (h *PostHandler) GetUserPosts(c *gin.Context) {
 	// 从JWT中获取当前登录的用户ID
 	userID, exists := c.Get("user_id")
 	if !exists {
@@ -232,7 +310,7 @@ func GetUserPostsHandler(c *gin.Context) {
 	logger.Log(logger.INFO, "USER_POSTS", username.(string), clientIP, "User accessed their posts")
 
 	// 调用服务层获取用户的所有帖子
-	posts, err := service.GetPostsByUserIDService(userID.(uint))
+	posts, err := h.posts.ListByUserID(userID.(uint))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取帖子失败"})
 		return
@@ -0,0 +1,52 @@
+package handler_test
+
+import (
+	"my-social-platform/internal/app/apptest"
+	"my-social-platform/internal/config"
+	"my-social-platform/internal/handler"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// postLogin 用给定的IP请求 POST /login
+func postLogin(r http.Handler, ip, username, password string) *httptest.ResponseRecorder {
+	body := `{"username":"` + username + `","password":"` + password + `"}`
+	req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.RemoteAddr = ip + ":1234"
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestLoginRetryAfter(t *testing.T) {
+	a := apptest.New(t, func(cfg *config.Config) {
+		cfg.Auth.LoginMaxFailures = 2
+		cfg.Auth.LoginLockout = 15 * time.Minute
+	})
+	a.CreateUser(t, "alice", "Spring2025x")
+	h := handler.NewUserHandler(a.AuthService, a.UserService, a.PostService, a.TokenService, a.VerifyService, a.TwoFactor, handler.NewTokenCookies(a.Config))
+	r := gin.New()
+	r.POST("/login", h.Login)
+
+	if w := postLogin(r, "192.0.2.1", "alice", "wrong-password1"); w.Code != http.StatusUnauthorized {
+		t.Fatalf("first failure: status %d", w.Code)
+	}
+	w := postLogin(r, "192.0.2.1", "alice", "wrong-password2")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "900" {
+		t.Fatalf("lockout: status %d, Retry-After %q", w.Code, w.Header().Get("Retry-After"))
+	}
+
+	// 锁定期间正确的密码同样返回429，Retry-After是剩余的秒数
+	w = postLogin(r, "192.0.2.2", "alice", "Spring2025x")
+	retryAfter, err := strconv.Atoi(w.Header().Get("Retry-After"))
+	if w.Code != http.StatusTooManyRequests || err != nil || retryAfter <= 0 || retryAfter > 900 {
+		t.Errorf("while locked: status %d, Retry-After %q", w.Code, w.Header().Get("Retry-After"))
+	}
+}
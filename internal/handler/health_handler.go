@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"my-social-platform/internal/config"
+	"my-social-platform/internal/health"
+	"my-social-platform/internal/pkg/buildinfo"
+	"my-social-platform/internal/pkg/keystore"
+	"my-social-platform/internal/repository/migrations"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// HealthHandler 健康检查和构建信息的处理器
+type HealthHandler struct {
+	db   *gorm.DB
+	cfg  *config.Config
+	keys *keystore.Store
+}
+
+// NewHealthHandler 创建HealthHandler
+func NewHealthHandler(db *gorm.DB, cfg *config.Config, keys *keystore.Store) *HealthHandler {
+	return &HealthHandler{db: db, cfg: cfg, keys: keys}
+}
+
+// Healthz 存活检查
+// 只要进程能处理HTTP请求就返回200，不检查外部依赖，供负载均衡/编排系统判断是否需要重启
+func (h *HealthHandler) Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": health.StatusOK})
+}
+
+// Readyz 就绪检查
+// 检查数据库连接池、上传目录和JWT签名密钥是否可用，任一失败返回503，
+// 负载均衡据此决定是否把流量转发到本实例
+func (h *HealthHandler) Readyz(c *gin.Context) {
+	checks, ok := health.Run(c.Request.Context(),
+		health.Database(h.db),
+		health.WritableDir("uploads", h.cfg.ImageDir()),
+		health.JWTKeys(h.keys),
+	)
+
+	status, code := health.StatusOK, http.StatusOK
+	if !ok {
+		status, code = health.StatusFail, http.StatusServiceUnavailable
+	}
+	c.JSON(code, gin.H{"status": status, "checks": checks})
+}
+
+// Version 返回构建信息和数据库表结构版本
+func (h *HealthHandler) Version(c *gin.Context) {
+	schema := gin.H{"latest": migrations.LatestVersion()}
+	if version, err := migrations.CurrentVersion(h.db); err != nil {
+		schema["error"] = err.Error()
+	} else {
+		schema["current"] = version
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"build":  buildinfo.Get(),
+		"schema": schema,
+	})
+}
@@ -0,0 +1,25 @@
+package handler
+
+import (
+	"my-social-platform/internal/pkg/keystore"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JWKSHandler 发布JWT验签公钥
+type JWKSHandler struct {
+	keys *keystore.Store
+}
+
+// NewJWKSHandler 创建JWKSHandler
+func NewJWKSHandler(keys *keystore.Store) *JWKSHandler {
+	return &JWKSHandler{keys: keys}
+}
+
+// JWKS 返回全部验签公钥（RFC 7517），其他服务可以据此独立验证访问令牌
+// 缓存时间较短，密钥轮换后调用方能很快拿到新公钥
+func (h *JWKSHandler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.keys.JWKS())
+}
@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"my-social-platform/internal/config"
+	"my-social-platform/internal/pkg/logger"
+	"my-social-platform/internal/service"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// oidcFlowCookie 保存一次登录的state、nonce和code_verifier，只发送给 /auth/oidc 下的接口
+	oidcFlowCookie = "oidc_flow"
+	oidcCookiePath = "/auth/oidc"
+	// oidcFlowTTL 从跳转到身份提供方到回调的最长时间
+	oidcFlowTTL = 10 * time.Minute
+)
+
+// oidcFlowState 保存在Cookie中的登录参数，Cookie为true表示前端请求了Cookie认证模式（/auth/oidc/login?mode=cookie）
+type oidcFlowState struct {
+	service.OIDCFlow
+	Cookie bool `json:"cookie,omitempty"`
+}
+
+// OIDCHandler 学校统一身份认证登录的处理器
+//
+// 流程：前端跳转到 /auth/oidc/login → 身份提供方登录 → 回调 /auth/oidc/callback →
+// 签发本平台的令牌后跳转回前端的 /oidc/callback，令牌放在URL的#片段中（片段不会发送给服务器，也不会出现在访问日志里）；
+// Cookie认证模式下令牌写入Cookie，片段中只有 auth_mode=cookie
+type OIDCHandler struct {
+	oidc      *service.OIDCService
+	tokens    *service.TokenService
+	twoFactor *service.TwoFactorService
+	cookies   *TokenCookies
+	cfg       *config.Config
+}
+
+// NewOIDCHandler 创建OIDCHandler；没有开启统一身份认证时oidc为nil
+func NewOIDCHandler(oidc *service.OIDCService, tokens *service.TokenService, twoFactor *service.TwoFactorService, cookies *TokenCookies, cfg *config.Config) *OIDCHandler {
+	return &OIDCHandler{oidc: oidc, tokens: tokens, twoFactor: twoFactor, cookies: cookies, cfg: cfg}
+}
+
+// Info 前端据此决定是否在登录页显示统一身份认证按钮
+func (h *OIDCHandler) Info(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"enabled": h.oidc != nil, "name": h.cfg.OIDC.Name})
+}
+
+// Login 生成state、nonce和PKCE参数保存到Cookie，然后跳转到身份提供方
+// 前端使用Cookie认证模式时带上 ?mode=cookie
+func (h *OIDCHandler) Login(c *gin.Context) {
+	if h.oidc == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "SSO login is not enabled"})
+		return
+	}
+
+	authURL, flow, err := h.oidc.Begin(c.Request.Context())
+	if err != nil {
+		logger.Log(logger.ERROR, "OIDC_LOGIN", "guest", c.ClientIP(), "Failed to start SSO login: "+err.Error())
+		h.redirect(c, url.Values{"error": {"sso_unavailable"}})
+		return
+	}
+	data, _ := json.Marshal(oidcFlowState{OIDCFlow: *flow, Cookie: c.Query("mode") == "cookie"})
+	h.setFlowCookie(c, base64.RawURLEncoding.EncodeToString(data), int(oidcFlowTTL.Seconds()))
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// Callback 身份提供方登录后的回调
+// 校验state后用授权码换取ID令牌，找到或创建用户，再和 /login 一样签发令牌（开启了两步验证时签发挑战令牌）
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	clientIP := c.ClientIP()
+	if h.oidc == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "SSO login is not enabled"})
+		return
+	}
+
+	// Cookie只使用一次
+	raw, _ := c.Cookie(oidcFlowCookie)
+	h.setFlowCookie(c, "", -1)
+	var flow oidcFlowState
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil || json.Unmarshal(data, &flow) != nil || flow.State == "" || c.Query("state") != flow.State {
+		logger.Security("OIDC_LOGIN", "guest", clientIP, "Invalid or missing SSO state")
+		h.redirect(c, url.Values{"error": {"invalid_state"}})
+		return
+	}
+	if e := c.Query("error"); e != "" {
+		logger.Log(logger.WARNING, "OIDC_LOGIN", "guest", clientIP, "Identity provider returned error: "+e+" "+c.Query("error_description"))
+		h.redirect(c, url.Values{"error": {"access_denied"}})
+		return
+	}
+
+	user, created, err := h.oidc.Complete(c.Request.Context(), &flow.OIDCFlow, c.Query("code"))
+	switch {
+	case err == nil:
+	case errors.Is(err, service.ErrNoLinkedAccount):
+		logger.Log(logger.WARNING, "OIDC_LOGIN", "guest", clientIP, "No linked account for SSO identity")
+		h.redirect(c, url.Values{"error": {"no_account"}})
+		return
+	case errors.Is(err, service.ErrUserBanned):
+		logger.Security("OIDC_LOGIN", "guest", clientIP, "Banned user tried to log in via SSO")
+		h.redirect(c, url.Values{"error": {"banned"}})
+		return
+	default:
+		logger.Log(logger.ERROR, "OIDC_LOGIN", "guest", clientIP, "SSO login failed: "+err.Error())
+		h.redirect(c, url.Values{"error": {"sso_failed"}})
+		return
+	}
+	if created {
+		logger.Log(logger.INFO, "REGISTER", user.Username, clientIP, "User provisioned via SSO")
+	}
+
+	if user.TOTPEnabledAt != nil {
+		challenge, err := h.twoFactor.Challenge(user)
+		if err != nil {
+			logger.Log(logger.ERROR, "OIDC_LOGIN", user.Username, clientIP, "Failed to generate challenge: "+err.Error())
+			h.redirect(c, url.Values{"error": {"sso_failed"}})
+			return
+		}
+		logger.Log(logger.INFO, "OIDC_LOGIN", user.Username, clientIP, "SSO accepted, waiting for two-factor code")
+		h.redirect(c, url.Values{"challenge_token": {challenge.Token}})
+		return
+	}
+
+	tokens, err := h.tokens.Issue(user, clientInfo(c))
+	if err != nil {
+		logger.Log(logger.ERROR, "OIDC_LOGIN", user.Username, clientIP, "Failed to generate token: "+err.Error())
+		h.redirect(c, url.Values{"error": {"sso_failed"}})
+		return
+	}
+	logger.Log(logger.INFO, "OIDC_LOGIN", user.Username, clientIP, "User logged in via SSO")
+	if flow.Cookie {
+		h.cookies.Set(c, tokens)
+		h.redirect(c, url.Values{"auth_mode": {"cookie"}, "expires_in": {strconv.FormatInt(tokens.ExpiresIn, 10)}})
+		return
+	}
+	h.redirect(c, url.Values{
+		"token":         {tokens.AccessToken},
+		"refresh_token": {tokens.RefreshToken},
+		"expires_in":    {strconv.FormatInt(tokens.ExpiresIn, 10)},
+	})
+}
+
+// redirect 跳转回前端的 /oidc/callback，结果放在#片段中
+func (h *OIDCHandler) redirect(c *gin.Context, fragment url.Values) {
+	c.Header("Cache-Control", "no-store")
+	c.Redirect(http.StatusFound, h.cfg.FrontendLink("/oidc/callback")+"#"+fragment.Encode())
+}
+
+// setFlowCookie 设置或删除（maxAge为-1）保存登录参数的Cookie
+// SameSite=Lax：身份提供方跳转回来是顶层GET导航，Lax模式下浏览器会带上Cookie
+func (h *OIDCHandler) setFlowCookie(c *gin.Context, value string, maxAge int) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcFlowCookie, value, maxAge, oidcCookiePath, "", h.cfg.SecureCookies(), true)
+}
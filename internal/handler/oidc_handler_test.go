@@ -0,0 +1,113 @@
+package handler_test
+
+import (
+	"my-social-platform/internal/app/apptest"
+	"my-social-platform/internal/config"
+	"my-social-platform/internal/handler"
+	"my-social-platform/internal/pkg/oidc/oidctest"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newOIDCRouter 注册 /auth/oidc/login 和 /auth/oidc/callback，身份提供方是进程内的oidctest
+func newOIDCRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	p, srv := oidctest.NewServer()
+	t.Cleanup(srv.Close)
+	p.Identity = &oidctest.Identity{Subject: "sub-1", PreferredUsername: "zhangsan"}
+	a := apptest.New(t, func(cfg *config.Config) {
+		cfg.OIDC.Enabled = true
+		cfg.OIDC.Issuer = srv.URL
+		cfg.OIDC.ClientID = "social"
+	})
+	h := handler.NewOIDCHandler(a.OIDC, a.TokenService, a.TwoFactor, handler.NewTokenCookies(a.Config), a.Config)
+	r := gin.New()
+	r.GET("/auth/oidc/login", h.Login)
+	r.GET("/auth/oidc/callback", h.Callback)
+	return r
+}
+
+// startLogin 请求 /auth/oidc/login 并在身份提供方自动登录，返回保存登录参数的Cookie和回调参数
+func startLogin(t *testing.T, r *gin.Engine) (*http.Cookie, url.Values) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/auth/oidc/login", nil))
+	if w.Code != http.StatusFound {
+		t.Fatalf("login: status %d", w.Code)
+	}
+	var flow *http.Cookie
+	for _, c := range w.Result().Cookies() {
+		if c.Name == "oidc_flow" {
+			flow = c
+		}
+	}
+	if flow == nil || !flow.HttpOnly {
+		t.Fatalf("login did not set an HttpOnly flow cookie: %v", w.Result().Cookies())
+	}
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(w.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	loc, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return flow, loc.Query()
+}
+
+// callback 带着Cookie请求回调地址，返回跳转到前端的#片段
+func callback(t *testing.T, r *gin.Engine, flow *http.Cookie, params url.Values) url.Values {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/auth/oidc/callback?"+params.Encode(), nil)
+	if flow != nil {
+		req.AddCookie(flow)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	loc := w.Header().Get("Location")
+	if w.Code != http.StatusFound || !strings.Contains(loc, "/oidc/callback#") {
+		t.Fatalf("callback: status %d, location %q", w.Code, loc)
+	}
+	fragment, err := url.ParseQuery(loc[strings.Index(loc, "#")+1:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fragment
+}
+
+func TestOIDCCallback(t *testing.T) {
+	r := newOIDCRouter(t)
+	flow, params := startLogin(t, r)
+
+	fragment := callback(t, r, flow, params)
+	if fragment.Get("error") != "" || fragment.Get("token") == "" || fragment.Get("refresh_token") == "" {
+		t.Errorf("callback fragment: %v", fragment)
+	}
+}
+
+func TestOIDCCallbackRejectsStateMismatch(t *testing.T) {
+	r := newOIDCRouter(t)
+	flow, params := startLogin(t, r)
+
+	// 攻击者用自己的授权码和state构造回调链接（登录CSRF）
+	forged := url.Values{"code": {params.Get("code")}, "state": {"attacker-state"}}
+	if fragment := callback(t, r, flow, forged); fragment.Get("error") != "invalid_state" {
+		t.Errorf("wrong state: %v", fragment)
+	}
+	// 没有Cookie（在另一个浏览器中打开回调链接）
+	if fragment := callback(t, r, nil, params); fragment.Get("error") != "invalid_state" {
+		t.Errorf("missing flow cookie: %v", fragment)
+	}
+}
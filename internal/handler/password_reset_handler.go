@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"math"
+	"my-social-platform/internal/pkg/logger"
+	"my-social-platform/internal/service"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// resetMailTimeout 后台发送重置邮件的超时时间
+const resetMailTimeout = 30 * time.Second
+
+// PasswordResetHandler 忘记密码相关的处理器
+type PasswordResetHandler struct {
+	resets *service.PasswordResetService
+}
+
+// NewPasswordResetHandler 创建PasswordResetHandler
+func NewPasswordResetHandler(resets *service.PasswordResetService) *PasswordResetHandler {
+	return &PasswordResetHandler{resets: resets}
+}
+
+// ForgotPassword 向邮箱发送重置密码链接
+// 无论邮箱是否已注册都返回相同的响应，避免泄露哪些邮箱已注册
+func (h *PasswordResetHandler) ForgotPassword(c *gin.Context) {
+	var input struct {
+		Email string `json:"email"`
+	}
+	clientIP := c.ClientIP()
+
+	if err := c.ShouldBindJSON(&input); err != nil || input.Email == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	var blocked *service.LoginBlockedError
+	if err := h.resets.Allow(clientIP, input.Email); errors.As(err, &blocked) {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(blocked.RetryAfter.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests, please try again later"})
+		return
+	}
+
+	// 在后台查找用户并发送邮件，已注册和未注册邮箱的响应时间相同；请求结束后继续发送，不使用请求的context
+	go func(email string) {
+		ctx, cancel := context.WithTimeout(context.Background(), resetMailTimeout)
+		defer cancel()
+		err := h.resets.Request(ctx, email)
+		switch {
+		case err == nil:
+			logger.Log(logger.INFO, "FORGOT_PASSWORD", "", clientIP, "Password reset requested")
+		case errors.Is(err, service.ErrPasswordResetTooFrequent):
+			logger.Log(logger.WARNING, "FORGOT_PASSWORD", "", clientIP, "Password reset email not sent: "+err.Error())
+		default:
+			// 发送失败只记录日志，响应与成功时相同
+			logger.Log(logger.ERROR, "FORGOT_PASSWORD", "", clientIP, "Failed to send reset email: "+err.Error())
+		}
+	}(input.Email)
+	c.JSON(http.StatusOK, gin.H{"message": "If the email is registered, a reset link has been sent"})
+}
+
+// ResetPassword 使用邮件中的令牌设置新密码
+// 成功后该用户所有设备上的登录都会失效，需要用新密码重新登录
+func (h *PasswordResetHandler) ResetPassword(c *gin.Context) {
+	var input struct {
+		Token       string `json:"token"`
+		NewPassword string `json:"new_password"`
+	}
+	clientIP := c.ClientIP()
+
+	if err := c.ShouldBindJSON(&input); err != nil || input.Token == "" || input.NewPassword == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	user, err := h.resets.Reset(input.Token, input.NewPassword)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidResetToken), errors.Is(err, service.ErrPasswordPolicy):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			logger.Log(logger.ERROR, "RESET_PASSWORD", "", clientIP, "Failed to reset password: "+err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		}
+		return
+	}
+
+	logger.Log(logger.INFO, "RESET_PASSWORD", user.Username, clientIP, "Password reset via email link")
+	c.JSON(http.StatusOK, gin.H{"message": "Password has been reset, please log in again"})
+}
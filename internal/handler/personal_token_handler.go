@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"my-social-platform/internal/pkg/logger"
+	"my-social-platform/internal/service"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PersonalTokenHandler 个人访问令牌管理：查看 / 创建 / 撤销
+// 这些接口只接受登录得到的访问令牌，个人访问令牌不能用来创建新的令牌
+type PersonalTokenHandler struct {
+	tokens *service.PersonalTokenService
+}
+
+// NewPersonalTokenHandler 创建PersonalTokenHandler
+func NewPersonalTokenHandler(tokens *service.PersonalTokenService) *PersonalTokenHandler {
+	return &PersonalTokenHandler{tokens: tokens}
+}
+
+// List 当前用户的个人访问令牌，不包含令牌本身
+func (h *PersonalTokenHandler) List(c *gin.Context) {
+	tokens, err := h.tokens.List(c.GetUint("user_id"))
+	if err != nil {
+		logger.Log(logger.ERROR, "TOKENS", c.GetString("username"), c.ClientIP(), "Failed to list personal access tokens: "+err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list tokens"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"tokens": tokens})
+}
+
+// Create 创建个人访问令牌，响应中的token只返回这一次
+// expires_in_days 为0表示永不过期
+func (h *PersonalTokenHandler) Create(c *gin.Context) {
+	var input struct {
+		Name          string   `json:"name" binding:"required,max=64"`
+		Scopes        []string `json:"scopes" binding:"required,min=1"`
+		ExpiresInDays int      `json:"expires_in_days" binding:"min=0,max=365"`
+	}
+	username := c.GetString("username")
+	clientIP := c.ClientIP()
+
+	if err := c.ShouldBindJSON(&input); err != nil || strings.TrimSpace(input.Name) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: name and at least one scope are required, expires_in_days must be 0-365"})
+		return
+	}
+
+	ttl := time.Duration(input.ExpiresInDays) * 24 * time.Hour
+	info, raw, err := h.tokens.Create(c.GetUint("user_id"), strings.TrimSpace(input.Name), input.Scopes, ttl)
+	var scopeErr *service.InvalidScopeError
+	switch {
+	case err == nil:
+	case errors.As(err, &scopeErr), errors.Is(err, service.ErrTooManyPersonalTokens):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	default:
+		logger.Log(logger.ERROR, "TOKEN_CREATE", username, clientIP, "Failed to create personal access token: "+err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create token"})
+		return
+	}
+
+	logger.Security("TOKEN_CREATE", username, clientIP,
+		fmt.Sprintf("Personal access token %d %q created with scopes %s", info.ID, info.Name, strings.Join(info.Scopes, ",")))
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusCreated, gin.H{"token": raw, "info": info})
+}
+
+// Revoke 撤销个人访问令牌，使用该令牌的脚本立即无法访问
+func (h *PersonalTokenHandler) Revoke(c *gin.Context) {
+	username := c.GetString("username")
+	clientIP := c.ClientIP()
+	tokenID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid token id"})
+		return
+	}
+
+	err = h.tokens.Revoke(c.GetUint("user_id"), uint(tokenID))
+	if errors.Is(err, service.ErrPersonalTokenNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		logger.Log(logger.ERROR, "TOKEN_REVOKE", username, clientIP, "Failed to revoke personal access token: "+err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke token"})
+		return
+	}
+
+	logger.Security("TOKEN_REVOKE", username, clientIP, "Personal access token "+c.Param("id")+" revoked")
+	c.JSON(http.StatusOK, gin.H{"message": "Token revoked"})
+}
@@ -1,7 +1,12 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
+	"my-social-platform/internal/middleware"
 	"my-social-platform/internal/model"
+	"my-social-platform/internal/pkg/logger"
+	"my-social-platform/internal/repository"
 	"my-social-platform/internal/service"
 	"net/http"
 	"strconv"
@@ -10,7 +15,7 @@ import (
 )
 
 // 处理发帖请求
-func CreatePostHandler(c *gin.Context) {
+func (h *PostHandler) CreatePost(c *gin.Context) {
 	var post model.Post
 	// 绑定JSON请求体到post中
 	if err := c.ShouldBindJSON(&post); err != nil {
@@ -26,7 +31,11 @@ func CreatePostHandler(c *gin.Context) {
 	post.UserID = userID.(uint)
 
 	// 调用服务层创建帖子
-	err := service.CreatePostService(&post)
+	err := h.posts.Create(&post)
+	if errors.Is(err, service.ErrNotVerified) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "请先完成邮箱认证再发帖", "code": "verification_required"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建帖子失败: " + err.Error()})
 		return
@@ -36,14 +45,48 @@ func CreatePostHandler(c *gin.Context) {
 }
 
 // 根据用户id查找帖子
-func GetPostDetailHandler(c *gin.Context) {
+func (h *PostHandler) GetPostDetail(c *gin.Context) {
 	// 拿到用户id
 	userIDStr := c.Param("id")
 	userID, err := strconv.ParseUint(userIDStr, 10, 64)
-	post, err := service.GetPostByIDService(uint(userID))
+	post, err := h.posts.GetByID(uint(userID))
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "帖子不存在"})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"post": post})
 }
+
+// DeletePost 删除帖子
+// 作者可以删除自己的帖子；有 post.delete.any 权限（版主、管理员）时可以删除任何人的帖子，记录到安全日志
+func (h *PostHandler) DeletePost(c *gin.Context) {
+	clientIP := c.ClientIP()
+	username := c.GetString("username")
+	postID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "帖子ID格式错误"})
+		return
+	}
+
+	post, err := h.posts.Delete(uint(postID), c.GetUint("user_id"), middleware.HasPermission(c, model.PermPostDeleteAny))
+	switch {
+	case err == nil:
+	case errors.Is(err, repository.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "帖子不存在"})
+		return
+	case errors.Is(err, service.ErrNotPostAuthor):
+		c.JSON(http.StatusForbidden, gin.H{"error": "只能删除自己的帖子", "code": "forbidden"})
+		return
+	default:
+		logger.Log(logger.ERROR, "POST_DELETE", username, clientIP, "删除帖子失败: "+err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除帖子失败"})
+		return
+	}
+
+	if post.UserID != c.GetUint("user_id") {
+		logger.Security("POST_MODERATE", username, clientIP, fmt.Sprintf("Deleted post %d of user %d", post.ID, post.UserID))
+	} else {
+		logger.Log(logger.INFO, "POST_DELETE", username, clientIP, fmt.Sprintf("删除帖子 %d", post.ID))
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "帖子已删除"})
+}
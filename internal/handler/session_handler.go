@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"errors"
+	"my-social-platform/internal/pkg/logger"
+	"my-social-platform/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SessionHandler 登录设备管理：查看自己的会话，结束其中任意一个
+type SessionHandler struct {
+	sessions *service.SessionService
+	cookies  *TokenCookies
+}
+
+// NewSessionHandler 创建SessionHandler
+func NewSessionHandler(sessions *service.SessionService, cookies *TokenCookies) *SessionHandler {
+	return &SessionHandler{sessions: sessions, cookies: cookies}
+}
+
+// List 当前用户有效的会话，current 标记发起请求的会话
+func (h *SessionHandler) List(c *gin.Context) {
+	sessions, err := h.sessions.List(c.GetUint("user_id"), c.GetUint("session_id"))
+	if err != nil {
+		logger.Log(logger.ERROR, "SESSIONS", c.GetString("username"), c.ClientIP(), "Failed to list sessions: "+err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list sessions"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"sessions": sessions})
+}
+
+// Revoke 结束一个会话，该设备需要重新登录；结束当前会话相当于退出登录
+func (h *SessionHandler) Revoke(c *gin.Context) {
+	username := c.GetString("username")
+	clientIP := c.ClientIP()
+	sessionID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session id"})
+		return
+	}
+
+	err = h.sessions.Revoke(c.GetUint("user_id"), uint(sessionID))
+	if errors.Is(err, service.ErrSessionNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		logger.Log(logger.ERROR, "SESSION_REVOKE", username, clientIP, "Failed to revoke session: "+err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+		return
+	}
+
+	logger.Security("SESSION_REVOKE", username, clientIP, "Session "+c.Param("id")+" terminated")
+	if uint(sessionID) == c.GetUint("session_id") && h.cookies.Requested(c) {
+		h.cookies.Clear(c)
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Session terminated"})
+}
+
+// clientInfo 发起请求的客户端，登录和刷新令牌时记录到会话中
+func clientInfo(c *gin.Context) service.ClientInfo {
+	return service.ClientInfo{IP: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+}
@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"crypto/rand"
+	"my-social-platform/internal/config"
+	"my-social-platform/internal/middleware"
+	"my-social-platform/internal/service"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// refreshCookiePath 刷新令牌的Cookie只发送给 /token/refresh，其他接口收不到
+const refreshCookiePath = "/token"
+
+// TokenCookies Cookie认证模式下令牌的下发和清除
+//
+// 浏览器登录时请求头带上 X-Auth-Mode: cookie，令牌写入HttpOnly Cookie而不是响应体，XSS无法读取令牌；
+// 同时下发一个非HttpOnly的csrf_token Cookie，前端在修改类请求中通过 X-CSRF-Token 请求头带回（见 middleware.ValidCSRF）
+// 没有该请求头的客户端（脚本、App）仍然使用Bearer模式，令牌在响应体中
+type TokenCookies struct {
+	secure     bool
+	sameSite   http.SameSite
+	domain     string
+	refreshTTL time.Duration
+}
+
+// NewTokenCookies 创建TokenCookies，server.base_url 为https时Cookie带Secure属性
+func NewTokenCookies(cfg *config.Config) *TokenCookies {
+	sameSite := map[string]http.SameSite{
+		"strict": http.SameSiteStrictMode,
+		"lax":    http.SameSiteLaxMode,
+		"none":   http.SameSiteNoneMode,
+	}[cfg.Auth.CookieSameSite]
+	return &TokenCookies{
+		secure:     cfg.SecureCookies(),
+		sameSite:   sameSite,
+		domain:     cfg.Auth.CookieDomain,
+		refreshTTL: cfg.Auth.RefreshTokenTTL,
+	}
+}
+
+// Requested 本次请求是否使用Cookie模式：客户端请求了Cookie模式，或者请求本身是用Cookie认证的
+func (t *TokenCookies) Requested(c *gin.Context) bool {
+	return c.GetHeader(middleware.AuthModeHeader) == "cookie" || c.GetBool("auth_cookie")
+}
+
+// Respond 返回新签发的令牌：Cookie模式下写入Cookie，响应体中只有有效期；Bearer模式下令牌放在响应体中
+// body为响应体中的其他字段，可以为nil
+func (t *TokenCookies) Respond(c *gin.Context, tokens *service.TokenPair, body gin.H) {
+	if body == nil {
+		body = gin.H{}
+	}
+	body["expires_in"] = tokens.ExpiresIn
+	if t.Requested(c) {
+		t.Set(c, tokens)
+	} else {
+		body["token"] = tokens.AccessToken
+		body["refresh_token"] = tokens.RefreshToken
+	}
+	c.JSON(http.StatusOK, body)
+}
+
+// Set 写入访问令牌、刷新令牌和新的CSRF令牌
+// 访问令牌Cookie和令牌同时过期，浏览器不再发送后前端收到401，再用刷新令牌换取新令牌
+func (t *TokenCookies) Set(c *gin.Context, tokens *service.TokenPair) {
+	c.Header("Cache-Control", "no-store")
+	t.set(c, middleware.AccessTokenCookie, tokens.AccessToken, "/", int(tokens.ExpiresIn), true)
+	t.set(c, middleware.RefreshTokenCookie, tokens.RefreshToken, refreshCookiePath, int(t.refreshTTL.Seconds()), true)
+	t.set(c, middleware.CSRFCookie, rand.Text(), "/", int(t.refreshTTL.Seconds()), false)
+}
+
+// Clear 删除令牌Cookie，用于Cookie模式下的退出登录和刷新失败
+func (t *TokenCookies) Clear(c *gin.Context) {
+	t.set(c, middleware.AccessTokenCookie, "", "/", -1, true)
+	t.set(c, middleware.RefreshTokenCookie, "", refreshCookiePath, -1, true)
+	t.set(c, middleware.CSRFCookie, "", "/", -1, false)
+}
+
+// set 写入Cookie，maxAge小于0时删除（同时设置过去的Expires，兼容只认Expires的客户端）
+func (t *TokenCookies) set(c *gin.Context, name, value, path string, maxAge int, httpOnly bool) {
+	cookie := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   t.domain,
+		MaxAge:   maxAge,
+		Secure:   t.secure,
+		HttpOnly: httpOnly,
+		SameSite: t.sameSite,
+	}
+	if maxAge < 0 {
+		cookie.Expires = time.Unix(0, 0)
+	}
+	http.SetCookie(c.Writer, cookie)
+}
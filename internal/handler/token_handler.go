@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"errors"
+	"my-social-platform/internal/middleware"
+	"my-social-platform/internal/pkg/logger"
+	"my-social-platform/internal/service"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TokenHandler 令牌刷新相关的处理器
+type TokenHandler struct {
+	tokens  *service.TokenService
+	cookies *TokenCookies
+}
+
+// NewTokenHandler 创建TokenHandler
+func NewTokenHandler(tokens *service.TokenService, cookies *TokenCookies) *TokenHandler {
+	return &TokenHandler{tokens: tokens, cookies: cookies}
+}
+
+// Refresh 用刷新令牌换取新的访问令牌和刷新令牌
+// 每个刷新令牌只能使用一次，客户端必须保存响应中新的refresh_token
+// Cookie认证模式下请求体为空，刷新令牌从Cookie中读取（需要通过CSRF校验），新令牌同样写入Cookie
+func (h *TokenHandler) Refresh(c *gin.Context) {
+	var input struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	clientIP := c.ClientIP()
+
+	// 请求体在Cookie模式下是可选的
+	_ = c.ShouldBindJSON(&input)
+	if input.RefreshToken == "" {
+		if cookie, err := c.Cookie(middleware.RefreshTokenCookie); err == nil && cookie != "" {
+			if !middleware.ValidCSRF(c) {
+				logger.Security("CSRF_FAILED", "", clientIP, "Missing or invalid CSRF token for token refresh")
+				c.JSON(http.StatusForbidden, gin.H{"error": "Missing or invalid CSRF token", "code": "csrf_failed"})
+				return
+			}
+			input.RefreshToken = cookie
+			c.Set("auth_cookie", true)
+		}
+	}
+	if input.RefreshToken == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	user, tokens, err := h.tokens.Refresh(input.RefreshToken, clientInfo(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrRefreshTokenReused):
+			// 旧令牌被重复使用，可能已泄露，整个登录会话已被吊销
+			logger.Security("TOKEN_REUSE", "", clientIP, err.Error()+", session and token family revoked")
+			h.unauthorized(c)
+		case errors.Is(err, service.ErrInvalidRefreshToken):
+			h.unauthorized(c)
+		default:
+			logger.Log(logger.ERROR, "TOKEN_REFRESH", "", clientIP, "Failed to refresh token: "+err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh token"})
+		}
+		return
+	}
+
+	logger.Log(logger.INFO, "TOKEN_REFRESH", user.Username, clientIP, "Token refreshed")
+	h.cookies.Respond(c, tokens, nil)
+}
+
+// unauthorized 刷新令牌无效，Cookie模式下同时删除Cookie，前端据此回到未登录状态
+func (h *TokenHandler) unauthorized(c *gin.Context) {
+	if h.cookies.Requested(c) {
+		h.cookies.Clear(c)
+	}
+	c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired refresh token"})
+}
+
+// Logout 退出当前登录
+// 当前访问令牌立即失效，当前会话结束（会话的刷新令牌随之失效）；
+// 请求体中带上refresh_token时，对应的刷新令牌也一并吊销
+func (h *TokenHandler) Logout(c *gin.Context) {
+	var input struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	// 请求体是可选的
+	_ = c.ShouldBindJSON(&input)
+
+	userID := c.GetUint("user_id")
+	username := c.GetString("username")
+	clientIP := c.ClientIP()
+
+	if err := h.tokens.Logout(userID, c.GetUint("session_id"), c.GetString("jti"), c.MustGet("token_expires_at").(time.Time), input.RefreshToken); err != nil {
+		logger.Log(logger.ERROR, "LOGOUT", username, clientIP, "Failed to logout: "+err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to logout"})
+		return
+	}
+
+	logger.Log(logger.INFO, "LOGOUT", username, clientIP, "User logged out")
+	if h.cookies.Requested(c) {
+		h.cookies.Clear(c)
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out"})
+}
+
+// LogoutAll 退出所有设备，该用户之前签发的所有令牌失效
+func (h *TokenHandler) LogoutAll(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	username := c.GetString("username")
+	clientIP := c.ClientIP()
+
+	if err := h.tokens.LogoutAll(userID); err != nil {
+		logger.Log(logger.ERROR, "LOGOUT_ALL", username, clientIP, "Failed to logout all sessions: "+err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to logout"})
+		return
+	}
+
+	logger.Log(logger.INFO, "LOGOUT_ALL", username, clientIP, "User logged out of all sessions")
+	if h.cookies.Requested(c) {
+		h.cookies.Clear(c)
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out of all sessions"})
+}
@@ -0,0 +1,232 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"my-social-platform/internal/pkg/logger"
+	"my-social-platform/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TwoFactorHandler 两步验证相关的处理器：登录的第二步和开启/关闭两步验证
+type TwoFactorHandler struct {
+	twoFactor *service.TwoFactorService
+	tokens    *service.TokenService
+	cookies   *TokenCookies
+}
+
+// NewTwoFactorHandler 创建TwoFactorHandler
+func NewTwoFactorHandler(twoFactor *service.TwoFactorService, tokens *service.TokenService, cookies *TokenCookies) *TwoFactorHandler {
+	return &TwoFactorHandler{twoFactor: twoFactor, tokens: tokens, cookies: cookies}
+}
+
+// CompleteLogin 登录的第二步：提交 /login 返回的挑战令牌和认证器应用中的验证码（或一个恢复码）
+// 成功时和 /login 一样返回访问令牌和刷新令牌；验证码错误计入登录失败次数，失败次数过多时返回429
+func (h *TwoFactorHandler) CompleteLogin(c *gin.Context) {
+	var input struct {
+		ChallengeToken string `json:"challenge_token"`
+		Code           string `json:"code"`
+	}
+	clientIP := c.ClientIP()
+
+	if err := c.ShouldBindJSON(&input); err != nil || input.ChallengeToken == "" || input.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	user, recovery, err := h.twoFactor.CompleteLogin(input.ChallengeToken, input.Code, clientIP)
+	username := "unknown"
+	if user != nil {
+		username = user.Username
+	}
+	var blocked *service.LoginBlockedError
+	switch {
+	case err == nil:
+	case errors.As(err, &blocked):
+		retryAfter := int(math.Ceil(blocked.RetryAfter.Seconds()))
+		if blocked.Locked {
+			logger.Security("LOGIN_2FA_LOCKED", username, clientIP, fmt.Sprintf("Too many failed two-factor attempts, %s locked for %ds", blocked.Scope, retryAfter))
+		}
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many failed login attempts, please try again later"})
+		return
+	case errors.Is(err, service.ErrInvalidChallenge):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, service.ErrInvalidTwoFactorCode):
+		logger.Security("LOGIN_2FA_FAILED", username, clientIP, "Invalid two-factor code")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, service.ErrUserBanned):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	default:
+		logger.Log(logger.ERROR, "LOGIN", username, clientIP, "Two-factor login failed: "+err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Login failed"})
+		return
+	}
+
+	tokens, err := h.tokens.Issue(user, clientInfo(c))
+	if err != nil {
+		logger.Log(logger.ERROR, "LOGIN", user.Username, clientIP, "Failed to generate token: "+err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	if recovery {
+		logger.Security("LOGIN_RECOVERY_CODE", user.Username, clientIP, "Logged in with a recovery code")
+	}
+	logger.Log(logger.INFO, "LOGIN", user.Username, clientIP, "User logged in with two-factor authentication")
+	h.cookies.Respond(c, tokens, gin.H{"user": service.ToUserDTO(user)})
+}
+
+// Status 当前用户的两步验证状态
+func (h *TwoFactorHandler) Status(c *gin.Context) {
+	status, err := h.twoFactor.Status(c.GetUint("user_id"))
+	if err != nil {
+		logger.Log(logger.ERROR, "TWO_FACTOR", c.GetString("username"), c.ClientIP(), "Failed to get two-factor status: "+err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get two-factor status"})
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// Setup 开始开启两步验证，需要当前密码；返回密钥和 otpauth:// 链接（前端显示为二维码）
+func (h *TwoFactorHandler) Setup(c *gin.Context) {
+	var input struct {
+		Password string `json:"password"`
+	}
+	userID := c.GetUint("user_id")
+	username := c.GetString("username")
+	clientIP := c.ClientIP()
+
+	if err := c.ShouldBindJSON(&input); err != nil || input.Password == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	setup, err := h.twoFactor.Setup(userID, input.Password, clientIP)
+	if err != nil {
+		h.fail(c, "Failed to set up two-factor authentication", err)
+		return
+	}
+
+	logger.Log(logger.INFO, "TWO_FACTOR", username, clientIP, "Two-factor setup started")
+	c.JSON(http.StatusOK, setup)
+}
+
+// Confirm 提交验证码确认开启两步验证
+// 返回只显示一次的恢复码，同时吊销该用户的其他会话，并为当前会话签发一对新令牌
+func (h *TwoFactorHandler) Confirm(c *gin.Context) {
+	var input struct {
+		Code string `json:"code"`
+	}
+	userID := c.GetUint("user_id")
+	username := c.GetString("username")
+	clientIP := c.ClientIP()
+
+	if err := c.ShouldBindJSON(&input); err != nil || input.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	codes, err := h.twoFactor.Confirm(userID, input.Code, clientIP)
+	if err != nil {
+		h.fail(c, "Failed to enable two-factor authentication", err)
+		return
+	}
+
+	tokens, err := h.tokens.ResetSessions(userID, clientInfo(c))
+	if err != nil {
+		logger.Log(logger.ERROR, "TWO_FACTOR", username, clientIP, "Failed to reset sessions: "+err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Two-factor authentication enabled but failed to reset sessions, please log in again"})
+		return
+	}
+
+	logger.Security("TWO_FACTOR_ENABLED", username, clientIP, "Two-factor authentication enabled, other sessions revoked")
+	h.cookies.Respond(c, tokens, gin.H{"recovery_codes": codes})
+}
+
+// Disable 关闭两步验证，需要当前密码和一个验证码（或恢复码）
+func (h *TwoFactorHandler) Disable(c *gin.Context) {
+	var input struct {
+		Password string `json:"password"`
+		Code     string `json:"code"`
+	}
+	userID := c.GetUint("user_id")
+	username := c.GetString("username")
+	clientIP := c.ClientIP()
+
+	if err := c.ShouldBindJSON(&input); err != nil || input.Password == "" || input.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	if err := h.twoFactor.Disable(userID, input.Password, input.Code, clientIP); err != nil {
+		h.fail(c, "Failed to disable two-factor authentication", err)
+		return
+	}
+
+	logger.Security("TWO_FACTOR_DISABLED", username, clientIP, "Two-factor authentication disabled")
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码，原有的恢复码全部作废
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var input struct {
+		Code string `json:"code"`
+	}
+	userID := c.GetUint("user_id")
+	username := c.GetString("username")
+	clientIP := c.ClientIP()
+
+	if err := c.ShouldBindJSON(&input); err != nil || input.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	codes, err := h.twoFactor.RegenerateRecoveryCodes(userID, input.Code, clientIP)
+	if err != nil {
+		h.fail(c, "Failed to regenerate recovery codes", err)
+		return
+	}
+
+	logger.Security("RECOVERY_CODES_REGENERATED", username, clientIP, "Recovery codes regenerated")
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+// fail 把两步验证设置接口的错误转换为HTTP响应
+// 密码和验证码输错计入登录失败次数，失败次数过多时和登录一样返回429
+func (h *TwoFactorHandler) fail(c *gin.Context, msg string, err error) {
+	username := c.GetString("username")
+	clientIP := c.ClientIP()
+	var blocked *service.LoginBlockedError
+	switch {
+	case errors.As(err, &blocked):
+		retryAfter := int(math.Ceil(blocked.RetryAfter.Seconds()))
+		if blocked.Locked {
+			logger.Security("TWO_FACTOR_LOCKED", username, clientIP, fmt.Sprintf("Too many failed two-factor attempts, %s locked for %ds", blocked.Scope, retryAfter))
+		}
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many failed attempts, please try again later"})
+	case errors.Is(err, service.ErrWrongPassword):
+		logger.Security("TWO_FACTOR", username, clientIP, "Wrong password")
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidTwoFactorCode):
+		logger.Security("TWO_FACTOR", username, clientIP, "Invalid two-factor code")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrTwoFactorNotSetUp):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrTwoFactorAlreadyEnabled), errors.Is(err, service.ErrTwoFactorNotEnabled):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrTwoFactorRequired):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "code": "mfa_required_for_role"})
+	default:
+		logger.Log(logger.ERROR, "TWO_FACTOR", username, clientIP, msg+": "+err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+	}
+}
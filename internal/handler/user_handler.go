@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"errors"
+	"my-social-platform/internal/middleware"
 	"my-social-platform/internal/pkg/logger"
 	"my-social-platform/internal/service"
 	"net/http"
@@ -8,8 +10,8 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// UpdateProfileHandler 处理用户资料更新请求
-func UpdateProfileHandler(c *gin.Context) {
+// UpdateProfile 处理用户资料更新请求
+func (h *UserHandler) UpdateProfile(c *gin.Context) {
 	// 获取当前登录用户ID
 	userID, exists := c.Get("user_id")
 	if !exists {
@@ -26,6 +28,7 @@ func UpdateProfileHandler(c *gin.Context) {
 		Nickname string `json:"nickname"`
 		Bio      string `json:"bio"`
 		Avatar   string `json:"avatar"`
+		Email    string `json:"email"` // 为空表示不修改
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -34,17 +37,47 @@ func UpdateProfileHandler(c *gin.Context) {
 		return
 	}
 
+	// 邮箱用于找回密码，不允许通过个人访问令牌修改
+	if input.Email != "" && middleware.ViaPersonalToken(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "修改邮箱需要登录后操作", "code": "forbidden"})
+		return
+	}
+
 	// 更新用户资料
-	err := service.UpdateUserProfile(userID.(uint), input.Nickname, input.Bio)
+	err := h.users.UpdateProfile(userID.(uint), input.Nickname, input.Bio)
 	if err != nil {
 		logger.Log(logger.ERROR, "UPDATE_PROFILE", username.(string), clientIP, "更新资料失败: "+err.Error())
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新资料失败"})
 		return
 	}
 
+	// 如果提供了邮箱，更新邮箱
+	if input.Email != "" {
+		changed, err := h.users.UpdateEmail(userID.(uint), input.Email)
+		if err != nil {
+			if errors.Is(err, service.ErrInvalidEmail) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "fields": gin.H{"email": err.Error()}})
+				return
+			}
+			if errors.Is(err, service.ErrEmailTaken) {
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "fields": gin.H{"email": err.Error()}})
+				return
+			}
+			logger.Log(logger.ERROR, "UPDATE_PROFILE", username.(string), clientIP, "更新邮箱失败: "+err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "更新邮箱失败"})
+			return
+		}
+		// 新邮箱需要重新验证
+		if changed {
+			if err := h.verify.Send(c.Request.Context(), userID.(uint)); err != nil {
+				logger.Log(logger.ERROR, "UPDATE_PROFILE", username.(string), clientIP, "发送验证码失败: "+err.Error())
+			}
+		}
+	}
+
 	// 如果提供了头像URL，更新头像
 	if input.Avatar != "" {
-		err = service.UpdateAvatar(userID.(uint), input.Avatar)
+		err = h.users.UpdateAvatar(userID.(uint), input.Avatar)
 		if err != nil {
 			logger.Log(logger.ERROR, "UPDATE_PROFILE", username.(string), clientIP, "更新头像失败: "+err.Error())
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "更新头像失败"})
@@ -53,7 +86,7 @@ func UpdateProfileHandler(c *gin.Context) {
 	}
 
 	// 获取更新后的用户资料
-	updatedProfile, err := service.GetUserProfileByID(userID.(uint))
+	updatedProfile, err := h.users.GetProfileByID(userID.(uint))
 	if err != nil {
 		logger.Log(logger.ERROR, "UPDATE_PROFILE", username.(string), clientIP, "获取更新后的资料失败: "+err.Error())
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取更新后的资料失败"})
@@ -63,3 +96,45 @@ func UpdateProfileHandler(c *gin.Context) {
 	logger.Log(logger.INFO, "UPDATE_PROFILE", username.(string), clientIP, "用户资料更新成功")
 	c.JSON(http.StatusOK, gin.H{"message": "资料更新成功", "user": updatedProfile})
 }
+
+// ChangePassword 修改当前登录用户的密码
+// 校验当前密码和密码策略，成功后吊销该用户的所有令牌（其他设备需要重新登录），
+// 并为当前会话返回一对新令牌
+func (h *UserHandler) ChangePassword(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	username := c.GetString("username")
+	clientIP := c.ClientIP()
+
+	var input struct {
+		CurrentPassword string `json:"current_password"`
+		NewPassword     string `json:"new_password"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil || input.CurrentPassword == "" || input.NewPassword == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	if err := h.users.ChangePassword(userID, input.CurrentPassword, input.NewPassword); err != nil {
+		switch {
+		case errors.Is(err, service.ErrWrongPassword):
+			logger.Log(logger.WARNING, "CHANGE_PASSWORD", username, clientIP, "Wrong current password")
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrPasswordPolicy):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			logger.Log(logger.ERROR, "CHANGE_PASSWORD", username, clientIP, "Failed to change password: "+err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change password"})
+		}
+		return
+	}
+
+	tokens, err := h.tokens.ResetSessions(userID, clientInfo(c))
+	if err != nil {
+		logger.Log(logger.ERROR, "CHANGE_PASSWORD", username, clientIP, "Failed to reset sessions: "+err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Password changed but failed to reset sessions, please log in again"})
+		return
+	}
+
+	logger.Log(logger.INFO, "CHANGE_PASSWORD", username, clientIP, "Password changed, other sessions revoked")
+	h.cookies.Respond(c, tokens, nil)
+}
@@ -0,0 +1,77 @@
+package health
+
+import (
+	"context"
+	"my-social-platform/internal/pkg/keystore"
+	"my-social-platform/internal/repository"
+	"os"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 检查状态
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// Check 一项依赖检查
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// Result 单项检查的结果
+type Result struct {
+	Status    string `json:"status"`
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Run 依次执行所有检查，返回每项的结果以及是否全部通过
+// 就绪检查接口 /readyz 和 doctor 命令共用这里的检查
+func Run(ctx context.Context, checks ...Check) (map[string]Result, bool) {
+	results := make(map[string]Result, len(checks))
+	ok := true
+	for _, check := range checks {
+		start := time.Now()
+		err := check.Run(ctx)
+		r := Result{Status: StatusOK, LatencyMS: time.Since(start).Milliseconds()}
+		if err != nil {
+			r.Status = StatusFail
+			r.Error = err.Error()
+			ok = false
+		}
+		results[check.Name] = r
+	}
+	return results, ok
+}
+
+// Database 检查数据库连接池是否可用，最多等待2秒
+func Database(db *gorm.DB) Check {
+	return Check{Name: "database", Run: func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+		defer cancel()
+		return repository.Ping(ctx, db)
+	}}
+}
+
+// WritableDir 实际写入并删除一个临时文件，确认目录存在且可写
+func WritableDir(name, dir string) Check {
+	return Check{Name: name, Run: func(ctx context.Context) error {
+		f, err := os.CreateTemp(dir, ".healthcheck-*")
+		if err != nil {
+			return err
+		}
+		f.Close()
+		return os.Remove(f.Name())
+	}}
+}
+
+// JWTKeys 检查当前签名密钥能否正常签名和验签
+func JWTKeys(keys *keystore.Store) Check {
+	return Check{Name: "jwt_keys", Run: func(ctx context.Context) error {
+		return keys.Check()
+	}}
+}
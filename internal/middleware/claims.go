@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Claims 访问令牌的声明
+// 标准声明(RegisteredClaims)：
+//   - iss 签发方，固定为配置的auth.issuer
+//   - aud 受众，本服务只接受auth.audience，为其他客户端(如移动端、管理后台)签发的令牌会被拒绝
+//   - sub 用户ID（字符串）
+//   - jti 令牌ID，退出登录时按jti吊销
+//   - iat / nbf / exp 签发时间、生效时间、过期时间
+//
+// 自定义声明：
+//   - username 用户名，方便记录日志
+//   - ver 签发时用户的令牌版本，退出所有设备后版本加一，旧令牌随之失效
+//   - mfa_enroll 用户的角色要求两步验证但还没有开启，此时只能访问开启两步验证的接口
+//   - role、perms 签发时用户的角色和该角色的权限，修改角色后令牌版本加一，旧令牌失效
+//   - sid 令牌所属的登录会话，会话被结束后令牌失效
+type Claims struct {
+	Username     string   `json:"username"`
+	TokenVersion int      `json:"ver"`
+	SessionID    uint     `json:"sid,omitempty"`
+	EnrollMFA    bool     `json:"mfa_enroll,omitempty"`
+	Role         string   `json:"role,omitempty"`
+	Permissions  []string `json:"perms,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// ChallengeClaims 两步验证挑战令牌的声明
+// 密码正确后签发，受众为 auth.audience 加上 ":mfa" 后缀，因此不能当作访问令牌使用
+type ChallengeClaims struct {
+	TokenVersion int `json:"ver"`
+	jwt.RegisteredClaims
+}
+
+// UserID 从sub解析用户ID
+func (c *ChallengeClaims) UserID() (uint, error) {
+	return parseSubject(c.Subject)
+}
+
+// UserID 从sub解析用户ID
+func (c *Claims) UserID() (uint, error) {
+	return parseSubject(c.Subject)
+}
+
+func parseSubject(subject string) (uint, error) {
+	id, err := strconv.ParseUint(subject, 10, 64)
+	if err != nil || id == 0 {
+		return 0, errors.New("invalid subject")
+	}
+	return uint(id), nil
+}
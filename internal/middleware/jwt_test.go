@@ -0,0 +1,262 @@
+package middleware_test
+
+import (
+	"my-social-platform/internal/app/apptest"
+	"my-social-platform/internal/middleware"
+	"my-social-platform/internal/model"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newRouter 和serve命令一样注册接口：GET/PUT /profile 接受profile范围的个人访问令牌，POST /logout 只接受登录令牌
+func newRouter(a *apptest.App) *gin.Engine {
+	r := gin.New()
+	ok := func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"user_id": c.GetUint("user_id"), "username": c.GetString("username")})
+	}
+	r.GET("/profile", a.JWT.Middleware(model.ScopeProfileRead), ok)
+	r.PUT("/profile", a.JWT.Middleware(model.ScopeProfileWrite), ok)
+	r.POST("/logout", a.JWT.Middleware(), ok)
+	return r
+}
+
+type request struct {
+	method  string
+	path    string
+	header  map[string]string
+	cookies map[string]string
+}
+
+func (r request) do(h http.Handler) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(r.method, r.path, nil)
+	for k, v := range r.header {
+		req.Header.Set(k, v)
+	}
+	for k, v := range r.cookies {
+		req.AddCookie(&http.Cookie{Name: k, Value: v})
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func bearer(token string) map[string]string {
+	return map[string]string{"Authorization": "Bearer " + token}
+}
+
+func TestMiddlewareBearer(t *testing.T) {
+	a := apptest.New(t)
+	alice := a.CreateUser(t, "alice", "Spring2025x")
+	pair, err := a.TokenService.Issue(alice, apptest.Client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := newRouter(a)
+
+	tests := []struct {
+		name string
+		req  request
+		want int
+	}{
+		{"missing token", request{method: "GET", path: "/profile"}, http.StatusUnauthorized},
+		{"garbage token", request{method: "GET", path: "/profile", header: bearer("not-a-jwt")}, http.StatusUnauthorized},
+		{"valid token", request{method: "GET", path: "/profile", header: bearer(pair.AccessToken)}, http.StatusOK},
+		{"valid token, unscoped route", request{method: "POST", path: "/logout", header: bearer(pair.AccessToken)}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		if w := tt.req.do(r); w.Code != tt.want {
+			t.Errorf("%s: status %d, want %d (%s)", tt.name, w.Code, tt.want, w.Body)
+		}
+	}
+}
+
+func TestMiddlewareRejectsRevokedTokens(t *testing.T) {
+	a := apptest.New(t)
+	alice := a.CreateUser(t, "alice", "Spring2025x")
+	r := newRouter(a)
+
+	// 退出登录：吊销jti
+	pair, err := a.TokenService.Issue(alice, apptest.Client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, _ := a.JWT.ParseJWT(pair.AccessToken)
+	if err := a.Revocations.Revoke(claims.ID, alice.ID, claims.ExpiresAt.Time); err != nil {
+		t.Fatal(err)
+	}
+	if w := (request{method: "GET", path: "/profile", header: bearer(pair.AccessToken)}).do(r); w.Code != http.StatusUnauthorized {
+		t.Errorf("revoked jti: status %d, want 401", w.Code)
+	}
+
+	// 退出所有设备：令牌版本加一
+	pair, err = a.TokenService.Issue(alice, apptest.Client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.TokenService.LogoutAll(alice.ID); err != nil {
+		t.Fatal(err)
+	}
+	if w := (request{method: "GET", path: "/profile", header: bearer(pair.AccessToken)}).do(r); w.Code != http.StatusUnauthorized {
+		t.Errorf("after logout all: status %d, want 401", w.Code)
+	}
+}
+
+func TestMiddlewareRejectsRevokedSession(t *testing.T) {
+	a := apptest.New(t)
+	alice := a.CreateUser(t, "alice", "Spring2025x")
+	r := newRouter(a)
+
+	// 在设备管理中结束会话
+	pair, err := a.TokenService.Issue(alice, apptest.Client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, _ := a.JWT.ParseJWT(pair.AccessToken)
+	if err := a.SessionService.Revoke(alice.ID, claims.SessionID); err != nil {
+		t.Fatal(err)
+	}
+	if w := (request{method: "GET", path: "/profile", header: bearer(pair.AccessToken)}).do(r); w.Code != http.StatusUnauthorized {
+		t.Errorf("revoked session: status %d, want 401", w.Code)
+	}
+}
+
+func TestMiddlewarePersonalAccessToken(t *testing.T) {
+	a := apptest.New(t)
+	alice := a.CreateUser(t, "alice", "Spring2025x")
+	_, raw, err := a.PersonalTokenService.Create(alice.ID, "bot", []string{model.ScopeProfileRead}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, expired, err := a.PersonalTokenService.Create(alice.ID, "expired", []string{model.ScopeProfileRead}, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, revoked, err := a.PersonalTokenService.Create(alice.ID, "revoked", []string{model.ScopeProfileRead}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.PersonalTokenService.Revoke(alice.ID, info.ID); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	r := newRouter(a)
+
+	tests := []struct {
+		name string
+		req  request
+		want int
+	}{
+		{"granted scope", request{method: "GET", path: "/profile", header: bearer(raw)}, http.StatusOK},
+		{"missing scope", request{method: "PUT", path: "/profile", header: bearer(raw)}, http.StatusForbidden},
+		{"route without scopes", request{method: "POST", path: "/logout", header: bearer(raw)}, http.StatusForbidden},
+		{"unknown token", request{method: "GET", path: "/profile", header: bearer(model.PersonalAccessTokenPrefix + "nope")}, http.StatusUnauthorized},
+		{"expired token", request{method: "GET", path: "/profile", header: bearer(expired)}, http.StatusUnauthorized},
+		{"revoked token", request{method: "GET", path: "/profile", header: bearer(revoked)}, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		if w := tt.req.do(r); w.Code != tt.want {
+			t.Errorf("%s: status %d, want %d (%s)", tt.name, w.Code, tt.want, w.Body)
+		}
+	}
+
+	if err := a.UserService.SetBanned("alice", true); err != nil {
+		t.Fatal(err)
+	}
+	if w := (request{method: "GET", path: "/profile", header: bearer(raw)}).do(r); w.Code != http.StatusUnauthorized {
+		t.Errorf("banned user: status %d, want 401", w.Code)
+	}
+}
+
+func TestMiddlewareCookieCSRF(t *testing.T) {
+	a := apptest.New(t)
+	alice := a.CreateUser(t, "alice", "Spring2025x")
+	pair, err := a.TokenService.Issue(alice, apptest.Client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := newRouter(a)
+	cookies := map[string]string{middleware.AccessTokenCookie: pair.AccessToken, middleware.CSRFCookie: "csrf-value"}
+
+	tests := []struct {
+		name string
+		req  request
+		want int
+	}{
+		{"GET without CSRF header", request{method: "GET", path: "/profile", cookies: cookies}, http.StatusOK},
+		{"PUT without CSRF header", request{method: "PUT", path: "/profile", cookies: cookies}, http.StatusForbidden},
+		{"PUT with wrong CSRF header", request{method: "PUT", path: "/profile", cookies: cookies,
+			header: map[string]string{middleware.CSRFHeader: "other"}}, http.StatusForbidden},
+		{"PUT without CSRF cookie", request{method: "PUT", path: "/profile",
+			cookies: map[string]string{middleware.AccessTokenCookie: pair.AccessToken},
+			header:  map[string]string{middleware.CSRFHeader: ""}}, http.StatusForbidden},
+		{"PUT with CSRF header", request{method: "PUT", path: "/profile", cookies: cookies,
+			header: map[string]string{middleware.CSRFHeader: "csrf-value"}}, http.StatusOK},
+		// 请求头中的令牌优先，不需要CSRF
+		{"bearer with stale cookie", request{method: "PUT", path: "/profile", cookies: map[string]string{middleware.AccessTokenCookie: "stale"},
+			header: bearer(pair.AccessToken)}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		if w := tt.req.do(r); w.Code != tt.want {
+			t.Errorf("%s: status %d, want %d (%s)", tt.name, w.Code, tt.want, w.Body)
+		}
+	}
+}
+
+func TestRequirePermission(t *testing.T) {
+	a := apptest.New(t)
+	alice := a.CreateUser(t, "alice", "Spring2025x")
+	a.CreateUser(t, "carol", "Winter2025z")
+	if _, err := a.UserService.SetRole("carol", model.RoleModerator); err != nil {
+		t.Fatal(err)
+	}
+	carol := a.User(t, "carol")
+
+	r := gin.New()
+	r.DELETE("/admin/posts/:id", a.JWT.Middleware(), middleware.RequirePermission(model.PermPostDeleteAny), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	r.GET("/posts/:id", a.JWT.Middleware(), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"can_delete": middleware.HasPermission(c, model.PermPostDeleteAny)})
+	})
+
+	tokens := make(map[string]string)
+	for _, tt := range []struct {
+		user      *model.User
+		want      int
+		canDelete string
+	}{{alice, http.StatusForbidden, "false"}, {carol, http.StatusNoContent, "true"}} {
+		pair, err := a.TokenService.Issue(tt.user, apptest.Client)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tokens[tt.user.Username] = pair.AccessToken
+		w := (request{method: "DELETE", path: "/admin/posts/1", header: bearer(pair.AccessToken)}).do(r)
+		if w.Code != tt.want {
+			t.Errorf("%s: status %d, want %d", tt.user.Username, w.Code, tt.want)
+		}
+		if w.Code == http.StatusForbidden && !strings.Contains(w.Body.String(), `"code":"forbidden"`) {
+			t.Errorf("%s: 403 body %s", tt.user.Username, w.Body)
+		}
+		w = (request{method: "GET", path: "/posts/1", header: bearer(pair.AccessToken)}).do(r)
+		if !strings.Contains(w.Body.String(), `"can_delete":`+tt.canDelete) {
+			t.Errorf("%s: HasPermission returned %s", tt.user.Username, w.Body)
+		}
+	}
+
+	// 撤销角色后，带着旧权限的令牌立即失效
+	if _, err := a.UserService.SetRole("carol", model.RoleUser); err != nil {
+		t.Fatal(err)
+	}
+	if w := (request{method: "DELETE", path: "/admin/posts/1", header: bearer(tokens["carol"])}).do(r); w.Code != http.StatusUnauthorized {
+		t.Errorf("token issued before the role was revoked: status %d, want 401", w.Code)
+	}
+}
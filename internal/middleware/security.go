@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HSTS 添加 Strict-Transport-Security 响应头
+// 浏览器收到后，在maxAge内访问本站都会直接使用HTTPS，防止被降级到明文HTTP
+// 只应在HTTPS服务上使用；includeSubdomains 为true时同时作用于所有子域名
+func HSTS(maxAge time.Duration, includeSubdomains bool) gin.HandlerFunc {
+	value := fmt.Sprintf("max-age=%d", int64(maxAge.Seconds()))
+	if includeSubdomains {
+		value += "; includeSubDomains"
+	}
+	return func(c *gin.Context) {
+		c.Header("Strict-Transport-Security", value)
+		c.Next()
+	}
+}
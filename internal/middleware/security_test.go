@@ -0,0 +1,28 @@
+package middleware_test
+
+import (
+	"my-social-platform/internal/middleware"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestHSTS(t *testing.T) {
+	tests := []struct {
+		includeSubdomains bool
+		want              string
+	}{
+		{false, "max-age=15552000"},
+		{true, "max-age=15552000; includeSubDomains"},
+	}
+	for _, tt := range tests {
+		r := gin.New()
+		r.GET("/", middleware.HSTS(180*24*time.Hour, tt.includeSubdomains), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+		w := request{method: http.MethodGet, path: "/"}.do(r)
+		if got := w.Header().Get("Strict-Transport-Security"); got != tt.want {
+			t.Errorf("includeSubdomains=%v: got %q, want %q", tt.includeSubdomains, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// EmailVerification 邮箱验证码
+// 验证码只有6位数字，数据库保存的是它和邮箱一起计算的SHA-256哈希；
+// 尝试次数有上限，防止穷举
+type EmailVerification struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	Email     string     `json:"email" gorm:"size:255;not null"` // 发送验证码时的邮箱，用户之后修改了邮箱时验证码失效
+	CodeHash  string     `json:"-" gorm:"size:64;not null"`
+	Attempts  int        `json:"attempts" gorm:"not null;default:0"` // 已校验的次数，每次比较验证码之前加一
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"` // 使用或作废时写入
+}
+
+// TableName 自定义表名
+func (EmailVerification) TableName() string {
+	return "email_verification"
+}
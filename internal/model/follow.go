@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// Follow 关注关系：FollowerID 关注了 FolloweeID
+type Follow struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	FollowerID uint      `json:"follower_id" gorm:"not null;uniqueIndex:idx_follow_pair"`       // 关注者ID
+	FolloweeID uint      `json:"followee_id" gorm:"not null;uniqueIndex:idx_follow_pair;index"` // 被关注者ID
+}
+
+// TableName 自定义表名
+func (Follow) TableName() string {
+	return "follow"
+}
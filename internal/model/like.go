@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// PostLike 帖子点赞记录，同一用户对同一帖子只能点赞一次
+type PostLike struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	PostID    uint      `json:"post_id" gorm:"not null;uniqueIndex:idx_post_like_pair"`       // 帖子ID
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_post_like_pair;index"` // 点赞用户ID
+}
+
+// TableName 自定义表名
+func (PostLike) TableName() string {
+	return "post_like"
+}
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// PasswordResetToken 找回密码的一次性令牌
+// 和刷新令牌一样，数据库只保存SHA-256哈希，原始令牌只出现在发给用户的邮件链接中
+type PasswordResetToken struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	TokenHash string     `json:"-" gorm:"size:64;not null;uniqueIndex"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"` // 使用或作废时写入
+}
+
+// TableName 自定义表名
+func (PasswordResetToken) TableName() string {
+	return "password_reset_token"
+}
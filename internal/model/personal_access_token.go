@@ -0,0 +1,38 @@
+package model
+
+import "time"
+
+// PersonalAccessTokenPrefix 个人访问令牌的前缀，中间件据此区分个人访问令牌和JWT
+const PersonalAccessTokenPrefix = "msp_"
+
+// 个人访问令牌的权限范围，格式为 资源:操作
+const (
+	ScopeProfileRead  = "profile:read"  // 读取个人资料
+	ScopeProfileWrite = "profile:write" // 修改昵称、签名等个人资料
+	ScopePostsRead    = "posts:read"    // 读取帖子
+	ScopePostsWrite   = "posts:write"   // 发帖、删除自己的帖子、上传图片
+)
+
+// Scopes 全部权限范围
+var Scopes = []string{ScopeProfileRead, ScopeProfileWrite, ScopePostsRead, ScopePostsWrite}
+
+// PersonalAccessToken 个人访问令牌，供脚本和机器人调用API
+// 数据库只保存SHA-256哈希，原始令牌只在创建时返回一次；只能访问权限范围内的接口
+type PersonalAccessToken struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UserID     uint       `json:"user_id" gorm:"not null;index"`
+	Name       string     `json:"name" gorm:"size:64;not null"`
+	Prefix     string     `json:"prefix" gorm:"size:16"` // 令牌开头的几个字符，方便用户辨认
+	TokenHash  string     `json:"-" gorm:"size:64;not null;uniqueIndex"`
+	Scopes     string     `json:"-" gorm:"size:255;not null"` // 逗号分隔的权限范围
+	ExpiresAt  *time.Time `json:"expires_at"`                 // 为空表示永不过期
+	LastUsedAt *time.Time `json:"last_used_at"`
+	LastUsedIP string     `json:"last_used_ip" gorm:"size:64"`
+	RevokedAt  *time.Time `json:"-"`
+}
+
+// TableName 自定义表名
+func (PersonalAccessToken) TableName() string {
+	return "personal_access_token"
+}
@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// RecoveryCode 两步验证的一次性恢复码
+// 手机丢失时用来代替验证码登录，每个只能使用一次；数据库只保存SHA-256哈希
+type RecoveryCode struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	CodeHash  string     `json:"-" gorm:"size:64;not null;uniqueIndex"`
+	UsedAt    *time.Time `json:"used_at"`
+}
+
+// TableName 自定义表名
+func (RecoveryCode) TableName() string {
+	return "recovery_code"
+}
@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// RefreshToken 刷新令牌
+// 数据库只保存令牌的SHA-256哈希，原始令牌只在签发时返回给客户端一次
+// 每次刷新都会作废旧令牌并签发新令牌，同一次登录产生的令牌共享FamilyID；
+// 已使用过的令牌再次出现说明被盗用，整个家族会被吊销
+type RefreshToken struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	FamilyID  string     `json:"family_id" gorm:"size:64;not null;index"`
+	TokenHash string     `json:"-" gorm:"size:64;not null;uniqueIndex"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`    // 轮换时写入
+	RevokedAt *time.Time `json:"revoked_at"` // 吊销时写入
+}
+
+// TableName 自定义表名
+func (RefreshToken) TableName() string {
+	return "refresh_token"
+}
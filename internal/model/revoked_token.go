@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// RevokedToken 已吊销的访问令牌(JWT)
+// 用户退出登录时记录令牌的jti，直到令牌本身过期之前都拒绝它；过期后记录可以删除
+type RevokedToken struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	JTI       string    `json:"jti" gorm:"column:jti;size:64;not null;uniqueIndex"`
+	UserID    uint      `json:"user_id" gorm:"not null;index"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"index"` // 令牌本身的过期时间
+}
+
+// TableName 自定义表名
+func (RevokedToken) TableName() string {
+	return "revoked_token"
+}
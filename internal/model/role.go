@@ -0,0 +1,29 @@
+package model
+
+// Role 角色，内置 user、moderator、admin 三个，由数据库迁移创建
+type Role struct {
+	Name        string `gorm:"primaryKey;size:32" json:"name"`
+	Description string `gorm:"size:255" json:"description"`
+}
+
+// TableName 自定义表名
+func (Role) TableName() string {
+	return "role"
+}
+
+// RolePermission 角色拥有的权限，一个角色一行一个权限
+type RolePermission struct {
+	Role       string `gorm:"primaryKey;size:32" json:"role"`
+	Permission string `gorm:"primaryKey;size:64" json:"permission"`
+}
+
+// TableName 自定义表名
+func (RolePermission) TableName() string {
+	return "role_permission"
+}
+
+// 权限，格式为 资源.操作[.范围]
+const (
+	PermPostDeleteAny  = "post.delete.any"  // 删除任何人的帖子（作者删除自己的帖子不需要权限）
+	PermUserRoleManage = "user.role.manage" // 查看角色、授予和撤销用户的角色
+)
@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// Session 登录会话
+// 每次登录（密码、两步验证或统一身份认证）创建一个会话，对应一个刷新令牌家族；
+// 访问令牌的sid声明指向会话，结束会话后该会话签发的访问令牌和刷新令牌立即失效
+type Session struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UserID     uint       `json:"user_id" gorm:"not null;index"`
+	FamilyID   string     `json:"-" gorm:"size:64;not null;uniqueIndex"` // 刷新令牌家族
+	JTI        string     `json:"-" gorm:"size:64;index"`                // 最近一次签发的访问令牌的jti
+	UserAgent  string     `json:"user_agent" gorm:"size:512"`
+	IP         string     `json:"ip" gorm:"size:64"` // 最近一次访问的IP
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	ExpiresAt  time.Time  `json:"expires_at"` // 最近一次签发的刷新令牌的过期时间，之后会话自然结束
+	RevokedAt  *time.Time `json:"revoked_at"` // 退出登录或被用户结束的时间
+}
+
+// TableName 自定义表名
+func (Session) TableName() string {
+	return "session"
+}
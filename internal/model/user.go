@@ -4,20 +4,39 @@ import "time"
 
 // 用户模型
 type User struct {
-	ID          uint       `gorm:"primaryKey" json:"id"`
-	CreatedAt   time.Time  `json:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at"`
-	DeletedAt   *time.Time `json:"deleted_at" gorm:"index"`
-	Username    string     `json:"username" gorm:"unique;not null"`
-	Password    string     `json:"password"`
-	Nickname    string     `json:"nickname"`
-	Avatar      string     `json:"avatar"`
-	Bio         string     `json:"bio"`                           // 个性签名
-	FollowCount int        `json:"follow_count" gorm:"default:0"` // 关注数
-	FansCount   int        `json:"fans_count" gorm:"default:0"`   // 粉丝数
-	LikeCount   int        `json:"like_count" gorm:"default:0"`   // 获赞数
+	ID              uint       `gorm:"primaryKey" json:"id"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	DeletedAt       *time.Time `json:"deleted_at" gorm:"index"`
+	Username        string     `json:"username" gorm:"unique;not null"`
+	Email           *string    `json:"email" gorm:"size:255;uniqueIndex:idx_users_email"` // 找回密码使用，可以为空
+	Password        string     `json:"password"`
+	Nickname        string     `json:"nickname"`
+	Avatar          string     `json:"avatar"`
+	Bio             string     `json:"bio"`                                            // 个性签名
+	FollowCount     int        `json:"follow_count" gorm:"default:0"`                  // 关注数
+	FansCount       int        `json:"fans_count" gorm:"default:0"`                    // 粉丝数
+	LikeCount       int        `json:"like_count" gorm:"default:0"`                    // 获赞数
+	BannedAt        *time.Time `json:"banned_at"`                                      // 封禁时间，为空表示正常账号
+	TokenVersion    int        `json:"-" gorm:"not null;default:0"`                    // 令牌版本，加一后之前签发的所有访问令牌失效
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`                              // 邮箱验证时间，为空表示未验证；修改邮箱后清空
+	StudentVerified bool       `json:"student_verified" gorm:"not null;default:false"` // 认证学生：验证的邮箱属于学校邮箱域名
+	Role            string     `json:"role" gorm:"size:32;not null;default:user"`      // 角色：user、moderator 或 admin
+	TOTPSecret      string     `json:"-" gorm:"size:64"`                               // 两步验证密钥，开启前（确认中）也会保存
+	TOTPEnabledAt   *time.Time `json:"-"`                                              // 开启两步验证的时间，为空表示未开启
+	TOTPLastStep    int64      `json:"-" gorm:"not null;default:0"`                    // 最近一次使用的验证码周期，防止同一个验证码被重复使用
 }
 
+// 角色
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+// Roles 全部角色
+var Roles = []string{RoleUser, RoleModerator, RoleAdmin}
+
 // TableName 自定义表名
 func (User) TableName() string {
 	return "users"
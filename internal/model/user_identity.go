@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// UserIdentity 用户在外部身份提供方（学校统一身份认证）的身份
+// 同一个身份提供方中 (Issuer, Subject) 唯一确定一个人，用它而不是邮箱来识别用户，邮箱可能变化
+type UserIdentity struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UserID    uint      `json:"user_id" gorm:"not null;index"`
+	Issuer    string    `json:"issuer" gorm:"size:255;not null;uniqueIndex:idx_user_identity_subject"`
+	Subject   string    `json:"subject" gorm:"size:255;not null;uniqueIndex:idx_user_identity_subject"`
+	Email     string    `json:"email" gorm:"size:255"` // 关联时身份提供方返回的邮箱，仅供排查问题
+}
+
+// TableName 自定义表名
+func (UserIdentity) TableName() string {
+	return "user_identity"
+}
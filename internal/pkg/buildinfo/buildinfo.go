@@ -0,0 +1,58 @@
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// 构建信息，发布时通过 -ldflags 注入，例如:
+//
+//	go build -ldflags "-X my-social-platform/internal/pkg/buildinfo.Commit=$(git rev-parse HEAD) \
+//	  -X my-social-platform/internal/pkg/buildinfo.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)" ./cmd
+//
+// 未注入Commit时从Go自带的VCS构建信息中读取；BuildTime只能注入，
+// VCS构建信息中只有最后一次提交的时间（vcs.time），单独作为CommitTime返回
+var (
+	Commit    = ""
+	BuildTime = ""
+)
+
+// Info 构建信息
+type Info struct {
+	Commit     string `json:"commit"`
+	CommitTime string `json:"commit_time"` // 提交时间，来自VCS构建信息
+	BuildTime  string `json:"build_time"`  // 编译时间，未通过 -ldflags 注入时为 unknown
+	Modified   bool   `json:"modified"`    // 构建时工作区是否有未提交的修改
+	GoVersion  string `json:"go_version"`
+}
+
+// Get 返回当前二进制的构建信息
+func Get() Info {
+	info := Info{
+		Commit:    Commit,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+	}
+
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = s.Value
+				}
+			case "vcs.time":
+				info.CommitTime = s.Value
+			case "vcs.modified":
+				info.Modified = s.Value == "true"
+			}
+		}
+	}
+
+	for _, field := range []*string{&info.Commit, &info.CommitTime, &info.BuildTime} {
+		if *field == "" {
+			*field = "unknown"
+		}
+	}
+	return info
+}
@@ -0,0 +1,36 @@
+package keystore
+
+import "math/big"
+
+// JWK RFC 7517 格式的RSA公钥
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// JWKS 公钥集合，发布在 /.well-known/jwks.json，其他服务可以用它独立验证令牌
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS 导出全部密钥（包括已退役、仍用于验签的密钥）的公钥
+func (s *Store) JWKS() JWKS {
+	keys := s.Keys()
+	set := JWKS{Keys: make([]JWK, 0, len(keys))}
+	for _, k := range keys {
+		pub := k.Public()
+		set.Keys = append(set.Keys, JWK{
+			Kty: "RSA",
+			Use: "sig",
+			Alg: "RS256",
+			Kid: k.ID,
+			N:   b64(pub.N.Bytes()),
+			E:   b64(big.NewInt(int64(pub.E)).Bytes()),
+		})
+	}
+	return set
+}
@@ -0,0 +1,382 @@
+package keystore
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 密钥目录结构：
+//
+//	keys/<kid>.pem   每个RSA私钥一个文件，PEM头部Created记录生成时间
+//	keys/active      当前用于签名的kid
+//
+// 多个服务实例共享同一个目录时使用相同的密钥；新密钥生成后旧密钥仍保留用于验签，
+// 直到用它签发的令牌全部过期后再由 keys prune 删除
+
+const (
+	activeFile = "active"
+	keyBits    = 2048
+	// reloadOnMissInterval 遇到未知kid时最多这么久从磁盘重新加载一次，防止伪造的kid造成大量磁盘读取
+	reloadOnMissInterval = 5 * time.Second
+)
+
+// Key 一个签名密钥
+type Key struct {
+	ID        string // kid，公钥的RFC 7638指纹
+	Private   *rsa.PrivateKey
+	CreatedAt time.Time
+}
+
+// Public 公钥
+func (k *Key) Public() *rsa.PublicKey {
+	return &k.Private.PublicKey
+}
+
+// Store 持有目录中的全部密钥，并记住当前用于签名的密钥
+type Store struct {
+	dir string
+
+	mu         sync.RWMutex
+	keys       map[string]*Key
+	active     string
+	lastReload time.Time
+}
+
+// Load 加载目录中的密钥，目录中没有可用的密钥时返回错误
+func Load(dir string) (*Store, error) {
+	s := &Store{dir: dir}
+	if _, err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// LoadOrCreate 加载目录中的密钥，目录为空时生成第一个密钥
+// 旧版本的 keys/private.pem 会被导入为第一个密钥，已签发的令牌不受影响
+// 多个实例同时启动时只有一个实例生成的密钥会成为active，其余实例使用它
+func LoadOrCreate(dir string) (*Store, error) {
+	s := &Store{dir: dir}
+	if _, err := os.Stat(filepath.Join(dir, activeFile)); errors.Is(err, os.ErrNotExist) {
+		if err := s.create(); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// create 生成（或从旧版本的private.pem导入）第一个密钥
+func (s *Store) create() error {
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return err
+	}
+
+	key, err := importLegacy(filepath.Join(s.dir, "private.pem"))
+	if err != nil {
+		return err
+	}
+	if key == nil {
+		if key, err = generate(); err != nil {
+			return err
+		}
+	}
+	if err := writeKey(s.dir, key); err != nil {
+		return err
+	}
+
+	// O_EXCL保证并发启动时只有一个实例能写入active
+	f, err := os.OpenFile(filepath.Join(s.dir, activeFile), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if errors.Is(err, os.ErrExist) {
+		os.Remove(filepath.Join(s.dir, key.ID+".pem"))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(key.ID + "\n"); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Rotate 生成新密钥并设为active，旧密钥保留用于验签
+func (s *Store) Rotate() (*Key, error) {
+	key, err := generate()
+	if err != nil {
+		return nil, err
+	}
+	if err := writeKey(s.dir, key); err != nil {
+		return nil, err
+	}
+	if err := writeFileAtomic(filepath.Join(s.dir, activeFile), []byte(key.ID+"\n")); err != nil {
+		return nil, err
+	}
+	if _, err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Prune 删除退役时间超过maxAge的密钥，返回被删除的kid
+// 一个密钥的退役时间是下一个密钥的生成时间；maxAge不小于访问令牌有效期时，
+// 用被删除密钥签发的令牌都已过期
+func (s *Store) Prune(maxAge time.Duration) ([]string, error) {
+	keys := s.Keys()
+	active := s.Active()
+	now := time.Now()
+
+	var removed []string
+	for i, k := range keys {
+		if k.ID == active.ID || i == len(keys)-1 {
+			continue
+		}
+		retiredAt := keys[i+1].CreatedAt
+		if now.Sub(retiredAt) < maxAge {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.dir, k.ID+".pem")); err != nil {
+			return removed, err
+		}
+		removed = append(removed, k.ID)
+	}
+	if _, err := s.Reload(); err != nil {
+		return removed, err
+	}
+	return removed, nil
+}
+
+// Active 当前用于签名的密钥
+func (s *Store) Active() *Key {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.keys[s.active]
+}
+
+// Get 根据kid查找密钥，用于验签
+// 找不到时从磁盘重新加载一次，以便立即识别其他实例轮换后签发的令牌
+func (s *Store) Get(kid string) (*Key, bool) {
+	s.mu.RLock()
+	key, ok := s.keys[kid]
+	recent := time.Since(s.lastReload) < reloadOnMissInterval
+	s.mu.RUnlock()
+	if ok || recent {
+		return key, ok
+	}
+
+	if _, err := s.Reload(); err != nil {
+		log.Println("JWT signing keys reload failed:", err)
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	key, ok = s.keys[kid]
+	return key, ok
+}
+
+// Keys 全部密钥，按生成时间排序
+func (s *Store) Keys() []*Key {
+	s.mu.RLock()
+	keys := make([]*Key, 0, len(s.keys))
+	for _, k := range s.keys {
+		keys = append(keys, k)
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].ID < keys[j].ID
+		}
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+	return keys
+}
+
+// Check 用当前密钥签名再验签，确认密钥可用，供就绪检查使用
+func (s *Store) Check() error {
+	key := s.Active()
+	if key == nil {
+		return errors.New("no active signing key")
+	}
+	digest := sha256.Sum256([]byte("probe"))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key.Private, crypto.SHA256, digest[:])
+	if err != nil {
+		return fmt.Errorf("sign probe: %v", err)
+	}
+	if err := rsa.VerifyPKCS1v15(key.Public(), crypto.SHA256, digest[:], sig); err != nil {
+		return fmt.Errorf("verify probe: %v", err)
+	}
+	return nil
+}
+
+// Watch 每隔interval从磁盘重新加载密钥，直到ctx结束
+// 在其他实例或 keys rotate 命令轮换密钥后，本实例开始使用新的active密钥签名
+func (s *Store) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			changed, err := s.Reload()
+			if err != nil {
+				log.Println("JWT signing keys reload failed, keeping current keys:", err)
+			} else if changed {
+				log.Println("JWT signing keys reloaded, active key", s.Active().ID)
+			}
+		}
+	}
+}
+
+// Reload 从磁盘重新加载全部密钥，返回active密钥或密钥集合是否有变化
+// 加载失败时保留原有密钥
+func (s *Store) Reload() (bool, error) {
+	s.mu.Lock()
+	s.lastReload = time.Now()
+	s.mu.Unlock()
+
+	data, err := os.ReadFile(filepath.Join(s.dir, activeFile))
+	if err != nil {
+		return false, fmt.Errorf("read active key id: %w", err)
+	}
+	active := strings.TrimSpace(string(data))
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return false, err
+	}
+	keys := make(map[string]*Key)
+	for _, e := range entries {
+		name := e.Name()
+		// private.pem / public.pem 是旧版本的密钥文件，已在首次启动时导入
+		if e.IsDir() || !strings.HasSuffix(name, ".pem") || name == "private.pem" || name == "public.pem" {
+			continue
+		}
+		key, err := readKey(filepath.Join(s.dir, name))
+		if err != nil {
+			return false, fmt.Errorf("%s: %w", name, err)
+		}
+		keys[key.ID] = key
+	}
+	if _, ok := keys[active]; !ok {
+		return false, fmt.Errorf("active key %q not found in %s", active, s.dir)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	changed := active != s.active || len(keys) != len(s.keys)
+	for kid := range keys {
+		if _, ok := s.keys[kid]; !ok {
+			changed = true
+		}
+	}
+	s.keys, s.active = keys, active
+	return changed, nil
+}
+
+// generate 生成新的RSA密钥
+func generate() (*Key, error) {
+	private, err := rsa.GenerateKey(rand.Reader, keyBits)
+	if err != nil {
+		return nil, err
+	}
+	return &Key{ID: Thumbprint(&private.PublicKey), Private: private, CreatedAt: time.Now().UTC().Truncate(time.Second)}, nil
+}
+
+// importLegacy 读取旧版本的 keys/private.pem，文件不存在时返回nil
+func importLegacy(path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: failed to decode PEM block", path)
+	}
+	private, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	log.Println("Importing legacy JWT signing key", path)
+	return &Key{ID: Thumbprint(&private.PublicKey), Private: private, CreatedAt: info.ModTime().UTC().Truncate(time.Second)}, nil
+}
+
+// writeKey 把私钥写入 <dir>/<kid>.pem，权限600
+func writeKey(dir string, key *Key) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	block := &pem.Block{
+		Type:    "RSA PRIVATE KEY",
+		Headers: map[string]string{"Created": key.CreatedAt.Format(time.RFC3339)},
+		Bytes:   x509.MarshalPKCS1PrivateKey(key.Private),
+	}
+	return writeFileAtomic(filepath.Join(dir, key.ID+".pem"), pem.EncodeToMemory(block))
+}
+
+// readKey 读取一个私钥文件，kid由公钥计算，不依赖文件名
+func readKey(path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("failed to decode PEM block")
+	}
+	private, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	created, err := time.Parse(time.RFC3339, block.Headers["Created"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid Created header: %v", err)
+	}
+	return &Key{ID: Thumbprint(&private.PublicKey), Private: private, CreatedAt: created}, nil
+}
+
+// writeFileAtomic 先写临时文件再重命名，其他实例不会读到写了一半的文件
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Thumbprint 计算RSA公钥的RFC 7638指纹，取前16个字符作为kid
+func Thumbprint(pub *rsa.PublicKey) string {
+	// 成员按字典序排列，没有空白
+	canonical := `{"e":"` + b64(big.NewInt(int64(pub.E)).Bytes()) + `","kty":"RSA","n":"` + b64(pub.N.Bytes()) + `"}`
+	sum := sha256.Sum256([]byte(canonical))
+	return b64(sum[:])[:16]
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
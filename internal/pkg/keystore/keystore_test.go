@@ -0,0 +1,230 @@
+package keystore
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// sign 用密钥签发一个带kid的令牌
+func sign(t *testing.T, key *Key) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"sub": "1"})
+	token.Header["kid"] = key.ID
+	signed, err := token.SignedString(key.Private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+// verify 和JWT中间件一样按kid查找公钥验签
+func verify(s *Store, signed string) error {
+	_, err := jwt.Parse(signed, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := s.Get(kid)
+		if !ok {
+			return nil, jwt.ErrTokenUnverifiable
+		}
+		return key.Public(), nil
+	}, jwt.WithValidMethods([]string{"RS256"}))
+	return err
+}
+
+// writeKeyCreatedAt 在目录中写入一个生成时间为createdAt的新密钥
+func writeKeyCreatedAt(t *testing.T, dir string, createdAt time.Time) *Key {
+	t.Helper()
+	key, err := generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key.CreatedAt = createdAt.UTC().Truncate(time.Second)
+	if err := writeKey(dir, key); err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestRotateKeepsOldKeyForVerification(t *testing.T) {
+	s, err := LoadOrCreate(t.TempDir())
+	if err != nil {
+		t.Fatalf("LoadOrCreate: %v", err)
+	}
+	old := s.Active()
+	signed := sign(t, old)
+
+	key, err := s.Rotate()
+	if err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	if key.ID == old.ID || s.Active().ID != key.ID {
+		t.Fatalf("active key %s after rotating from %s", s.Active().ID, old.ID)
+	}
+	if err := verify(s, signed); err != nil {
+		t.Errorf("token signed with the old key: %v", err)
+	}
+	if err := verify(s, sign(t, key)); err != nil {
+		t.Errorf("token signed with the new key: %v", err)
+	}
+	if kids := len(s.JWKS().Keys); kids != 2 {
+		t.Errorf("JWKS has %d keys, want 2", kids)
+	}
+}
+
+func TestPruneKeepsRecentlyRetiredKeys(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	oldest := writeKeyCreatedAt(t, dir, now.Add(-3*time.Hour))
+	retired := writeKeyCreatedAt(t, dir, now.Add(-2*time.Hour))
+	active := writeKeyCreatedAt(t, dir, now.Add(-10*time.Minute))
+	if err := writeFileAtomic(filepath.Join(dir, activeFile), []byte(active.ID+"\n")); err != nil {
+		t.Fatal(err)
+	}
+	s, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	// oldest两小时前退役，retired十分钟前退役，用它签发的访问令牌可能还没过期
+	removed, err := s.Prune(time.Hour)
+	if err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	if !slices.Equal(removed, []string{oldest.ID}) {
+		t.Errorf("removed %v, want only %s", removed, oldest.ID)
+	}
+	if _, ok := s.Get(retired.ID); !ok {
+		t.Error("recently retired key was pruned")
+	}
+	if _, err := os.Stat(filepath.Join(dir, oldest.ID+".pem")); !os.IsNotExist(err) {
+		t.Errorf("pruned key file still exists: %v", err)
+	}
+
+	// 当前密钥无论多久都不删除
+	if removed, err := s.Prune(0); err != nil || !slices.Equal(removed, []string{retired.ID}) {
+		t.Errorf("Prune(0) removed %v, %v", removed, err)
+	}
+	if s.Active().ID != active.ID {
+		t.Error("active key was pruned")
+	}
+}
+
+func TestGetReloadsOnMissAtMostOncePerInterval(t *testing.T) {
+	dir := t.TempDir()
+	s, err := LoadOrCreate(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 另一个实例轮换了密钥
+	other, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotated, err := other.Rotate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 刚加载过，不会因为未知的kid再读磁盘
+	if _, ok := s.Get(rotated.ID); ok {
+		t.Fatal("unknown kid triggered a reload right after loading")
+	}
+	s.mu.Lock()
+	s.lastReload = time.Now().Add(-reloadOnMissInterval)
+	s.mu.Unlock()
+	if _, ok := s.Get(rotated.ID); !ok {
+		t.Fatal("key rotated by another instance not found after the reload interval")
+	}
+
+	// 伪造的kid每个间隔最多触发一次重新加载
+	s.mu.Lock()
+	s.lastReload = time.Now().Add(-reloadOnMissInterval)
+	s.mu.Unlock()
+	if _, ok := s.Get("forged"); ok {
+		t.Fatal("forged kid found")
+	}
+	next, err := other.Rotate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.Get(next.ID); ok {
+		t.Error("second miss within the interval reloaded the keys")
+	}
+}
+
+func TestWatchPicksUpRotation(t *testing.T) {
+	dir := t.TempDir()
+	s, err := LoadOrCreate(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Watch(ctx, 10*time.Millisecond)
+		close(done)
+	}()
+
+	other, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotated, err := other.Rotate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for s.Active().ID != rotated.ID {
+		if time.Now().After(deadline) {
+			t.Fatal("Watch did not pick up the rotated key")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Watch did not return after the context was cancelled")
+	}
+}
+
+func TestLoadOrCreateImportsLegacyKey(t *testing.T) {
+	dir := t.TempDir()
+	private, err := rsa.GenerateKey(rand.Reader, keyBits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(private)})
+	if err := os.WriteFile(filepath.Join(dir, "private.pem"), legacy, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := LoadOrCreate(dir)
+	if err != nil {
+		t.Fatalf("LoadOrCreate: %v", err)
+	}
+	// 旧版本签发的令牌用同一个私钥签名，导入后仍然可以验证
+	keys := s.Keys()
+	if len(keys) != 1 || keys[0].ID != Thumbprint(&private.PublicKey) || s.Active().ID != keys[0].ID {
+		t.Fatalf("keys after import: %v, active %s", keys, s.Active().ID)
+	}
+	if !s.Active().Private.Equal(private) {
+		t.Error("imported key differs from private.pem")
+	}
+
+	// 再次启动时不会重复导入
+	again, err := LoadOrCreate(dir)
+	if err != nil || len(again.Keys()) != 1 || again.Active().ID != keys[0].ID {
+		t.Errorf("second start: %v keys, %v", len(again.Keys()), err)
+	}
+}
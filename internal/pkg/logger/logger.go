@@ -13,6 +13,8 @@ const (
 	INFO    LogLevel = "INFO"
 	WARNING LogLevel = "WARNING"
 	ERROR   LogLevel = "ERROR"
+	// SECURITY 安全事件（登录失败、账号锁定等），同时写入单独的安全日志文件
+	SECURITY LogLevel = "SECURITY"
 )
 
 // LogEntry 定义日志条目结构
@@ -28,6 +30,10 @@ type LogEntry struct {
 var (
 	// 日志文件
 	logFile *os.File
+	// 安全日志文件，只记录安全事件，便于审计和接入告警
+	securityFile *os.File
+	// securityLog 安全日志的输出，InitLogger之前（如管理命令）输出到终端
+	securityLog = log.New(os.Stderr, "", 0)
 )
 
 // InitLogger 初始化日志系统
@@ -54,6 +60,15 @@ func InitLogger() error {
 		return err
 	}
 
+	// 安全日志单独写入 logs/security-日期.log
+	sfile, err := os.OpenFile("logs/security-"+time.Now().Format("2006-01-02")+".log", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	securityFile = sfile
+	securityLog.SetOutput(sfile)
+
 	// 将打开的文件赋值给全局变量logFile（类似Java中的类成员变量）
 	logFile = file
 	// 设置标准日志输出到文件，类似Java中的System.setOut()   原本log.println会输出到终端
@@ -92,12 +107,30 @@ func Log(level LogLevel, action, user, ip, message string) {
 	)
 }
 
+// Security 记录安全事件
+// 写入普通日志的同时写入安全日志文件
+func Security(action, user, ip, message string) {
+	Log(SECURITY, action, user, ip, message)
+	securityLog.Printf("%s | Action: %s | User: %s | IP: %s | Message: %s",
+		time.Now().Format("2006-01-02 15:04:05"), action, user, ip, message)
+}
+
 // Close 关闭日志文件
 // 类似Java中实现Closeable接口的close()方法
 func Close() {
 	// 检查logFile是否为nil（类似Java中的null检查）
 	if logFile != nil {
-		// 关闭文件，释放资源（类似Java中的file.close()）
+		// 先把缓冲区内容刷到磁盘，再关闭文件，释放资源（类似Java中的file.close()）
+		logFile.Sync()
 		logFile.Close()
+		logFile = nil
+		// 日志文件关闭后恢复输出到终端，避免后续日志写入已关闭的文件
+		log.SetOutput(os.Stderr)
+	}
+	if securityFile != nil {
+		securityFile.Sync()
+		securityFile.Close()
+		securityFile = nil
+		securityLog.SetOutput(os.Stderr)
 	}
 }
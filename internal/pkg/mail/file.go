@@ -0,0 +1,34 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileMailer 把每封邮件保存为目录中的一个.eml文件，可以直接用邮件客户端打开
+type FileMailer struct {
+	from string
+	dir  string
+}
+
+// NewFileMailer 创建FileMailer
+func NewFileMailer(from, dir string) *FileMailer {
+	return &FileMailer{from: from, dir: dir}
+}
+
+// Send 保存邮件，目录不存在时自动创建
+func (m *FileMailer) Send(ctx context.Context, msg Message) error {
+	if err := os.MkdirAll(m.dir, 0700); err != nil {
+		return err
+	}
+	now := time.Now()
+	data, err := encode(m.from, msg, now)
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%d.eml", now.Format("20060102-150405"), now.UnixNano()%1e9)
+	return os.WriteFile(filepath.Join(m.dir, name), data, 0600)
+}
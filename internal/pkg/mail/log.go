@@ -0,0 +1,18 @@
+package mail
+
+import (
+	"context"
+	"log"
+)
+
+// LogMailer 不发送邮件，只把内容写入日志，本地开发时使用
+// 注意：邮件正文中的找回密码链接等敏感信息也会写入日志，不要在生产环境使用
+type LogMailer struct {
+	from string
+}
+
+// Send 把邮件写入日志
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	log.Printf("mail from %s to %s: %s\n%s", m.from, msg.To, msg.Subject, msg.Body)
+	return nil
+}
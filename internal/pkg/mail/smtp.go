@@ -0,0 +1,58 @@
+package mail
+
+import (
+	"context"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"time"
+)
+
+// SMTPMailer 通过SMTP服务器发送邮件
+// 服务器支持STARTTLS时自动加密；用户名为空时不认证，适合本地的 MailHog / Mailpit 等测试服务器
+type SMTPMailer struct {
+	from     string
+	addr     string
+	username string
+	password string
+}
+
+// NewSMTPMailer 创建SMTPMailer，addr形如 "smtp.example.com:587"
+func NewSMTPMailer(from, addr, username, password string) *SMTPMailer {
+	return &SMTPMailer{from: from, addr: addr, username: username, password: password}
+}
+
+// Send 发送邮件
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	data, err := encode(m.from, msg, time.Now())
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if m.username != "" {
+		host, _, err := net.SplitHostPort(m.addr)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", m.username, m.password, host)
+	}
+
+	// 信封中的发件人只能是地址，from可以带显示名称，如 "校园智能社交平台 <no-reply@example.com>"
+	sender := m.from
+	if addr, err := mail.ParseAddress(m.from); err == nil {
+		sender = addr.Address
+	}
+
+	// smtp.SendMail 不支持context，放到goroutine中执行，ctx结束时不再等待
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(m.addr, auth, sender, []string{msg.To}, data)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
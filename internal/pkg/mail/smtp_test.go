@@ -0,0 +1,183 @@
+package mail
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+)
+
+// caught 本地SMTP测试服务器收到的一封邮件
+type caught struct {
+	from string
+	to   []string
+	data string
+}
+
+// startCatcher 在随机端口启动一个最简的SMTP服务器（类似MailHog），把收到的邮件发送到返回的channel
+// 不支持STARTTLS和认证，和本地开发时使用的测试服务器一样
+func startCatcher(t *testing.T) (string, <-chan caught) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	messages := make(chan caught, 10)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveSMTP(conn, messages)
+		}
+	}()
+	return ln.Addr().String(), messages
+}
+
+func serveSMTP(conn net.Conn, messages chan<- caught) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	r := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+
+	reply("220 catcher ESMTP")
+	var msg caught
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch {
+		case cmd == "EHLO" || cmd == "HELO":
+			reply("250-catcher")
+			reply("250 8BITMIME")
+		case strings.HasPrefix(strings.ToUpper(line), "MAIL FROM:"):
+			msg = caught{from: path(line)}
+			reply("250 OK")
+		case strings.HasPrefix(strings.ToUpper(line), "RCPT TO:"):
+			msg.to = append(msg.to, path(line))
+			reply("250 OK")
+		case cmd == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(l, "."))
+			}
+			msg.data = data.String()
+			messages <- msg
+			reply("250 OK: queued")
+		case cmd == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+// path 取出 MAIL FROM / RCPT TO 命令中尖括号里的地址，忽略后面的 BODY=8BITMIME 等参数
+func path(line string) string {
+	start, end := strings.Index(line, "<"), strings.Index(line, ">")
+	if start < 0 || end < start {
+		return ""
+	}
+	return line[start+1 : end]
+}
+
+func TestSMTPMailerSend(t *testing.T) {
+	addr, messages := startCatcher(t)
+	m := NewSMTPMailer("校园智能社交平台 <no-reply@scut.edu.cn>", addr, "", "")
+
+	body := "alice，你好：\n\n请打开下面的链接设置新密码：\nhttp://localhost:3000/reset-password?token=abc\n"
+	if err := m.Send(context.Background(), Message{To: "alice@mail.scut.edu.cn", Subject: "重置密码", Body: body}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	var got caught
+	select {
+	case got = <-messages:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+	}
+	if got.from != "no-reply@scut.edu.cn" {
+		t.Errorf("MAIL FROM = %q", got.from)
+	}
+	if len(got.to) != 1 || got.to[0] != "alice@mail.scut.edu.cn" {
+		t.Errorf("RCPT TO = %v", got.to)
+	}
+
+	parsed, err := mail.ReadMessage(strings.NewReader(got.data))
+	if err != nil {
+		t.Fatalf("parse message: %v", err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil || subject != "重置密码" {
+		t.Errorf("Subject = %q (%v)", subject, err)
+	}
+	if parsed.Header.Get("Message-ID") == "" || parsed.Header.Get("Date") == "" {
+		t.Error("missing Message-ID or Date header")
+	}
+	decoded, err := io.ReadAll(quotedprintable.NewReader(parsed.Body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.ReplaceAll(string(decoded), "\r\n", "\n"); got != body {
+		t.Errorf("body = %q, want %q", got, body)
+	}
+}
+
+func TestSMTPMailerRejectsHeaderInjection(t *testing.T) {
+	addr, messages := startCatcher(t)
+	m := NewSMTPMailer("no-reply@localhost", addr, "", "")
+
+	err := m.Send(context.Background(), Message{To: "alice@example.com", Subject: "hi\r\nBcc: victim@example.com", Body: "x"})
+	if err == nil {
+		t.Fatal("Send accepted a subject with CRLF")
+	}
+	select {
+	case <-messages:
+		t.Error("message was delivered")
+	default:
+	}
+}
+
+func TestSMTPMailerContextCanceled(t *testing.T) {
+	// 只接受连接、不回复的服务器：Send应该在ctx结束时返回
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err == nil {
+			defer conn.Close()
+			time.Sleep(2 * time.Second)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	m := NewSMTPMailer("no-reply@localhost", ln.Addr().String(), "", "")
+	if err := m.Send(ctx, Message{To: "alice@example.com", Subject: "hi", Body: "x"}); err != context.DeadlineExceeded {
+		t.Errorf("Send = %v, want context.DeadlineExceeded", err)
+	}
+}
@@ -0,0 +1,133 @@
+package oidc_test
+
+import (
+	"context"
+	"my-social-platform/internal/pkg/oidc"
+	"my-social-platform/internal/pkg/oidc/oidctest"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// newProvider 启动进程内的身份提供方和指向它的客户端
+func newProvider(t *testing.T) (*oidctest.Provider, *oidc.Client) {
+	t.Helper()
+	p, srv := oidctest.NewServer()
+	t.Cleanup(srv.Close)
+	p.ClientID, p.ClientSecret = "social", "secret"
+	p.Identity = &oidctest.Identity{Subject: "student-001", Email: "alice@mail.scut.edu.cn", EmailVerified: true}
+	return p, oidc.New(oidc.Config{
+		Issuer:       srv.URL,
+		ClientID:     "social",
+		ClientSecret: "secret",
+		RedirectURL:  "http://localhost:8080/auth/oidc/callback",
+		Scopes:       []string{"openid", "email", "profile"},
+	})
+}
+
+// authorize 打开授权地址（身份提供方以Identity自动登录），返回回调地址中的参数
+func authorize(t *testing.T, authURL string) url.Values {
+	t.Helper()
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorize: status %d", resp.StatusCode)
+	}
+	loc, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc.Query()
+}
+
+// login 完成一次授权，返回授权码
+func login(t *testing.T, c *oidc.Client, nonce, verifier string) string {
+	t.Helper()
+	authURL, err := c.AuthCodeURL(context.Background(), "state-1", nonce, oidc.S256Challenge(verifier))
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+	params := authorize(t, authURL)
+	if params.Get("state") != "state-1" || params.Get("code") == "" {
+		t.Fatalf("callback parameters: %v", params)
+	}
+	return params.Get("code")
+}
+
+func TestExchange(t *testing.T) {
+	_, c := newProvider(t)
+
+	code := login(t, c, "nonce-1", "verifier-1")
+	claims, err := c.Exchange(context.Background(), code, "verifier-1", "nonce-1")
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if claims.Subject != "student-001" || claims.Email != "alice@mail.scut.edu.cn" || !claims.EmailVerified {
+		t.Errorf("claims: %+v", claims)
+	}
+
+	// 授权码只能使用一次
+	if _, err := c.Exchange(context.Background(), code, "verifier-1", "nonce-1"); err == nil {
+		t.Error("code was accepted twice")
+	}
+}
+
+func TestExchangeRejectsWrongVerifierAndNonce(t *testing.T) {
+	_, c := newProvider(t)
+
+	code := login(t, c, "nonce-1", "verifier-1")
+	if _, err := c.Exchange(context.Background(), code, "another-verifier", "nonce-1"); err == nil {
+		t.Error("wrong PKCE verifier was accepted")
+	}
+
+	// 截获的授权码用在另一次登录中：nonce不一致
+	code = login(t, c, "nonce-1", "verifier-1")
+	_, err := c.Exchange(context.Background(), code, "verifier-1", "nonce-2")
+	if err == nil || !strings.Contains(err.Error(), "nonce mismatch") {
+		t.Errorf("nonce mismatch: got %v", err)
+	}
+}
+
+func TestVerifyRejectsTamperedTokens(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(claims jwt.MapClaims, header map[string]interface{})
+		want   string
+	}{
+		{"wrong audience", func(claims jwt.MapClaims, _ map[string]interface{}) { claims["aud"] = "another-client" }, "aud"},
+		{"wrong issuer", func(claims jwt.MapClaims, _ map[string]interface{}) { claims["iss"] = "https://evil.example.com" }, "iss"},
+		{"expired", func(claims jwt.MapClaims, _ map[string]interface{}) { claims["exp"] = 1 }, "expired"},
+		{"missing sub", func(claims jwt.MapClaims, _ map[string]interface{}) { delete(claims, "sub") }, "missing sub"},
+		{"unknown kid", func(_ jwt.MapClaims, header map[string]interface{}) { header["kid"] = "rotated" }, "unknown signing key"},
+		{"alg none", func(_ jwt.MapClaims, header map[string]interface{}) { header["alg"] = "none" }, "signing method"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, c := newProvider(t)
+			p.ModifyIDToken = tt.modify
+
+			code := login(t, c, "nonce-1", "verifier-1")
+			_, err := c.Exchange(context.Background(), code, "verifier-1", "nonce-1")
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got %v, want error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiscoverRejectsIssuerMismatch(t *testing.T) {
+	p, c := newProvider(t)
+	p.Issuer = "https://idp.example.com"
+
+	_, err := c.AuthCodeURL(context.Background(), "state", "nonce", "challenge")
+	if err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Errorf("got %v, want issuer mismatch", err)
+	}
+}
@@ -0,0 +1,326 @@
+// Package oidctest 一个最简的OpenID Connect身份提供方，用于本地开发和测试
+//
+// 支持发现文档、授权码模式（必须使用PKCE S256）、令牌端点和JWKS；
+// 不校验用户密码：授权页直接填写要登录的身份，或者通过 Identity 字段自动登录（测试中使用）
+//
+// 测试中可以在进程内启动：
+//
+//	p, srv := oidctest.NewServer()
+//	defer srv.Close()
+//	p.Identity = &oidctest.Identity{Subject: "s1", Email: "alice@scut.edu.cn", EmailVerified: true}
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"html/template"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	keyID    = "oidctest"
+	codeTTL  = time.Minute
+	tokenTTL = 5 * time.Minute
+)
+
+// Identity 登录到身份提供方的用户
+type Identity struct {
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	PreferredUsername string
+	Name              string
+}
+
+// Provider 身份提供方，实现http.Handler
+type Provider struct {
+	// Issuer 签发方地址，必须是Provider对外的根地址
+	Issuer string
+	// ClientID、ClientSecret 不为空时校验客户端；为空时接受任意客户端
+	ClientID     string
+	ClientSecret string
+	// Identity 不为空时授权端点直接以该身份登录，不显示授权页
+	Identity *Identity
+	// ModifyIDToken 不为空时在签名前调用，测试中用来构造iss、aud或kid不正确的ID令牌
+	ModifyIDToken func(claims jwt.MapClaims, header map[string]interface{})
+
+	key *rsa.PrivateKey
+	mux *http.ServeMux
+
+	mu    sync.Mutex
+	codes map[string]*authCode
+}
+
+// authCode 已签发、尚未使用的授权码
+type authCode struct {
+	clientID    string
+	redirectURI string
+	nonce       string
+	challenge   string
+	identity    Identity
+	expiresAt   time.Time
+}
+
+// New 创建Provider，签名密钥在内存中生成，重启后失效
+func New(issuer string) (*Provider, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	p := &Provider{
+		Issuer: strings.TrimRight(issuer, "/"),
+		key:    key,
+		mux:    http.NewServeMux(),
+		codes:  make(map[string]*authCode),
+	}
+	p.mux.HandleFunc("GET /.well-known/openid-configuration", p.discovery)
+	p.mux.HandleFunc("GET /authorize", p.authorize)
+	p.mux.HandleFunc("POST /authorize", p.authorize)
+	p.mux.HandleFunc("POST /token", p.token)
+	p.mux.HandleFunc("GET /jwks", p.jwks)
+	return p, nil
+}
+
+// NewServer 在随机端口启动Provider，调用方负责关闭返回的服务器
+func NewServer() (*Provider, *httptest.Server) {
+	p, err := New("")
+	if err != nil {
+		panic(err)
+	}
+	srv := httptest.NewServer(p)
+	p.Issuer = srv.URL
+	return p, srv
+}
+
+// ServeHTTP 实现http.Handler
+func (p *Provider) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.mux.ServeHTTP(w, r)
+}
+
+func (p *Provider) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                p.Issuer,
+		"authorization_endpoint":                p.Issuer + "/authorize",
+		"token_endpoint":                        p.Issuer + "/token",
+		"jwks_uri":                              p.Issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+		"scopes_supported":                      []string{"openid", "profile", "email"},
+	})
+}
+
+var loginPage = template.Must(template.New("login").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>OIDC test provider</title></head>
+<body style="font-family: sans-serif; max-width: 420px; margin: 40px auto">
+<h2>OIDC test provider</h2>
+<p>Sign in to <b>{{.ClientID}}</b> as:</p>
+<form method="post">
+<p><label>sub<br><input name="login_sub" value="student-001" required></label></p>
+<p><label>email<br><input name="login_email" value="student001@mail.scut.edu.cn"></label></p>
+<p><label><input type="checkbox" name="login_email_verified" value="true" checked> email verified</label></p>
+<p><label>preferred_username<br><input name="login_username" value="student001"></label></p>
+<p><label>name<br><input name="login_name" value="Test Student"></label></p>
+<p><button type="submit">Sign in</button></p>
+</form></body></html>`))
+
+// authorize 授权端点：GET显示授权页（或以Identity自动登录），POST提交授权页后签发授权码
+func (p *Provider) authorize(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	q := r.Form
+	redirectURI := q.Get("redirect_uri")
+	u, err := url.Parse(redirectURI)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+	if p.ClientID != "" && q.Get("client_id") != p.ClientID {
+		http.Error(w, "unknown client_id", http.StatusBadRequest)
+		return
+	}
+
+	fail := func(code, desc string) {
+		v := u.Query()
+		v.Set("error", code)
+		v.Set("error_description", desc)
+		v.Set("state", q.Get("state"))
+		u.RawQuery = v.Encode()
+		http.Redirect(w, r, u.String(), http.StatusFound)
+	}
+	if q.Get("response_type") != "code" {
+		fail("unsupported_response_type", "only the code flow is supported")
+		return
+	}
+	if !strings.Contains(" "+q.Get("scope")+" ", " openid ") {
+		fail("invalid_scope", "scope must include openid")
+		return
+	}
+	if q.Get("code_challenge") == "" || q.Get("code_challenge_method") != "S256" {
+		fail("invalid_request", "PKCE with S256 is required")
+		return
+	}
+
+	var identity Identity
+	switch {
+	case p.Identity != nil:
+		identity = *p.Identity
+	case r.Method == http.MethodGet:
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		// 表单提交到当前地址（包括查询参数），POST时授权参数仍然从查询参数中读取
+		loginPage.Execute(w, map[string]interface{}{"ClientID": q.Get("client_id")})
+		return
+	default:
+		identity = Identity{
+			Subject:           q.Get("login_sub"),
+			Email:             q.Get("login_email"),
+			EmailVerified:     q.Get("login_email_verified") == "true",
+			PreferredUsername: q.Get("login_username"),
+			Name:              q.Get("login_name"),
+		}
+		if identity.Subject == "" {
+			fail("access_denied", "sub is required")
+			return
+		}
+	}
+
+	code := randomString(24)
+	p.mu.Lock()
+	p.codes[code] = &authCode{
+		clientID:    q.Get("client_id"),
+		redirectURI: redirectURI,
+		nonce:       q.Get("nonce"),
+		challenge:   q.Get("code_challenge"),
+		identity:    identity,
+		expiresAt:   time.Now().Add(codeTTL),
+	}
+	p.mu.Unlock()
+
+	v := u.Query()
+	v.Set("code", code)
+	v.Set("state", q.Get("state"))
+	u.RawQuery = v.Encode()
+	http.Redirect(w, r, u.String(), http.StatusFound)
+}
+
+// token 令牌端点：校验授权码、redirect_uri、客户端和PKCE后签发ID令牌，每个授权码只能使用一次
+func (p *Provider) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+		return
+	}
+	clientID, secret, basic := r.BasicAuth()
+	if basic {
+		clientID, _ = url.QueryUnescape(clientID)
+		secret, _ = url.QueryUnescape(secret)
+	} else {
+		clientID, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if p.ClientID != "" && (clientID != p.ClientID || subtle.ConstantTimeCompare([]byte(secret), []byte(p.ClientSecret)) != 1) {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	p.mu.Lock()
+	code := p.codes[r.PostForm.Get("code")]
+	delete(p.codes, r.PostForm.Get("code"))
+	p.mu.Unlock()
+
+	invalid := func(desc string) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": desc})
+	}
+	switch {
+	case code == nil || time.Now().After(code.expiresAt):
+		invalid("unknown or expired code")
+		return
+	case code.clientID != clientID:
+		invalid("code was issued to another client")
+		return
+	case code.redirectURI != r.PostForm.Get("redirect_uri"):
+		invalid("redirect_uri mismatch")
+		return
+	}
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(sum[:]) != code.challenge {
+		invalid("PKCE verification failed")
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":   p.Issuer,
+		"sub":   code.identity.Subject,
+		"aud":   clientID,
+		"iat":   now.Unix(),
+		"exp":   now.Add(tokenTTL).Unix(),
+		"nonce": code.nonce,
+	}
+	if code.identity.Email != "" {
+		claims["email"] = code.identity.Email
+		claims["email_verified"] = code.identity.EmailVerified
+	}
+	if code.identity.PreferredUsername != "" {
+		claims["preferred_username"] = code.identity.PreferredUsername
+	}
+	if code.identity.Name != "" {
+		claims["name"] = code.identity.Name
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = keyID
+	if p.ModifyIDToken != nil {
+		p.ModifyIDToken(claims, token.Header)
+	}
+	idToken, err := token.SignedString(p.key)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": randomString(24),
+		"token_type":   "Bearer",
+		"expires_in":   int(tokenTTL.Seconds()),
+		"id_token":     idToken,
+	})
+}
+
+func (p *Provider) jwks(w http.ResponseWriter, r *http.Request) {
+	pub := p.key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": keyID,
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func randomString(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,91 @@
+package totp_test
+
+import (
+	"encoding/base32"
+	"my-social-platform/internal/pkg/totp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret RFC 6238 附录B中SHA-1的测试密钥 "12345678901234567890"
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestCodeRFC6238Vectors(t *testing.T) {
+	// 附录B给出的是8位验证码，6位验证码是同一个值的后6位
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+	for _, tt := range tests {
+		got, err := totp.Code(rfcSecret, totp.Step(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("Code at %d: %v", tt.unix, err)
+		}
+		if want := tt.want[len(tt.want)-totp.Digits:]; got != want {
+			t.Errorf("Code at %d = %s, want %s", tt.unix, got, want)
+		}
+	}
+
+	// 认证器应用显示的密钥可能是小写
+	if got, err := totp.Code(strings.ToLower(rfcSecret), totp.Step(time.Unix(59, 0))); err != nil || got != "287082" {
+		t.Errorf("lowercase secret: %s, %v", got, err)
+	}
+	if _, err := totp.Code("not base32!", 1); err == nil {
+		t.Error("invalid secret was accepted")
+	}
+}
+
+func TestValidateWindow(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	step := totp.Step(now)
+
+	for offset := int64(-3); offset <= 3; offset++ {
+		code, err := totp.Code(rfcSecret, step+offset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		matched, ok := totp.Validate(rfcSecret, code, now)
+		// 前后各一个周期内的验证码有效，返回验证码所在的周期
+		if wantOK := offset >= -1 && offset <= 1; ok != wantOK {
+			t.Errorf("offset %d: valid %v, want %v", offset, ok, wantOK)
+		}
+		if ok && matched != step+offset {
+			t.Errorf("offset %d: matched step %d, want %d", offset, matched, step+offset)
+		}
+	}
+
+	for _, code := range []string{"", "12345", "1234567", "abcdef"} {
+		if _, ok := totp.Validate(rfcSecret, code, now); ok {
+			t.Errorf("code %q was accepted", code)
+		}
+	}
+}
+
+func TestValidateReturnsStepForReplayCheck(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	code, err := totp.Code(rfcSecret, totp.Step(now))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 调用方记录已使用的最大周期，同一个验证码在窗口内再次提交时返回相同的周期，据此拒绝
+	first, ok := totp.Validate(rfcSecret, code, now)
+	if !ok {
+		t.Fatal("current code rejected")
+	}
+	second, ok := totp.Validate(rfcSecret, code, now.Add(totp.Period))
+	if !ok || second != first {
+		t.Errorf("replayed code: step %d (valid %v), want %d so the caller can reject it", second, ok, first)
+	}
+	if _, ok := totp.Validate(rfcSecret, code, now.Add(2*totp.Period)); ok {
+		t.Error("code accepted two periods later")
+	}
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"my-social-platform/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// gormCommentRepository CommentRepository的GORM实现
+type gormCommentRepository struct {
+	db *gorm.DB
+}
+
+// NewCommentRepository 创建基于GORM的评论仓库
+func NewCommentRepository(db *gorm.DB) CommentRepository {
+	return &gormCommentRepository{db: db}
+}
+
+// Create 新建评论
+func (r *gormCommentRepository) Create(comment *model.Comment) error {
+	return r.db.Create(comment).Error
+}
+
+// GetByID 根据评论ID获取未删除的评论
+func (r *gormCommentRepository) GetByID(id uint) (*model.Comment, error) {
+	var comment model.Comment
+	if err := r.db.Where("deleted_at IS NULL").First(&comment, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &comment, nil
+}
+
+// ListByPostID 按时间顺序获取帖子下未删除的评论
+func (r *gormCommentRepository) ListByPostID(postID uint) ([]*model.Comment, error) {
+	var comments []*model.Comment
+	err := r.db.Where("post_id = ? AND deleted_at IS NULL", postID).Order("created_at ASC").Find(&comments).Error
+	return comments, err
+}
+
+// Delete 软删除评论
+// model.Comment的DeletedAt是普通指针字段而不是gorm.DeletedAt，所以这里手动写入删除时间
+func (r *gormCommentRepository) Delete(id uint) error {
+	return r.db.Model(&model.Comment{}).Where("id = ?", id).Update("deleted_at", time.Now()).Error
+}
@@ -1,51 +1,112 @@
 package repository
 
 import (
+	"context"
+	"fmt"
 	"log"
-	"my-social-platform/internal/model"
+	"my-social-platform/internal/config"
+	"my-social-platform/internal/repository/migrations"
+	"os"
+	"path/filepath"
 
+	"github.com/glebarez/sqlite"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
 
-var DB *gorm.DB
-
-// InitDB - 初始化MySQL数据库连接
-func InitDB() {
-	// 连接字符串
-	dsn := "root:123456@tcp(127.0.0.1:3306)/social_platform?charset=utf8mb4&parseTime=True&loc=Local"
+// Open 根据配置的驱动打开数据库连接
+// 支持MySQL、SQLite文件数据库和SQLite内存数据库
+func Open(cfg config.DatabaseConfig) (*gorm.DB, error) {
+	var dialector gorm.Dialector
+	switch cfg.Driver {
+	case config.DriverMySQL:
+		dialector = mysql.Open(cfg.DSN)
+	case config.DriverSQLite:
+		// 确保数据库文件所在目录存在；DSN可能是带参数的 "file:" URI，按去掉前缀和参数后的路径计算目录
+		if path := cfg.SQLitePath(); path != "" {
+			if dir := filepath.Dir(path); dir != "." {
+				if err := os.MkdirAll(dir, 0755); err != nil {
+					return nil, err
+				}
+			}
+		}
+		dialector = sqlite.Open(cfg.DSN)
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q", cfg.Driver)
+	}
 
-	// 尝试连接数据库
-	var err error
-	DB, err = gorm.Open(mysql.Open(dsn), &gorm.Config{
+	db, err := gorm.Open(dialector, &gorm.Config{
 		DisableForeignKeyConstraintWhenMigrating: true, // 迁移时禁用外键约束
+		TranslateError:                           true, // 把各数据库的唯一约束冲突统一转换为gorm.ErrDuplicatedKey
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Driver == config.DriverSQLite {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return nil, err
+		}
+		// SQLite同一时间只允许一个写入者；内存数据库每个连接都是独立的库，
+		// 所以只保留一个连接，保证所有请求看到同一份数据
+		sqlDB.SetMaxOpenConns(1)
+	}
+	return db, nil
+}
+
+// InitDB - 初始化数据库连接，返回的连接由调用方注入到各个仓库中
+// 驱动和连接字符串来自配置文件的 database 段或环境变量 APP_DATABASE_DRIVER / APP_DATABASE_DSN
+func InitDB(cfg config.DatabaseConfig) *gorm.DB {
+	// 尝试连接数据库
+	db, err := Open(cfg)
 	if err != nil {
 		log.Fatal("Failed to connect to database:", err)
 	}
 	log.Println("Database connection established.")
 
-	// 检查数据库中是否已存在所需的表
-	if !DB.Migrator().HasTable(&model.User{}) ||
-		!DB.Migrator().HasTable(&model.Post{}) ||
-		!DB.Migrator().HasTable(&model.Comment{}) {
-		log.Println("Tables do not exist, creating schema...")
-		// 自动迁移表结构 - 只更新表结构，不删除数据
-		err = DB.AutoMigrate(&model.User{}, &model.Post{}, &model.Comment{})
+	// 按配置自动执行未执行的迁移，迁移定义见 migrations 包
+	if cfg.AutoMigrate {
+		ran, err := migrations.Up(db)
 		if err != nil {
 			log.Fatal("Failed to migrate database:", err)
 		}
-		log.Println("Database migration completed successfully.")
+		for _, m := range ran {
+			log.Printf("Applied migration %d_%s", m.Version, m.Name)
+		}
+	}
+
+	version, err := migrations.CurrentVersion(db)
+	if err != nil {
+		log.Fatal("Failed to read schema version:", err)
+	}
+	if latest := migrations.LatestVersion(); version < latest {
+		log.Printf("Database schema version %d is behind latest %d, run `migrate up`", version, latest)
 	} else {
-		log.Println("Tables already exist, skipping migration.")
+		log.Printf("Database schema version %d.", version)
 	}
+	return db
+}
+
+// Ping - 检查数据库连接池是否可用
+func Ping(ctx context.Context, db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
 }
 
 // CloseDB - 关闭数据库连接
-func CloseDB() {
-	sqlDB, err := DB.DB()
+func CloseDB(db *gorm.DB) {
+	sqlDB, err := db.DB()
 	if err != nil {
-		log.Fatal("Failed to get SQL DB instance:", err)
+		log.Println("Failed to get SQL DB instance:", err)
+		return
+	}
+	if err := sqlDB.Close(); err != nil {
+		log.Println("Failed to close database:", err)
+		return
 	}
-	sqlDB.Close()
+	log.Println("Database connection closed.")
 }
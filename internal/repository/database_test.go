@@ -0,0 +1,50 @@
+package repository_test
+
+import (
+	"my-social-platform/internal/config"
+	"my-social-platform/internal/repository"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenCreatesDirectoryForFileURI(t *testing.T) {
+	dir := t.TempDir()
+	db, err := repository.Open(config.DatabaseConfig{
+		Driver: config.DriverSQLite,
+		DSN:    "file:" + filepath.Join(dir, "data", "social.db") + "?_pragma=busy_timeout(5000)",
+	})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if err := db.Exec("CREATE TABLE t (id INTEGER)").Error; err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.Close()
+
+	if _, err := os.Stat(filepath.Join(dir, "data", "social.db")); err != nil {
+		t.Errorf("database file was not created at the path in the URI: %v", err)
+	}
+	// 不应按原始DSN创建 "file:..." 开头的目录
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 || entries[0].Name() != "data" {
+		t.Errorf("unexpected entries in %s: %v", dir, entries)
+	}
+}
+
+func TestOpenMemoryDSNCreatesNoDirectory(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	for _, dsn := range []string{":memory:", "file::memory:?cache=shared", "file:testdb?mode=memory&cache=shared"} {
+		db, err := repository.Open(config.DatabaseConfig{Driver: config.DriverSQLite, DSN: dsn})
+		if err != nil {
+			t.Fatalf("Open(%q): %v", dsn, err)
+		}
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}
+	if entries, _ := os.ReadDir("."); len(entries) != 0 {
+		t.Errorf("memory DSNs created files: %v", entries)
+	}
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"my-social-platform/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// gormEmailVerificationRepository EmailVerificationRepository的GORM实现
+type gormEmailVerificationRepository struct {
+	db *gorm.DB
+}
+
+// NewEmailVerificationRepository 创建基于GORM的邮箱验证码仓库
+func NewEmailVerificationRepository(db *gorm.DB) EmailVerificationRepository {
+	return &gormEmailVerificationRepository{db: db}
+}
+
+// Create 保存新的验证码
+func (r *gormEmailVerificationRepository) Create(v *model.EmailVerification) error {
+	return r.db.Create(v).Error
+}
+
+// GetLatest 查找用户最近发送的验证码（包括已使用的）
+func (r *gormEmailVerificationRepository) GetLatest(userID uint) (*model.EmailVerification, error) {
+	var v model.EmailVerification
+	if err := r.db.Where("user_id = ?", userID).Order("id DESC").First(&v).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &v, nil
+}
+
+// IncrementAttempts 尝试次数小于max时加一，返回false表示次数已用完
+// 条件更新，并发请求不会超过上限
+func (r *gormEmailVerificationRepository) IncrementAttempts(id uint, max int) (bool, error) {
+	result := r.db.Model(&model.EmailVerification{}).
+		Where("id = ? AND attempts < ?", id, max).
+		Update("attempts", gorm.Expr("attempts + 1"))
+	return result.RowsAffected == 1, result.Error
+}
+
+// MarkUsed 将未使用的验证码标记为已使用，返回false表示验证码已被使用过
+func (r *gormEmailVerificationRepository) MarkUsed(id uint, at time.Time) (bool, error) {
+	result := r.db.Model(&model.EmailVerification{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", at)
+	return result.RowsAffected == 1, result.Error
+}
+
+// InvalidateUser 作废用户所有未使用的验证码
+func (r *gormEmailVerificationRepository) InvalidateUser(userID uint, at time.Time) error {
+	return r.db.Model(&model.EmailVerification{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", at).Error
+}
+
+// CountSince 统计用户在since之后发送的验证码数量，用于限制每天的发送次数
+func (r *gormEmailVerificationRepository) CountSince(userID uint, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.EmailVerification{}).
+		Where("user_id = ? AND created_at >= ?", userID, since).
+		Count(&count).Error
+	return count, err
+}
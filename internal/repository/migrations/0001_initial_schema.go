@@ -0,0 +1,70 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 0001 初始表结构：users、post、comment
+// 之前的版本通过 AutoMigrate 建表，这里同样使用 AutoMigrate，
+// 已存在的表会被保留，因此可以直接在老数据库上执行
+
+type user0001 struct {
+	ID          uint `gorm:"primaryKey"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DeletedAt   *time.Time `gorm:"index"`
+	Username    string     `gorm:"unique;not null"`
+	Password    string
+	Nickname    string
+	Avatar      string
+	Bio         string
+	FollowCount int `gorm:"default:0"`
+	FansCount   int `gorm:"default:0"`
+	LikeCount   int `gorm:"default:0"`
+}
+
+func (user0001) TableName() string { return "users" }
+
+type post0001 struct {
+	ID           uint `gorm:"primaryKey"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	DeletedAt    *time.Time `gorm:"index"`
+	UserID       uint       `gorm:"index"`
+	Content      string
+	Images       string
+	Tag          string
+	LikeCount    int `gorm:"default:0"`
+	FavCount     int `gorm:"default:0"`
+	CommentCount int `gorm:"default:0"`
+}
+
+func (post0001) TableName() string { return "post" }
+
+type comment0001 struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt *time.Time `gorm:"index"`
+	PostID    uint       `gorm:"index"`
+	UserID    uint       `gorm:"index"`
+	Content   string
+	LikeCount int `gorm:"default:0"`
+}
+
+func (comment0001) TableName() string { return "comment" }
+
+func init() {
+	register(Migration{
+		Version: 1,
+		Name:    "initial_schema",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&user0001{}, &post0001{}, &comment0001{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&comment0001{}, &post0001{}, &user0001{})
+		},
+	})
+}
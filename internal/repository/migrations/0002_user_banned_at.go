@@ -0,0 +1,34 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 0002 users表增加banned_at列，记录账号被封禁的时间
+
+type user0002 struct {
+	BannedAt *time.Time
+}
+
+func (user0002) TableName() string { return "users" }
+
+func init() {
+	register(Migration{
+		Version: 2,
+		Name:    "user_banned_at",
+		Up: func(tx *gorm.DB) error {
+			if tx.Migrator().HasColumn(&user0002{}, "BannedAt") {
+				return nil
+			}
+			return tx.Migrator().AddColumn(&user0002{}, "BannedAt")
+		},
+		Down: func(tx *gorm.DB) error {
+			if !tx.Migrator().HasColumn(&user0002{}, "BannedAt") {
+				return nil
+			}
+			return tx.Migrator().DropColumn(&user0002{}, "BannedAt")
+		},
+	})
+}
@@ -0,0 +1,40 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 0003 新增关注关系表follow和帖子点赞表post_like
+
+type follow0003 struct {
+	ID         uint `gorm:"primaryKey"`
+	CreatedAt  time.Time
+	FollowerID uint `gorm:"not null;uniqueIndex:idx_follow_pair"`
+	FolloweeID uint `gorm:"not null;uniqueIndex:idx_follow_pair;index"`
+}
+
+func (follow0003) TableName() string { return "follow" }
+
+type postLike0003 struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	PostID    uint `gorm:"not null;uniqueIndex:idx_post_like_pair"`
+	UserID    uint `gorm:"not null;uniqueIndex:idx_post_like_pair;index"`
+}
+
+func (postLike0003) TableName() string { return "post_like" }
+
+func init() {
+	register(Migration{
+		Version: 3,
+		Name:    "follow_and_post_like",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&follow0003{}, &postLike0003{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&postLike0003{}, &follow0003{})
+		},
+	})
+}
@@ -0,0 +1,35 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 0004 新增刷新令牌表refresh_token
+
+type refreshToken0004 struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UserID    uint   `gorm:"not null;index"`
+	FamilyID  string `gorm:"size:64;not null;index"`
+	TokenHash string `gorm:"size:64;not null;uniqueIndex"`
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	RevokedAt *time.Time
+}
+
+func (refreshToken0004) TableName() string { return "refresh_token" }
+
+func init() {
+	register(Migration{
+		Version: 4,
+		Name:    "refresh_token",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&refreshToken0004{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&refreshToken0004{})
+		},
+	})
+}
@@ -0,0 +1,49 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 0005 新增已吊销访问令牌表revoked_token，users表增加token_version列
+
+type revokedToken0005 struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	JTI       string    `gorm:"column:jti;size:64;not null;uniqueIndex"`
+	UserID    uint      `gorm:"not null;index"`
+	ExpiresAt time.Time `gorm:"index"`
+}
+
+func (revokedToken0005) TableName() string { return "revoked_token" }
+
+type user0005 struct {
+	TokenVersion int `gorm:"not null;default:0"`
+}
+
+func (user0005) TableName() string { return "users" }
+
+func init() {
+	register(Migration{
+		Version: 5,
+		Name:    "token_revocation",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(&revokedToken0005{}); err != nil {
+				return err
+			}
+			if tx.Migrator().HasColumn(&user0005{}, "TokenVersion") {
+				return nil
+			}
+			return tx.Migrator().AddColumn(&user0005{}, "TokenVersion")
+		},
+		Down: func(tx *gorm.DB) error {
+			if tx.Migrator().HasColumn(&user0005{}, "TokenVersion") {
+				if err := tx.Migrator().DropColumn(&user0005{}, "TokenVersion"); err != nil {
+					return err
+				}
+			}
+			return tx.Migrator().DropTable(&revokedToken0005{})
+		},
+	})
+}
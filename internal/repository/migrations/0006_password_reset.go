@@ -0,0 +1,62 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 0006 users表增加email列（唯一，可为空），新增找回密码令牌表password_reset_token
+
+type user0006 struct {
+	Email *string `gorm:"size:255;uniqueIndex:idx_users_email"`
+}
+
+func (user0006) TableName() string { return "users" }
+
+type passwordResetToken0006 struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UserID    uint   `gorm:"not null;index"`
+	TokenHash string `gorm:"size:64;not null;uniqueIndex"`
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
+
+func (passwordResetToken0006) TableName() string { return "password_reset_token" }
+
+func init() {
+	register(Migration{
+		Version: 6,
+		Name:    "password_reset",
+		Up: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			if !m.HasColumn(&user0006{}, "Email") {
+				if err := m.AddColumn(&user0006{}, "Email"); err != nil {
+					return err
+				}
+			}
+			if !m.HasIndex(&user0006{}, "idx_users_email") {
+				if err := m.CreateIndex(&user0006{}, "idx_users_email"); err != nil {
+					return err
+				}
+			}
+			return tx.AutoMigrate(&passwordResetToken0006{})
+		},
+		Down: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			if err := m.DropTable(&passwordResetToken0006{}); err != nil {
+				return err
+			}
+			if m.HasIndex(&user0006{}, "idx_users_email") {
+				if err := m.DropIndex(&user0006{}, "idx_users_email"); err != nil {
+					return err
+				}
+			}
+			if !m.HasColumn(&user0006{}, "Email") {
+				return nil
+			}
+			return m.DropColumn(&user0006{}, "Email")
+		},
+	})
+}
@@ -0,0 +1,62 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 0007 users表增加email_verified_at、student_verified列，新增邮箱验证码表email_verification
+
+type user0007 struct {
+	EmailVerifiedAt *time.Time
+	StudentVerified bool `gorm:"not null;default:false"`
+}
+
+func (user0007) TableName() string { return "users" }
+
+type emailVerification0007 struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UserID    uint   `gorm:"not null;index"`
+	Email     string `gorm:"size:255;not null"`
+	CodeHash  string `gorm:"size:64;not null"`
+	Attempts  int    `gorm:"not null;default:0"`
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
+
+func (emailVerification0007) TableName() string { return "email_verification" }
+
+func init() {
+	register(Migration{
+		Version: 7,
+		Name:    "email_verification",
+		Up: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			for _, column := range []string{"EmailVerifiedAt", "StudentVerified"} {
+				if !m.HasColumn(&user0007{}, column) {
+					if err := m.AddColumn(&user0007{}, column); err != nil {
+						return err
+					}
+				}
+			}
+			return tx.AutoMigrate(&emailVerification0007{})
+		},
+		Down: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			if err := m.DropTable(&emailVerification0007{}); err != nil {
+				return err
+			}
+			for _, column := range []string{"StudentVerified", "EmailVerifiedAt"} {
+				if !m.HasColumn(&user0007{}, column) {
+					continue
+				}
+				if err := m.DropColumn(&user0007{}, column); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
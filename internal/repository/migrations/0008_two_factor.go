@@ -0,0 +1,61 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 0008 users表增加role和两步验证相关的列，新增恢复码表recovery_code
+
+type user0008 struct {
+	Role          string `gorm:"size:32;not null;default:user"`
+	TOTPSecret    string `gorm:"size:64"`
+	TOTPEnabledAt *time.Time
+	TOTPLastStep  int64 `gorm:"not null;default:0"`
+}
+
+func (user0008) TableName() string { return "users" }
+
+type recoveryCode0008 struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UserID    uint   `gorm:"not null;index"`
+	CodeHash  string `gorm:"size:64;not null;uniqueIndex"`
+	UsedAt    *time.Time
+}
+
+func (recoveryCode0008) TableName() string { return "recovery_code" }
+
+func init() {
+	register(Migration{
+		Version: 8,
+		Name:    "two_factor",
+		Up: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			for _, column := range []string{"Role", "TOTPSecret", "TOTPEnabledAt", "TOTPLastStep"} {
+				if !m.HasColumn(&user0008{}, column) {
+					if err := m.AddColumn(&user0008{}, column); err != nil {
+						return err
+					}
+				}
+			}
+			return tx.AutoMigrate(&recoveryCode0008{})
+		},
+		Down: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			if err := m.DropTable(&recoveryCode0008{}); err != nil {
+				return err
+			}
+			for _, column := range []string{"TOTPLastStep", "TOTPEnabledAt", "TOTPSecret", "Role"} {
+				if !m.HasColumn(&user0008{}, column) {
+					continue
+				}
+				if err := m.DropColumn(&user0008{}, column); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
@@ -0,0 +1,33 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 0009 新增外部身份表user_identity，用于学校统一身份认证登录
+
+type userIdentity0009 struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UserID    uint   `gorm:"not null;index"`
+	Issuer    string `gorm:"size:255;not null;uniqueIndex:idx_user_identity_subject"`
+	Subject   string `gorm:"size:255;not null;uniqueIndex:idx_user_identity_subject"`
+	Email     string `gorm:"size:255"`
+}
+
+func (userIdentity0009) TableName() string { return "user_identity" }
+
+func init() {
+	register(Migration{
+		Version: 9,
+		Name:    "user_identity",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&userIdentity0009{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&userIdentity0009{})
+		},
+	})
+}
@@ -0,0 +1,52 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 0010 新增角色表role和角色权限表role_permission，写入内置角色和权限
+
+type role0010 struct {
+	Name        string `gorm:"primaryKey;size:32"`
+	Description string `gorm:"size:255"`
+}
+
+func (role0010) TableName() string { return "role" }
+
+type rolePermission0010 struct {
+	Role       string `gorm:"primaryKey;size:32"`
+	Permission string `gorm:"primaryKey;size:64"`
+}
+
+func (rolePermission0010) TableName() string { return "role_permission" }
+
+func init() {
+	register(Migration{
+		Version: 10,
+		Name:    "rbac",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(&role0010{}, &rolePermission0010{}); err != nil {
+				return err
+			}
+			roles := []role0010{
+				{Name: "user", Description: "普通用户"},
+				{Name: "moderator", Description: "版主，可以删除违规帖子"},
+				{Name: "admin", Description: "管理员，可以管理用户的角色"},
+			}
+			// 内置数据已存在时跳过：MySQL中建表会隐式提交，上次中断时可能已经写入了一部分
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&roles).Error; err != nil {
+				return err
+			}
+			permissions := []rolePermission0010{
+				{Role: "moderator", Permission: "post.delete.any"},
+				{Role: "admin", Permission: "post.delete.any"},
+				{Role: "admin", Permission: "user.role.manage"},
+			}
+			return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&permissions).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&rolePermission0010{}, &role0010{})
+		},
+	})
+}
@@ -0,0 +1,37 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 0011 新增登录会话表session
+
+type session0011 struct {
+	ID         uint `gorm:"primaryKey"`
+	CreatedAt  time.Time
+	UserID     uint   `gorm:"not null;index"`
+	FamilyID   string `gorm:"size:64;not null;uniqueIndex"`
+	JTI        string `gorm:"size:64;index"`
+	UserAgent  string `gorm:"size:512"`
+	IP         string `gorm:"size:64"`
+	LastSeenAt time.Time
+	ExpiresAt  time.Time
+	RevokedAt  *time.Time
+}
+
+func (session0011) TableName() string { return "session" }
+
+func init() {
+	register(Migration{
+		Version: 11,
+		Name:    "session",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&session0011{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&session0011{})
+		},
+	})
+}
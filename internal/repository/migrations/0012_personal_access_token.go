@@ -0,0 +1,38 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 0012 新增个人访问令牌表personal_access_token
+
+type personalAccessToken0012 struct {
+	ID         uint `gorm:"primaryKey"`
+	CreatedAt  time.Time
+	UserID     uint   `gorm:"not null;index"`
+	Name       string `gorm:"size:64;not null"`
+	Prefix     string `gorm:"size:16"`
+	TokenHash  string `gorm:"size:64;not null;uniqueIndex"`
+	Scopes     string `gorm:"size:255;not null"`
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	LastUsedIP string `gorm:"size:64"`
+	RevokedAt  *time.Time
+}
+
+func (personalAccessToken0012) TableName() string { return "personal_access_token" }
+
+func init() {
+	register(Migration{
+		Version: 12,
+		Name:    "personal_access_token",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&personalAccessToken0012{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&personalAccessToken0012{})
+		},
+	})
+}
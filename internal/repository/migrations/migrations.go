@@ -0,0 +1,189 @@
+package migrations
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migration 一次版本化的表结构变更
+// Up 执行变更，Down 撤销变更；两者都在事务中执行，但只有SQLite的DDL可以回滚：
+// MySQL执行DDL（建表、加列、加索引等）时会隐式提交，迁移中途失败后已经执行的DDL不会撤销，
+// 之后的语句也不再处于事务中。因此每个Up和Down都必须是幂等的——先检查表、列、索引是否存在，
+// 内置数据使用冲突时忽略的插入——失败修复后重新执行 migrate up / down 可以从中断处继续
+// 注意：迁移中不要直接引用 model 包里的结构体，model 以后会继续变化，
+// 而迁移必须永远描述"当时"的表结构，所以每个迁移都定义自己的表结构快照
+type Migration struct {
+	Version int64  // 版本号，严格递增
+	Name    string // 简短描述
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// SchemaMigration schema_migrations 表的记录，每条表示一个已执行的迁移
+type SchemaMigration struct {
+	Version   int64  `gorm:"primaryKey;autoIncrement:false"`
+	Name      string `gorm:"size:255"`
+	AppliedAt time.Time
+}
+
+// TableName 自定义表名
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Status 单个迁移的执行状态
+type Status struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt *time.Time
+}
+
+// registry 所有已注册的迁移，在各迁移文件的 init 中注册
+var registry []Migration
+
+// register 注册一个迁移，版本号重复时直接panic，在启动阶段暴露问题
+func register(m Migration) {
+	for _, existing := range registry {
+		if existing.Version == m.Version {
+			panic(fmt.Sprintf("migrations: duplicate version %d", m.Version))
+		}
+	}
+	registry = append(registry, m)
+	sort.Slice(registry, func(i, j int) bool { return registry[i].Version < registry[j].Version })
+}
+
+// All 按版本号升序返回所有迁移
+func All() []Migration {
+	out := make([]Migration, len(registry))
+	copy(out, registry)
+	return out
+}
+
+// ensureTable 确保 schema_migrations 表存在
+func ensureTable(db *gorm.DB) error {
+	return db.AutoMigrate(&SchemaMigration{})
+}
+
+// applied 返回已执行迁移的版本号集合
+func applied(db *gorm.DB) (map[int64]SchemaMigration, error) {
+	var rows []SchemaMigration
+	if err := db.Order("version").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make(map[int64]SchemaMigration, len(rows))
+	for _, r := range rows {
+		out[r.Version] = r
+	}
+	return out, nil
+}
+
+// Up 按顺序执行所有未执行的迁移，返回本次执行的迁移
+func Up(db *gorm.DB) ([]Migration, error) {
+	if err := ensureTable(db); err != nil {
+		return nil, fmt.Errorf("create schema_migrations: %w", err)
+	}
+	done, err := applied(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var ran []Migration
+	for _, m := range registry {
+		if _, ok := done[m.Version]; ok {
+			continue
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := m.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&SchemaMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return ran, fmt.Errorf("migration %d (%s) up: %w", m.Version, m.Name, err)
+		}
+		ran = append(ran, m)
+	}
+	return ran, nil
+}
+
+// Down 按倒序撤销最近执行的steps个迁移，返回本次撤销的迁移
+func Down(db *gorm.DB, steps int) ([]Migration, error) {
+	if steps <= 0 {
+		return nil, errors.New("steps must be positive")
+	}
+	if err := ensureTable(db); err != nil {
+		return nil, fmt.Errorf("create schema_migrations: %w", err)
+	}
+	done, err := applied(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var reverted []Migration
+	for i := len(registry) - 1; i >= 0 && len(reverted) < steps; i-- {
+		m := registry[i]
+		if _, ok := done[m.Version]; !ok {
+			continue
+		}
+		if m.Down == nil {
+			return reverted, fmt.Errorf("migration %d (%s) is irreversible", m.Version, m.Name)
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := m.Down(tx); err != nil {
+				return err
+			}
+			return tx.Delete(&SchemaMigration{}, m.Version).Error
+		})
+		if err != nil {
+			return reverted, fmt.Errorf("migration %d (%s) down: %w", m.Version, m.Name, err)
+		}
+		reverted = append(reverted, m)
+	}
+	return reverted, nil
+}
+
+// StatusOf 返回每个已注册迁移的执行状态
+func StatusOf(db *gorm.DB) ([]Status, error) {
+	if err := ensureTable(db); err != nil {
+		return nil, fmt.Errorf("create schema_migrations: %w", err)
+	}
+	done, err := applied(db)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]Status, 0, len(registry))
+	for _, m := range registry {
+		st := Status{Version: m.Version, Name: m.Name}
+		if r, ok := done[m.Version]; ok {
+			st.Applied = true
+			appliedAt := r.AppliedAt
+			st.AppliedAt = &appliedAt
+		}
+		out = append(out, st)
+	}
+	return out, nil
+}
+
+// CurrentVersion 返回数据库当前的表结构版本（已执行的最大版本号），未执行任何迁移时返回0
+func CurrentVersion(db *gorm.DB) (int64, error) {
+	if !db.Migrator().HasTable(&SchemaMigration{}) {
+		return 0, nil
+	}
+	var version int64
+	err := db.Model(&SchemaMigration{}).Select("COALESCE(MAX(version), 0)").Scan(&version).Error
+	return version, err
+}
+
+// LatestVersion 返回代码中注册的最新迁移版本号
+func LatestVersion() int64 {
+	if len(registry) == 0 {
+		return 0
+	}
+	return registry[len(registry)-1].Version
+}
@@ -0,0 +1,74 @@
+package migrations_test
+
+import (
+	"my-social-platform/internal/config"
+	"my-social-platform/internal/repository"
+	"my-social-platform/internal/repository/migrations"
+	"testing"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+func openDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := repository.Open(config.DatabaseConfig{Driver: config.DriverSQLite, DSN: ":memory:"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Logger = gormlogger.Discard
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func TestUpAndDown(t *testing.T) {
+	db := openDB(t)
+
+	ran, err := migrations.Up(db)
+	if err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	if len(ran) != len(migrations.All()) {
+		t.Fatalf("Up ran %d migrations, want %d", len(ran), len(migrations.All()))
+	}
+	if ran, err := migrations.Up(db); err != nil || len(ran) != 0 {
+		t.Fatalf("second Up: ran %d, err %v", len(ran), err)
+	}
+
+	if _, err := migrations.Down(db, len(migrations.All())); err != nil {
+		t.Fatalf("Down: %v", err)
+	}
+	if version, _ := migrations.CurrentVersion(db); version != 0 {
+		t.Errorf("version after Down = %d", version)
+	}
+	if _, err := migrations.Up(db); err != nil {
+		t.Fatalf("Up after Down: %v", err)
+	}
+}
+
+// MySQL中DDL会隐式提交，中断的迁移会留下已经执行的部分，所以每个Up和Down都必须可以重复执行
+func TestMigrationsAreIdempotent(t *testing.T) {
+	db := openDB(t)
+	all := migrations.All()
+	for _, m := range all {
+		if err := m.Up(db); err != nil {
+			t.Fatalf("%d up: %v", m.Version, err)
+		}
+		if err := m.Up(db); err != nil {
+			t.Errorf("%d up twice: %v", m.Version, err)
+		}
+	}
+	for i := len(all) - 1; i >= 0; i-- {
+		m := all[i]
+		if err := m.Down(db); err != nil {
+			t.Fatalf("%d down: %v", m.Version, err)
+		}
+		if err := m.Down(db); err != nil {
+			t.Errorf("%d down twice: %v", m.Version, err)
+		}
+	}
+}
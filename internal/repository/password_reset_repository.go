@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"my-social-platform/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// gormPasswordResetRepository PasswordResetRepository的GORM实现
+type gormPasswordResetRepository struct {
+	db *gorm.DB
+}
+
+// NewPasswordResetRepository 创建基于GORM的找回密码令牌仓库
+func NewPasswordResetRepository(db *gorm.DB) PasswordResetRepository {
+	return &gormPasswordResetRepository{db: db}
+}
+
+// Create 保存新的找回密码令牌
+func (r *gormPasswordResetRepository) Create(token *model.PasswordResetToken) error {
+	return r.db.Create(token).Error
+}
+
+// GetByHash 根据令牌哈希查找
+func (r *gormPasswordResetRepository) GetByHash(hash string) (*model.PasswordResetToken, error) {
+	var token model.PasswordResetToken
+	if err := r.db.Where("token_hash = ?", hash).First(&token).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &token, nil
+}
+
+// MarkUsed 将未使用的令牌标记为已使用，返回false表示令牌已被使用过
+func (r *gormPasswordResetRepository) MarkUsed(id uint, at time.Time) (bool, error) {
+	result := r.db.Model(&model.PasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", at)
+	return result.RowsAffected == 1, result.Error
+}
+
+// InvalidateUser 作废用户所有未使用的令牌
+func (r *gormPasswordResetRepository) InvalidateUser(userID uint, at time.Time) error {
+	return r.db.Model(&model.PasswordResetToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", at).Error
+}
+
+// CountSince 统计用户在since之后创建的令牌数量，用于限制重置邮件的发送频率
+func (r *gormPasswordResetRepository) CountSince(userID uint, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.PasswordResetToken{}).
+		Where("user_id = ? AND created_at >= ?", userID, since).
+		Count(&count).Error
+	return count, err
+}
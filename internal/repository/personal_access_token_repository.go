@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"my-social-platform/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// gormPersonalAccessTokenRepository PersonalAccessTokenRepository的GORM实现
+type gormPersonalAccessTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewPersonalAccessTokenRepository 创建基于GORM的个人访问令牌仓库
+func NewPersonalAccessTokenRepository(db *gorm.DB) PersonalAccessTokenRepository {
+	return &gormPersonalAccessTokenRepository{db: db}
+}
+
+// Create 保存新令牌
+func (r *gormPersonalAccessTokenRepository) Create(token *model.PersonalAccessToken) error {
+	return r.db.Create(token).Error
+}
+
+// GetByHash 根据令牌哈希查找未撤销的令牌
+func (r *gormPersonalAccessTokenRepository) GetByHash(hash string) (*model.PersonalAccessToken, error) {
+	var token model.PersonalAccessToken
+	if err := r.db.Where("token_hash = ? AND revoked_at IS NULL", hash).First(&token).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &token, nil
+}
+
+// ListByUser 用户未撤销的令牌（包括已过期的），最新创建的在前
+func (r *gormPersonalAccessTokenRepository) ListByUser(userID uint) ([]*model.PersonalAccessToken, error) {
+	var tokens []*model.PersonalAccessToken
+	err := r.db.Where("user_id = ? AND revoked_at IS NULL", userID).Order("created_at DESC").Find(&tokens).Error
+	return tokens, err
+}
+
+// CountByUser 用户未撤销的令牌数量
+func (r *gormPersonalAccessTokenRepository) CountByUser(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.PersonalAccessToken{}).Where("user_id = ? AND revoked_at IS NULL", userID).Count(&count).Error
+	return count, err
+}
+
+// Revoke 撤销用户的一个令牌，返回false表示令牌不存在、不属于该用户或已撤销
+func (r *gormPersonalAccessTokenRepository) Revoke(id, userID uint, at time.Time) (bool, error) {
+	result := r.db.Model(&model.PersonalAccessToken{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", at)
+	return result.RowsAffected == 1, result.Error
+}
+
+// RevokeUser 撤销用户的所有令牌
+func (r *gormPersonalAccessTokenRepository) RevokeUser(userID uint, at time.Time) error {
+	return r.db.Model(&model.PersonalAccessToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", at).Error
+}
+
+// Touch 记录最后使用时间和IP
+func (r *gormPersonalAccessTokenRepository) Touch(id uint, ip string, at time.Time) error {
+	return r.db.Model(&model.PersonalAccessToken{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"last_used_at": at,
+		"last_used_ip": ip,
+	}).Error
+}
@@ -1,29 +1,52 @@
 package repository
 
-import "my-social-platform/internal/model"
+import (
+	"my-social-platform/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// gormPostRepository PostRepository的GORM实现
+type gormPostRepository struct {
+	db *gorm.DB
+}
+
+// NewPostRepository 创建基于GORM的帖子仓库
+func NewPostRepository(db *gorm.DB) PostRepository {
+	return &gormPostRepository{db: db}
+}
 
 // 新建帖子
-func CreatePost(post *model.Post) error {
-	return DB.Create(post).Error
+func (r *gormPostRepository) Create(post *model.Post) error {
+	return r.db.Create(post).Error
 }
 
-// 根据帖子id查询帖子
-func GetPostByID(id uint) (*model.Post, error) {
+// 根据帖子id查询未删除的帖子
+func (r *gormPostRepository) GetByID(id uint) (*model.Post, error) {
 	var post model.Post
-	err := DB.First(&post, id).Error
-	return &post, err
+	if err := r.db.Where("deleted_at IS NULL").First(&post, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &post, nil
 }
 
-// 获取所有帖子
-func GetAllPosts() ([]*model.Post, error) {
+// 获取所有未删除的帖子
+func (r *gormPostRepository) List() ([]*model.Post, error) {
 	var posts []*model.Post
-	err := DB.Order("created_at DESC").Find(&posts).Error
+	err := r.db.Where("deleted_at IS NULL").Order("created_at DESC").Find(&posts).Error
 	return posts, err
 }
 
-// 根据用户ID获取帖子
-func GetPostsByUserID(userID uint) ([]*model.Post, error) {
+// 根据用户ID获取未删除的帖子
+func (r *gormPostRepository) ListByUserID(userID uint) ([]*model.Post, error) {
 	var posts []*model.Post
-	err := DB.Where("user_id = ?", userID).Order("created_at DESC").Find(&posts).Error
+	err := r.db.Where("user_id = ? AND deleted_at IS NULL", userID).Order("created_at DESC").Find(&posts).Error
 	return posts, err
 }
+
+// Delete 软删除帖子
+// model.Post的DeletedAt是普通指针字段而不是gorm.DeletedAt，所以这里手动写入删除时间
+func (r *gormPostRepository) Delete(id uint) error {
+	return r.db.Model(&model.Post{}).Where("id = ? AND deleted_at IS NULL", id).Update("deleted_at", time.Now()).Error
+}
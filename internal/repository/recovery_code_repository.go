@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"my-social-platform/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// gormRecoveryCodeRepository RecoveryCodeRepository的GORM实现
+type gormRecoveryCodeRepository struct {
+	db *gorm.DB
+}
+
+// NewRecoveryCodeRepository 创建基于GORM的恢复码仓库
+func NewRecoveryCodeRepository(db *gorm.DB) RecoveryCodeRepository {
+	return &gormRecoveryCodeRepository{db: db}
+}
+
+// Replace 删除用户原有的恢复码并保存新的一组，在同一个事务中完成
+func (r *gormRecoveryCodeRepository) Replace(userID uint, codes []*model.RecoveryCode) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&model.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+// Use 将用户未使用的恢复码标记为已使用，返回false表示恢复码不存在或已使用
+func (r *gormRecoveryCodeRepository) Use(userID uint, hash string, at time.Time) (bool, error) {
+	result := r.db.Model(&model.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		Update("used_at", at)
+	return result.RowsAffected == 1, result.Error
+}
+
+// CountUnused 用户剩余可用的恢复码数量
+func (r *gormRecoveryCodeRepository) CountUnused(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userID).Count(&count).Error
+	return count, err
+}
+
+// DeleteAll 删除用户的全部恢复码，关闭两步验证时使用
+func (r *gormRecoveryCodeRepository) DeleteAll(userID uint) error {
+	return r.db.Where("user_id = ?", userID).Delete(&model.RecoveryCode{}).Error
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"my-social-platform/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// gormRefreshTokenRepository RefreshTokenRepository的GORM实现
+type gormRefreshTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewRefreshTokenRepository 创建基于GORM的刷新令牌仓库
+func NewRefreshTokenRepository(db *gorm.DB) RefreshTokenRepository {
+	return &gormRefreshTokenRepository{db: db}
+}
+
+// Create 保存新的刷新令牌
+func (r *gormRefreshTokenRepository) Create(token *model.RefreshToken) error {
+	return r.db.Create(token).Error
+}
+
+// GetByHash 根据令牌哈希查找
+func (r *gormRefreshTokenRepository) GetByHash(hash string) (*model.RefreshToken, error) {
+	var token model.RefreshToken
+	if err := r.db.Where("token_hash = ?", hash).First(&token).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &token, nil
+}
+
+// MarkUsed 将未使用的令牌标记为已使用
+// 使用条件更新保证并发刷新时只有一个请求成功，返回false表示令牌已被使用过
+func (r *gormRefreshTokenRepository) MarkUsed(id uint, at time.Time) (bool, error) {
+	result := r.db.Model(&model.RefreshToken{}).
+		Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", id).
+		Update("used_at", at)
+	return result.RowsAffected == 1, result.Error
+}
+
+// RevokeFamily 吊销同一家族的所有令牌
+func (r *gormRefreshTokenRepository) RevokeFamily(familyID string, at time.Time) error {
+	return r.db.Model(&model.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", at).Error
+}
+
+// RevokeUser 吊销用户的所有刷新令牌
+func (r *gormRefreshTokenRepository) RevokeUser(userID uint, at time.Time) error {
+	return r.db.Model(&model.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", at).Error
+}
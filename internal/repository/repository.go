@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"errors"
+	"my-social-platform/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrNotFound 记录不存在
+// 各实现都要把自己的"未找到"错误转换为ErrNotFound，上层只需判断这一个错误
+var ErrNotFound = errors.New("record not found")
+
+// ErrDuplicate 违反唯一约束，如用户名或邮箱已被使用
+var ErrDuplicate = errors.New("duplicate record")
+
+// UserRepository 用户数据访问接口
+type UserRepository interface {
+	Create(user *model.User) error
+	GetByID(id uint) (*model.User, error)
+	GetByUsername(username string) (*model.User, error)
+	GetByEmail(email string) (*model.User, error)
+	Update(user *model.User) error
+	UpdateAvatar(userID uint, avatarURL string) error
+	UpdateBio(userID uint, bio string) error
+	IncrementTokenVersion(userID uint) error
+	AdvanceTOTPStep(userID uint, step int64) (bool, error)
+}
+
+// PostRepository 帖子数据访问接口
+type PostRepository interface {
+	Create(post *model.Post) error
+	GetByID(id uint) (*model.Post, error)
+	List() ([]*model.Post, error)
+	ListByUserID(userID uint) ([]*model.Post, error)
+	Delete(id uint) error
+}
+
+// CommentRepository 评论数据访问接口
+type CommentRepository interface {
+	Create(comment *model.Comment) error
+	GetByID(id uint) (*model.Comment, error)
+	ListByPostID(postID uint) ([]*model.Comment, error)
+	Delete(id uint) error
+}
+
+// RefreshTokenRepository 刷新令牌数据访问接口
+type RefreshTokenRepository interface {
+	Create(token *model.RefreshToken) error
+	GetByHash(hash string) (*model.RefreshToken, error)
+	MarkUsed(id uint, at time.Time) (bool, error)
+	RevokeFamily(familyID string, at time.Time) error
+	RevokeUser(userID uint, at time.Time) error
+}
+
+// SessionRepository 登录会话数据访问接口
+type SessionRepository interface {
+	Create(session *model.Session) error
+	GetByID(id uint) (*model.Session, error)
+	GetByFamily(familyID string) (*model.Session, error)
+	ListActive(userID uint, now time.Time) ([]*model.Session, error)
+	Issued(id uint, jti, ip string, at, expiresAt time.Time) error
+	Touch(id uint, ip string, at time.Time) error
+	Revoke(id uint, at time.Time) (bool, error)
+	RevokeUser(userID uint, at time.Time) error
+}
+
+// PersonalAccessTokenRepository 个人访问令牌数据访问接口
+type PersonalAccessTokenRepository interface {
+	Create(token *model.PersonalAccessToken) error
+	GetByHash(hash string) (*model.PersonalAccessToken, error)
+	ListByUser(userID uint) ([]*model.PersonalAccessToken, error)
+	CountByUser(userID uint) (int64, error)
+	Revoke(id, userID uint, at time.Time) (bool, error)
+	RevokeUser(userID uint, at time.Time) error
+	Touch(id uint, ip string, at time.Time) error
+}
+
+// RevokedTokenRepository 已吊销访问令牌数据访问接口
+type RevokedTokenRepository interface {
+	Create(token *model.RevokedToken) error
+	ListActive(now time.Time) ([]*model.RevokedToken, error)
+	DeleteExpired(now time.Time) error
+}
+
+// PasswordResetRepository 找回密码令牌数据访问接口
+type PasswordResetRepository interface {
+	Create(token *model.PasswordResetToken) error
+	GetByHash(hash string) (*model.PasswordResetToken, error)
+	MarkUsed(id uint, at time.Time) (bool, error)
+	InvalidateUser(userID uint, at time.Time) error
+	CountSince(userID uint, since time.Time) (int64, error)
+}
+
+// EmailVerificationRepository 邮箱验证码数据访问接口
+type EmailVerificationRepository interface {
+	Create(v *model.EmailVerification) error
+	GetLatest(userID uint) (*model.EmailVerification, error)
+	IncrementAttempts(id uint, max int) (bool, error)
+	MarkUsed(id uint, at time.Time) (bool, error)
+	InvalidateUser(userID uint, at time.Time) error
+	CountSince(userID uint, since time.Time) (int64, error)
+}
+
+// RecoveryCodeRepository 两步验证恢复码数据访问接口
+type RecoveryCodeRepository interface {
+	Replace(userID uint, codes []*model.RecoveryCode) error
+	Use(userID uint, hash string, at time.Time) (bool, error)
+	CountUnused(userID uint) (int64, error)
+	DeleteAll(userID uint) error
+}
+
+// UserIdentityRepository 外部身份（统一身份认证）数据访问接口
+type UserIdentityRepository interface {
+	Create(identity *model.UserIdentity) error
+	Get(issuer, subject string) (*model.UserIdentity, error)
+}
+
+// RoleRepository 角色和权限数据访问接口
+type RoleRepository interface {
+	List() ([]*model.Role, error)
+	ListPermissions() ([]*model.RolePermission, error)
+}
+
+// translate 将GORM的错误转换为仓库层错误
+func translate(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrDuplicate
+	}
+	return err
+}
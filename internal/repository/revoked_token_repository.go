@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"my-social-platform/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// gormRevokedTokenRepository RevokedTokenRepository的GORM实现
+type gormRevokedTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewRevokedTokenRepository 创建基于GORM的已吊销令牌仓库
+func NewRevokedTokenRepository(db *gorm.DB) RevokedTokenRepository {
+	return &gormRevokedTokenRepository{db: db}
+}
+
+// Create 记录被吊销的令牌，同一个jti重复吊销时忽略
+func (r *gormRevokedTokenRepository) Create(token *model.RevokedToken) error {
+	var count int64
+	if err := r.db.Model(&model.RevokedToken{}).Where("jti = ?", token.JTI).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	return r.db.Create(token).Error
+}
+
+// ListActive 列出尚未过期的吊销记录
+func (r *gormRevokedTokenRepository) ListActive(now time.Time) ([]*model.RevokedToken, error) {
+	var tokens []*model.RevokedToken
+	err := r.db.Where("expires_at > ?", now).Find(&tokens).Error
+	return tokens, err
+}
+
+// DeleteExpired 删除令牌本身已经过期的吊销记录
+func (r *gormRevokedTokenRepository) DeleteExpired(now time.Time) error {
+	return r.db.Where("expires_at <= ?", now).Delete(&model.RevokedToken{}).Error
+}
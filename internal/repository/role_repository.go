@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"my-social-platform/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// gormRoleRepository RoleRepository的GORM实现
+type gormRoleRepository struct {
+	db *gorm.DB
+}
+
+// NewRoleRepository 创建基于GORM的角色仓库
+func NewRoleRepository(db *gorm.DB) RoleRepository {
+	return &gormRoleRepository{db: db}
+}
+
+// List 获取全部角色
+func (r *gormRoleRepository) List() ([]*model.Role, error) {
+	var roles []*model.Role
+	err := r.db.Order("name").Find(&roles).Error
+	return roles, err
+}
+
+// ListPermissions 获取全部角色的权限
+func (r *gormRoleRepository) ListPermissions() ([]*model.RolePermission, error) {
+	var permissions []*model.RolePermission
+	err := r.db.Order("role, permission").Find(&permissions).Error
+	return permissions, err
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"my-social-platform/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// gormSessionRepository SessionRepository的GORM实现
+type gormSessionRepository struct {
+	db *gorm.DB
+}
+
+// NewSessionRepository 创建基于GORM的会话仓库
+func NewSessionRepository(db *gorm.DB) SessionRepository {
+	return &gormSessionRepository{db: db}
+}
+
+// Create 保存新会话
+func (r *gormSessionRepository) Create(session *model.Session) error {
+	return r.db.Create(session).Error
+}
+
+// GetByID 根据会话ID查找
+func (r *gormSessionRepository) GetByID(id uint) (*model.Session, error) {
+	var session model.Session
+	if err := r.db.First(&session, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &session, nil
+}
+
+// GetByFamily 根据刷新令牌家族查找
+func (r *gormSessionRepository) GetByFamily(familyID string) (*model.Session, error) {
+	var session model.Session
+	if err := r.db.Where("family_id = ?", familyID).First(&session).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &session, nil
+}
+
+// ListActive 用户未结束、未过期的会话，最近访问的在前
+func (r *gormSessionRepository) ListActive(userID uint, now time.Time) ([]*model.Session, error) {
+	var sessions []*model.Session
+	err := r.db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, now).
+		Order("last_seen_at DESC").Find(&sessions).Error
+	return sessions, err
+}
+
+// Issued 会话签发了新的令牌：记录访问令牌的jti和刷新令牌的过期时间
+func (r *gormSessionRepository) Issued(id uint, jti, ip string, at, expiresAt time.Time) error {
+	return r.db.Model(&model.Session{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"jti":          jti,
+		"ip":           ip,
+		"last_seen_at": at,
+		"expires_at":   expiresAt,
+	}).Error
+}
+
+// Touch 记录最后访问时间和IP
+func (r *gormSessionRepository) Touch(id uint, ip string, at time.Time) error {
+	return r.db.Model(&model.Session{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"ip":           ip,
+		"last_seen_at": at,
+	}).Error
+}
+
+// Revoke 结束会话，返回false表示会话已经结束
+func (r *gormSessionRepository) Revoke(id uint, at time.Time) (bool, error) {
+	result := r.db.Model(&model.Session{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", at)
+	return result.RowsAffected == 1, result.Error
+}
+
+// RevokeUser 结束用户的所有会话
+func (r *gormSessionRepository) RevokeUser(userID uint, at time.Time) error {
+	return r.db.Model(&model.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", at).Error
+}
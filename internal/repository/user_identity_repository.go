@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"my-social-platform/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// gormUserIdentityRepository UserIdentityRepository的GORM实现
+type gormUserIdentityRepository struct {
+	db *gorm.DB
+}
+
+// NewUserIdentityRepository 创建基于GORM的外部身份仓库
+func NewUserIdentityRepository(db *gorm.DB) UserIdentityRepository {
+	return &gormUserIdentityRepository{db: db}
+}
+
+// Create 关联一个外部身份，同一身份已被关联时返回ErrDuplicate
+func (r *gormUserIdentityRepository) Create(identity *model.UserIdentity) error {
+	return translate(r.db.Create(identity).Error)
+}
+
+// Get 根据身份提供方和sub查找外部身份
+func (r *gormUserIdentityRepository) Get(issuer, subject string) (*model.UserIdentity, error) {
+	var identity model.UserIdentity
+	if err := r.db.Where("issuer = ? AND subject = ?", issuer, subject).First(&identity).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &identity, nil
+}
@@ -1,32 +1,79 @@
 package repository
 
-import "my-social-platform/internal/model"
+import (
+	"my-social-platform/internal/model"
 
-// GetUserByID 根据用户ID获取用户信息
-func GetUserByID(id uint) (*model.User, error) {
+	"gorm.io/gorm"
+)
+
+// gormUserRepository UserRepository的GORM实现
+type gormUserRepository struct {
+	db *gorm.DB
+}
+
+// NewUserRepository 创建基于GORM的用户仓库
+func NewUserRepository(db *gorm.DB) UserRepository {
+	return &gormUserRepository{db: db}
+}
+
+// Create 新建用户
+func (r *gormUserRepository) Create(user *model.User) error {
+	return translate(r.db.Create(user).Error)
+}
+
+// GetByID 根据用户ID获取用户信息
+func (r *gormUserRepository) GetByID(id uint) (*model.User, error) {
 	var user model.User
-	err := DB.First(&user, id).Error
-	return &user, err
+	if err := r.db.First(&user, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &user, nil
 }
 
-// GetUserByUsername 根据用户名获取用户信息
-func GetUserByUsername(username string) (*model.User, error) {
+// GetByUsername 根据用户名获取用户信息
+func (r *gormUserRepository) GetByUsername(username string) (*model.User, error) {
 	var user model.User
-	err := DB.Where("username = ?", username).First(&user).Error
-	return &user, err
+	if err := r.db.Where("username = ?", username).First(&user).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &user, nil
 }
 
-// UpdateUserProfile 更新用户个人资料
-func UpdateUserProfile(user *model.User) error {
-	return DB.Save(user).Error
+// GetByEmail 根据邮箱获取用户信息
+func (r *gormUserRepository) GetByEmail(email string) (*model.User, error) {
+	var user model.User
+	if err := r.db.Where("email = ?", email).First(&user).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &user, nil
+}
+
+// Update 保存用户的全部字段
+func (r *gormUserRepository) Update(user *model.User) error {
+	return translate(r.db.Save(user).Error)
 }
 
 // UpdateAvatar 更新用户头像
-func UpdateAvatar(userID uint, avatarURL string) error {
-	return DB.Model(&model.User{}).Where("id = ?", userID).Update("avatar", avatarURL).Error
+func (r *gormUserRepository) UpdateAvatar(userID uint, avatarURL string) error {
+	return r.db.Model(&model.User{}).Where("id = ?", userID).Update("avatar", avatarURL).Error
+}
+
+// UpdateBio 更新用户个性签名
+func (r *gormUserRepository) UpdateBio(userID uint, bio string) error {
+	return r.db.Model(&model.User{}).Where("id = ?", userID).Update("bio", bio).Error
+}
+
+// IncrementTokenVersion 令牌版本加一，使该用户之前签发的访问令牌全部失效
+func (r *gormUserRepository) IncrementTokenVersion(userID uint) error {
+	return r.db.Model(&model.User{}).Where("id = ?", userID).
+		Update("token_version", gorm.Expr("token_version + 1")).Error
 }
 
-// UpdateUserBio 更新用户个性签名
-func UpdateUserBio(userID uint, bio string) error {
-	return DB.Model(&model.User{}).Where("id = ?", userID).Update("bio", bio).Error
+// AdvanceTOTPStep 记录已使用的两步验证码周期，只有step大于已记录的周期时才更新
+// 返回false表示该周期（或更晚的周期）的验证码已经用过，防止验证码被截获后重放
+func (r *gormUserRepository) AdvanceTOTPStep(userID uint, step int64) (bool, error) {
+	result := r.db.Model(&model.User{}).
+		Where("id = ? AND totp_last_step < ?", userID, step).
+		Update("totp_last_step", step)
+	return result.RowsAffected == 1, result.Error
 }
@@ -0,0 +1,114 @@
+package seed
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+)
+
+// placeholderImages 生成的占位图片数量
+const placeholderImages = 12
+
+var surnames = []string{"王", "李", "张", "刘", "陈", "杨", "黄", "赵", "吴", "周", "徐", "孙", "马", "朱", "胡", "郭", "何", "林", "罗", "高"}
+
+var givenNames = []string{"子涵", "浩然", "梓萱", "宇轩", "欣怡", "俊杰", "雨桐", "思远", "佳琪", "一鸣", "诗雨", "明哲", "可馨", "天佑", "若曦", "博文", "晓彤", "嘉豪", "语嫣", "振宇"}
+
+var bios = []string{
+	"热爱生活，热爱学习",
+	"图书馆常驻人口",
+	"每天都要去食堂二楼",
+	"篮球场见",
+	"正在准备考研，互相鼓励",
+	"摄影爱好者，记录校园的四季",
+	"代码写累了就去跑步",
+	"社团招新中，欢迎私信",
+	"",
+}
+
+var tags = []string{"校园", "美食", "学习", "影视", "阅读", "运动", "社团", "二手"}
+
+var postTemplates = map[string][]string{
+	"校园": {"今天的晚霞太好看了，随手拍一张", "校园里的春天真美啊！", "新图书馆终于开放了，环境很不错", "宿舍楼下的猫又来了"},
+	"美食": {"二食堂新出的麻辣香锅强烈推荐", "分享一下校园美食！", "学校后街新开了一家奶茶店，味道还可以", "今天的早餐：豆浆油条"},
+	"学习": {"期末复习资料整理好了，需要的同学评论区留言", "分享一款不错的学习软件", "高数第三章有没有人一起讨论", "自习室占座问题大家怎么看"},
+	"影视": {"周末去看了电影，推荐给大家！", "最近在追的一部剧，剧情很精彩", "有没有好看的纪录片推荐"},
+	"阅读": {"这是我最近看的一本书，非常推荐！", "读书会本周主题：科幻小说", "图书馆新到了一批书"},
+	"运动": {"今晚操场夜跑，有人一起吗", "院篮球赛决赛，大家来加油", "羽毛球馆周末还有空位"},
+	"社团": {"摄影社本周六外拍活动，欢迎报名", "吉他社招新啦", "志愿者协会招募周末活动志愿者"},
+	"二手": {"出一台九成新的台灯，价格可议", "毕业清仓：教材和小电器", "求购二手自行车"},
+}
+
+var commentTemplates = []string{
+	"说得太对了",
+	"同问",
+	"已收藏，感谢分享",
+	"哈哈哈哈",
+	"在哪里呀？",
+	"下次带我一起",
+	"支持一下",
+	"这个我也遇到过",
+	"求资料，已私信",
+	"好看！",
+}
+
+// pick 随机选择一个元素
+func pick[T any](r *rand.Rand, items []T) T {
+	return items[r.IntN(len(items))]
+}
+
+// nickname 随机生成中文昵称
+func nickname(r *rand.Rand) string {
+	return pick(r, surnames) + pick(r, givenNames)
+}
+
+// writePlaceholderImages 在dir下生成纯色占位图片，返回可访问的URL路径
+// 图片内容固定，重复执行会覆盖为相同的文件
+func writePlaceholderImages(dir string) ([]string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	urls := make([]string, 0, placeholderImages)
+	for i := 0; i < placeholderImages; i++ {
+		name := fmt.Sprintf("seed-%02d.png", i+1)
+		if err := writePlaceholder(filepath.Join(dir, name), i); err != nil {
+			return nil, err
+		}
+		urls = append(urls, "/uploads/images/"+name)
+	}
+	return urls, nil
+}
+
+// writePlaceholder 生成一张400x300的渐变色PNG
+func writePlaceholder(path string, i int) error {
+	const w, h = 400, 300
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	// 色相按序号均匀分布
+	base := color.RGBA{
+		R: uint8(80 + (i*53)%160),
+		G: uint8(80 + (i*97)%160),
+		B: uint8(80 + (i*151)%160),
+		A: 255,
+	}
+	for y := 0; y < h; y++ {
+		shade := uint8(y * 60 / h)
+		c := color.RGBA{R: base.R - shade/2, G: base.G - shade/2, B: base.B - shade/2, A: 255}
+		for x := 0; x < w; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
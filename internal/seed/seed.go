@@ -0,0 +1,392 @@
+package seed
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"my-social-platform/internal/model"
+	"my-social-platform/internal/service"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// UsernamePrefix 生成的用户名前缀，用户名形如 seed_000001
+const UsernamePrefix = "seed_"
+
+// Options 生成数据的规模和随机种子
+// 相同的Seed和规模参数总是生成完全相同的数据（ID取决于数据库中已有的记录）
+type Options struct {
+	Seed            uint64 // 随机种子
+	Users           int    // 用户数
+	PostsPerUser    int    // 每个用户的平均发帖数
+	FollowsPerUser  int    // 每个用户的平均关注数
+	CommentsPerPost int    // 每个帖子的平均评论数
+	LikesPerPost    int    // 每个帖子的平均点赞数
+	Password        string // 所有生成用户的登录密码
+	ImageDir        string // 占位图片保存目录
+	BatchSize       int    // 批量插入的条数
+}
+
+// DefaultOptions 适合前端演示的小规模数据
+func DefaultOptions() Options {
+	return Options{
+		Seed:            1,
+		Users:           50,
+		PostsPerUser:    5,
+		FollowsPerUser:  10,
+		CommentsPerPost: 3,
+		LikesPerPost:    5,
+		Password:        "password",
+		ImageDir:        "uploads/images",
+		BatchSize:       1000,
+	}
+}
+
+// Stats 实际生成的记录数
+type Stats struct {
+	Users    int
+	Follows  int
+	Posts    int
+	Comments int
+	Likes    int
+}
+
+// baseTime 所有生成时间的起点，固定值保证结果可复现
+var baseTime = time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)
+
+// 各阶段使用独立的随机数流，互不影响：
+// 调整评论数不会改变生成的用户和关注关系
+const (
+	streamUsers = iota + 1
+	streamFollows
+	streamPosts
+)
+
+// Seeder 测试数据生成器
+type Seeder struct {
+	db   *gorm.DB
+	opts Options
+}
+
+// New 创建Seeder
+func New(db *gorm.DB, opts Options) *Seeder {
+	return &Seeder{db: db, opts: opts}
+}
+
+// rng 为指定阶段和序号创建确定性的随机数生成器
+func (s *Seeder) rng(stream, index uint64) *rand.Rand {
+	return rand.New(rand.NewPCG(s.opts.Seed, stream<<48|index))
+}
+
+// Run 生成全部数据
+// 顺序：占位图片 -> 关注关系（先算计数）-> 帖子计数 -> 用户 -> 关注 -> 帖子、点赞、评论
+func (s *Seeder) Run() (*Stats, error) {
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+
+	var existing int64
+	if err := s.db.Model(&model.User{}).Where("username LIKE ?", UsernamePrefix+"%").Count(&existing).Error; err != nil {
+		return nil, err
+	}
+	if existing > 0 {
+		return nil, fmt.Errorf("database already contains %d seeded users, use a fresh database", existing)
+	}
+
+	images, err := writePlaceholderImages(s.opts.ImageDir)
+	if err != nil {
+		return nil, fmt.Errorf("write placeholder images: %w", err)
+	}
+
+	// 所有用户共用一个密码哈希：bcrypt每次约几十毫秒，逐个哈希几十万用户需要数小时
+	hashed, err := service.HashPassword(s.opts.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	n := s.opts.Users
+	follows := s.followGraph()
+	followCount := make([]int, n)
+	fansCount := make([]int, n)
+	for _, e := range follows {
+		followCount[e[0]]++
+		fansCount[e[1]]++
+	}
+
+	// 先按用户生成帖子的概要（点赞数），累计每个用户的获赞数
+	postCounts := make([]int, n)
+	likeCount := make([]int, n)
+	for u := 0; u < n; u++ {
+		postCounts[u] = around(s.rng(streamPosts, uint64(u)), s.opts.PostsPerUser)
+	}
+	s.eachPost(postCounts, images, func(p *plannedPost) {
+		likeCount[p.owner] += len(p.likers)
+	})
+
+	stats := &Stats{}
+	userIDs, err := s.insertUsers(hashed, followCount, fansCount, likeCount)
+	if err != nil {
+		return stats, err
+	}
+	stats.Users = len(userIDs)
+	log.Printf("seed: %d users", stats.Users)
+
+	if stats.Follows, err = s.insertFollows(follows, userIDs); err != nil {
+		return stats, err
+	}
+	log.Printf("seed: %d follows", stats.Follows)
+
+	if err := s.insertPosts(postCounts, images, userIDs, stats); err != nil {
+		return stats, err
+	}
+	log.Printf("seed: %d posts, %d likes, %d comments", stats.Posts, stats.Likes, stats.Comments)
+	return stats, nil
+}
+
+// validate 校验参数
+func (s *Seeder) validate() error {
+	o := s.opts
+	switch {
+	case o.Users <= 0:
+		return errors.New("users must be positive")
+	case o.PostsPerUser < 0 || o.FollowsPerUser < 0 || o.CommentsPerPost < 0 || o.LikesPerPost < 0:
+		return errors.New("per-user and per-post averages must not be negative")
+	case o.Password == "":
+		return errors.New("password is required")
+	case o.BatchSize <= 0:
+		return errors.New("batch size must be positive")
+	}
+	return nil
+}
+
+// around 返回以avg为均值、在[0, 2*avg]内均匀分布的随机数
+func around(r *rand.Rand, avg int) int {
+	if avg <= 0 {
+		return 0
+	}
+	return r.IntN(2*avg + 1)
+}
+
+// popular 偏向小序号的随机用户：序号越小被选中的概率越大，模拟少数热门用户
+func popular(r *rand.Rand, n int) int {
+	f := r.Float64()
+	return int(f * f * float64(n))
+}
+
+// followGraph 生成关注关系，返回 [关注者序号, 被关注者序号] 列表
+func (s *Seeder) followGraph() [][2]int32 {
+	n := s.opts.Users
+	var edges [][2]int32
+	for u := 0; u < n; u++ {
+		r := s.rng(streamFollows, uint64(u))
+		k := min(around(r, s.opts.FollowsPerUser), n-1)
+		seen := make(map[int]bool, k)
+		for tries := 0; len(seen) < k && tries < 4*k; tries++ {
+			v := popular(r, n)
+			if v == u || seen[v] {
+				continue
+			}
+			seen[v] = true
+			edges = append(edges, [2]int32{int32(u), int32(v)})
+		}
+	}
+	return edges
+}
+
+// insertUsers 批量插入用户，返回按序号排列的用户ID
+func (s *Seeder) insertUsers(hashed string, followCount, fansCount, likeCount []int) ([]uint, error) {
+	n := s.opts.Users
+	ids := make([]uint, 0, n)
+	batch := make([]model.User, 0, s.opts.BatchSize)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := s.db.Create(&batch).Error; err != nil {
+			return err
+		}
+		for _, u := range batch {
+			ids = append(ids, u.ID)
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	for u := 0; u < n; u++ {
+		r := s.rng(streamUsers, uint64(u))
+		created := baseTime.Add(time.Duration(r.IntN(30*24)) * time.Hour)
+		batch = append(batch, model.User{
+			CreatedAt:   created,
+			UpdatedAt:   created,
+			Username:    fmt.Sprintf("%s%06d", UsernamePrefix, u+1),
+			Password:    hashed,
+			Nickname:    nickname(r),
+			Bio:         pick(r, bios),
+			FollowCount: followCount[u],
+			FansCount:   fansCount[u],
+			LikeCount:   likeCount[u],
+		})
+		if len(batch) == cap(batch) {
+			if err := flush(); err != nil {
+				return ids, err
+			}
+		}
+	}
+	return ids, flush()
+}
+
+// insertFollows 批量插入关注关系
+func (s *Seeder) insertFollows(edges [][2]int32, ids []uint) (int, error) {
+	batch := make([]model.Follow, 0, s.opts.BatchSize)
+	inserted := 0
+	for i, e := range edges {
+		batch = append(batch, model.Follow{
+			CreatedAt:  baseTime.Add(time.Duration(30*24+i%(60*24)) * time.Hour),
+			FollowerID: ids[e[0]],
+			FolloweeID: ids[e[1]],
+		})
+		if len(batch) == cap(batch) || i == len(edges)-1 {
+			if err := s.db.Create(&batch).Error; err != nil {
+				return inserted, err
+			}
+			inserted += len(batch)
+			batch = batch[:0]
+		}
+	}
+	return inserted, nil
+}
+
+// plannedPost 一条帖子及其点赞、评论的完整计划，由确定性的随机数生成
+type plannedPost struct {
+	owner    int
+	post     model.Post
+	likers   []int
+	comments []plannedComment
+}
+
+type plannedComment struct {
+	user    int
+	at      time.Time
+	content string
+}
+
+// eachPost 按顺序生成所有帖子的计划
+// 每条帖子使用以其全局序号为种子的随机数，多次调用结果完全一致
+func (s *Seeder) eachPost(postCounts []int, images []string, fn func(p *plannedPost)) {
+	n := s.opts.Users
+	index := uint64(0)
+	for u := 0; u < n; u++ {
+		for i := 0; i < postCounts[u]; i++ {
+			index++
+			r := s.rng(streamPosts, uint64(n)+index)
+
+			tag := pick(r, tags)
+			created := baseTime.Add(time.Duration(30*24+r.IntN(150*24)) * time.Hour).Add(time.Duration(r.IntN(3600)) * time.Second)
+			p := &plannedPost{owner: u}
+			p.post = model.Post{
+				CreatedAt: created,
+				UpdatedAt: created,
+				Content:   pick(r, postTemplates[tag]),
+				Tag:       tag,
+			}
+			// 大约三分之二的帖子带图片
+			if r.IntN(3) > 0 {
+				p.post.Images = pick(r, images)
+			}
+
+			likes := min(around(r, s.opts.LikesPerPost), n-1)
+			seen := make(map[int]bool, likes)
+			for tries := 0; len(seen) < likes && tries < 4*likes; tries++ {
+				v := popular(r, n)
+				if v == u || seen[v] {
+					continue
+				}
+				seen[v] = true
+				p.likers = append(p.likers, v)
+			}
+
+			for c := around(r, s.opts.CommentsPerPost); c > 0; c-- {
+				p.comments = append(p.comments, plannedComment{
+					user:    r.IntN(n),
+					at:      created.Add(time.Duration(1+r.IntN(72*60)) * time.Minute),
+					content: pick(r, commentTemplates),
+				})
+			}
+
+			p.post.LikeCount = len(p.likers)
+			p.post.CommentCount = len(p.comments)
+			fn(p)
+		}
+	}
+}
+
+// insertPosts 批量插入帖子，每批帖子插入后再插入它们的点赞和评论
+func (s *Seeder) insertPosts(postCounts []int, images []string, ids []uint, stats *Stats) error {
+	planned := make([]*plannedPost, 0, s.opts.BatchSize)
+	posts := make([]model.Post, 0, s.opts.BatchSize)
+	var likes []model.PostLike
+	var comments []model.Comment
+
+	flush := func() error {
+		if len(posts) == 0 {
+			return nil
+		}
+		if err := s.db.Create(&posts).Error; err != nil {
+			return err
+		}
+		for i, p := range planned {
+			postID := posts[i].ID
+			for j, liker := range p.likers {
+				likes = append(likes, model.PostLike{
+					CreatedAt: p.post.CreatedAt.Add(time.Duration(j+1) * time.Minute),
+					PostID:    postID,
+					UserID:    ids[liker],
+				})
+			}
+			for _, c := range p.comments {
+				comments = append(comments, model.Comment{
+					CreatedAt: c.at,
+					UpdatedAt: c.at,
+					PostID:    postID,
+					UserID:    ids[c.user],
+					Content:   c.content,
+				})
+			}
+		}
+		if len(likes) > 0 {
+			if err := s.db.CreateInBatches(&likes, s.opts.BatchSize).Error; err != nil {
+				return err
+			}
+		}
+		if len(comments) > 0 {
+			if err := s.db.CreateInBatches(&comments, s.opts.BatchSize).Error; err != nil {
+				return err
+			}
+		}
+		stats.Posts += len(posts)
+		stats.Likes += len(likes)
+		stats.Comments += len(comments)
+		planned, posts, likes, comments = planned[:0], posts[:0], likes[:0], comments[:0]
+		return nil
+	}
+
+	var err error
+	s.eachPost(postCounts, images, func(p *plannedPost) {
+		if err != nil {
+			return
+		}
+		p.post.UserID = ids[p.owner]
+		planned = append(planned, p)
+		posts = append(posts, p.post)
+		if len(posts) == cap(posts) {
+			err = flush()
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return flush()
+}
@@ -5,6 +5,7 @@ import (
 	"my-social-platform/internal/dto"
 	"my-social-platform/internal/model"
 	"my-social-platform/internal/repository"
+	"sync"
 
 	"golang.org/x/crypto/bcrypt"
 )
@@ -27,18 +28,35 @@ func VerifyPassword(hashedPassword, password string) bool {
 // User到UserDTO的转换函数
 func ToUserDTO(user *model.User) *dto.UserDTO {
 	return &dto.UserDTO{
-		ID:          user.ID,
-		Username:    user.Username,
-		Nickname:    user.Nickname,
-		Avatar:      user.Avatar,
-		Bio:         user.Bio,
-		FollowCount: user.FollowCount,
-		FansCount:   user.FansCount,
-		LikeCount:   user.LikeCount,
+		ID:               user.ID,
+		Username:         user.Username,
+		Email:            stringValue(user.Email),
+		Nickname:         user.Nickname,
+		Avatar:           user.Avatar,
+		Bio:              user.Bio,
+		FollowCount:      user.FollowCount,
+		FansCount:        user.FansCount,
+		LikeCount:        user.LikeCount,
+		EmailVerified:    user.EmailVerifiedAt != nil,
+		StudentVerified:  user.StudentVerified,
+		Role:             user.Role,
+		TwoFactorEnabled: user.TOTPEnabledAt != nil,
 	}
 }
 
-// Register - 注册用户
+// AuthService 注册和登录相关的业务逻辑
+// 类似于Java中的@Service，依赖通过构造函数注入（相当于@Autowired）
+type AuthService struct {
+	users     repository.UserRepository
+	guard     *LoginGuard
+	usernames *UsernamePolicy
+}
+
+// NewAuthService 创建AuthService
+func NewAuthService(users repository.UserRepository, guard *LoginGuard, usernames *UsernamePolicy) *AuthService {
+	return &AuthService{users: users, guard: guard, usernames: usernames}
+}
+
 // Register - 用户注册方法
 // 类似于Java中的Service层方法:
 // @Service
@@ -60,52 +78,148 @@ func ToUserDTO(user *model.User) *dto.UserDTO {
 //	        return userRepository.save(user);
 //	    }
 //	}
-func Register(username, password string) (*dto.UserDTO, error) {
-	// 1. 对密码进行加密,类似于Spring Security的passwordEncoder
+//
+// email可以为空；填写后可以用于找回密码
+//
+// 参数不合法时返回*ValidationError，其中包含每个字段的错误；
+// 用户名或邮箱已被使用时返回ErrUsernameTaken或ErrEmailTaken
+func (s *AuthService) Register(username, password, email string) (*dto.UserDTO, error) {
+	// 1. 校验参数，类似于Spring中的@Valid，一次返回所有字段的错误
+	normalized, err := s.validateRegistration(username, password, email)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. 检查用户名和邮箱是否已被使用，数据库的唯一约束兜底并发注册的情况
+	if _, err := s.users.GetByUsername(username); err == nil {
+		return nil, ErrUsernameTaken
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+	if normalized != "" {
+		if _, err := s.users.GetByEmail(normalized); err == nil {
+			return nil, ErrEmailTaken
+		} else if !errors.Is(err, repository.ErrNotFound) {
+			return nil, err
+		}
+	}
+
+	// 3. 对密码进行加密,类似于Spring Security的passwordEncoder
 	hashedPassword, err := HashPassword(password)
 	if err != nil {
 		return nil, err
 	}
 
-	// 2. 创建用户对象,类似于Java中的User实体类
+	// 4. 创建用户对象,类似于Java中的User实体类
 	user := &model.User{
 		Username: username,
 		Password: hashedPassword,
 	}
+	if normalized != "" {
+		user.Email = &normalized
+	}
 
-	// 3. 保存到数据库,类似于JPA的save方法
-	// repository.DB.Create相当于userRepository.save()
-	if err := repository.DB.Create(user).Error; err != nil {
+	// 5. 保存到数据库,类似于JPA的save方法
+	if err := s.users.Create(user); err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			return nil, s.duplicateError(username, normalized, err)
+		}
 		return nil, err
 	}
 
 	return ToUserDTO(user), nil
 }
 
-func Login(username, password string) (*dto.UserDTO, error) {
-	var user model.User
-	// 1. 根据用户名查找用户
-	// repository.DB.Where相当于JPA的findByUsername方法
-	// First方法相当于findOne或getOne方法
-	if err := repository.DB.Where("username = ?", username).First(&user).Error; err != nil {
-		return nil, errors.New("user not found")
+// duplicateError 并发注册时第2步没有发现冲突、由唯一约束拒绝，重新查询判断是用户名还是邮箱被占用
+func (s *AuthService) duplicateError(username, email string, err error) error {
+	if _, lookupErr := s.users.GetByUsername(username); lookupErr == nil {
+		return ErrUsernameTaken
+	}
+	if email != "" {
+		if _, lookupErr := s.users.GetByEmail(email); lookupErr == nil {
+			return ErrEmailTaken
+		}
+	}
+	return err
+}
+
+// validateRegistration 校验注册参数，返回规范化后的邮箱
+func (s *AuthService) validateRegistration(username, password, email string) (string, error) {
+	verr := &ValidationError{}
+	if msg := s.usernames.Validate(username); msg != "" {
+		verr.add("username", msg)
+	}
+	if password == "" {
+		verr.add("password", "password is required")
+	} else if err := ValidatePassword(username, password); err != nil {
+		verr.add("password", passwordFieldError(err))
+	}
+
+	var normalized string
+	if email != "" {
+		var err error
+		if normalized, err = NormalizeEmail(email); err != nil {
+			verr.add("email", err.Error())
+		}
+	}
+	return normalized, verr.orNil()
+}
+
+// Login - 校验用户名和密码，成功时返回用户模型（用于生成JWT）和DTO
+// 用户不存在和密码错误都返回ErrInvalidCredentials；
+// 同一IP或账号失败次数过多时返回*LoginBlockedError，此时不再校验密码
+func (s *AuthService) Login(username, password, clientIP string) (*model.User, *dto.UserDTO, error) {
+	// 1. 检查是否因为失败次数过多被限制
+	attempt, err := s.guard.Check(clientIP, username)
+	if err != nil {
+		return nil, nil, err
 	}
+	defer attempt.Release()
 
-	// 2. 验证密码
+	// 2. 根据用户名查找用户
+	// 相当于JPA的findByUsername方法
+	user, err := s.users.GetByUsername(username)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return nil, nil, err
+	}
+
+	// 3. 验证密码
 	// VerifyPassword方法类似于Spring Security的passwordEncoder.matches方法
-	if !VerifyPassword(user.Password, password) {
-		return nil, errors.New("invalid password")
+	// 用户不存在时同样计算一次bcrypt，避免通过响应时间判断用户名是否存在
+	if user == nil {
+		VerifyPassword(dummyPasswordHash(), password)
+	}
+	if user == nil || !VerifyPassword(user.Password, password) {
+		if err := attempt.Fail(); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, ErrInvalidCredentials
+	}
+	// 开启了两步验证时，等验证码也正确后再清除失败记录，
+	// 否则知道密码的攻击者可以反复登录来重置验证码的失败次数
+	if user.TOTPEnabledAt == nil {
+		attempt.Succeed()
 	}
 
-	// 3. 验证通过,返回用户信息
-	return ToUserDTO(&user), nil
+	// 4. 被封禁的账号不允许登录
+	if user.BannedAt != nil {
+		return nil, nil, ErrUserBanned
+	}
+
+	// 5. 验证通过,返回用户信息
+	// 开启了两步验证的用户（TOTPEnabledAt不为空）还需要由TwoFactorService完成第二步
+	return user, ToUserDTO(user), nil
 }
 
-// GetUserByUsername - 根据用户名查找用户（用于JWT生成）
-func GetUserByUsername(username string, user *model.User) error {
-	// First函数用于查询数据库并返回第一条匹配的记录
-	// 它会将查询结果填充到传入的user指针中
-	// 这里user是一个空的model.User指针，First会用查询到的数据填充它
-	// 如果找不到记录，会返回gorm.ErrRecordNotFound错误
-	return repository.DB.Where("username = ?", username).First(user).Error
+var (
+	dummyHashOnce sync.Once
+	dummyHash     string
+)
+
+// dummyPasswordHash 用于用户不存在时的bcrypt计算，第一次使用时生成
+func dummyPasswordHash() string {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = HashPassword("dummy-password-for-timing")
+	})
+	return dummyHash
 }
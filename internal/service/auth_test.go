@@ -0,0 +1,144 @@
+package service_test
+
+import (
+	"errors"
+	"my-social-platform/internal/app/apptest"
+	"my-social-platform/internal/config"
+	"my-social-platform/internal/model"
+	"my-social-platform/internal/repository"
+	"my-social-platform/internal/service"
+	"testing"
+)
+
+func TestRegisterValidatesAndRejectsDuplicates(t *testing.T) {
+	a := apptest.New(t)
+
+	var verr *service.ValidationError
+	_, err := a.AuthService.Register("ab", "password", "not-an-email")
+	if !errors.As(err, &verr) {
+		t.Fatalf("Register with invalid input: got %v, want *ValidationError", err)
+	}
+	for _, field := range []string{"username", "password", "email"} {
+		if verr.Fields[field] == "" {
+			t.Errorf("missing error for field %q: %v", field, verr)
+		}
+	}
+
+	user, err := a.AuthService.Register("alice", "Spring2025x", "Alice@Example.com")
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if user.Email != "alice@example.com" {
+		t.Errorf("email not normalized: %q", user.Email)
+	}
+	stored := a.User(t, "alice")
+	if stored.Password == "Spring2025x" || !service.VerifyPassword(stored.Password, "Spring2025x") {
+		t.Error("password is not stored as a bcrypt hash")
+	}
+
+	if _, err := a.AuthService.Register("alice", "Autumn2025y", ""); !errors.Is(err, service.ErrUsernameTaken) {
+		t.Errorf("duplicate username: got %v, want ErrUsernameTaken", err)
+	}
+	if _, err := a.AuthService.Register("bob", "Autumn2025y", "alice@example.com"); !errors.Is(err, service.ErrEmailTaken) {
+		t.Errorf("duplicate email: got %v, want ErrEmailTaken", err)
+	}
+}
+
+// racingUsers 模拟并发注册：注册前的检查查不到冲突，之后的查询和唯一约束照常
+type racingUsers struct {
+	repository.UserRepository
+	checked map[string]bool
+}
+
+func (r *racingUsers) GetByUsername(username string) (*model.User, error) {
+	if !r.checked["username"] {
+		r.checked["username"] = true
+		return nil, repository.ErrNotFound
+	}
+	return r.UserRepository.GetByUsername(username)
+}
+
+func (r *racingUsers) GetByEmail(email string) (*model.User, error) {
+	if !r.checked["email"] {
+		r.checked["email"] = true
+		return nil, repository.ErrNotFound
+	}
+	return r.UserRepository.GetByEmail(email)
+}
+
+func TestRegisterConcurrentDuplicates(t *testing.T) {
+	a := apptest.New(t)
+	if _, err := a.AuthService.Register("alice", "Spring2025x", "alice@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	register := func(username, email string) error {
+		auth := service.NewAuthService(&racingUsers{UserRepository: a.Users, checked: map[string]bool{}},
+			service.NewLoginGuard(a.Config.Auth), service.NewUsernamePolicy(a.Config.Auth))
+		_, err := auth.Register(username, "Autumn2025y", email)
+		return err
+	}
+
+	if err := register("bob", "alice@example.com"); !errors.Is(err, service.ErrEmailTaken) {
+		t.Errorf("email taken by a concurrent registration: got %v, want ErrEmailTaken", err)
+	}
+	if err := register("alice", "bob@example.com"); !errors.Is(err, service.ErrUsernameTaken) {
+		t.Errorf("username taken by a concurrent registration: got %v, want ErrUsernameTaken", err)
+	}
+}
+
+func TestLogin(t *testing.T) {
+	a := apptest.New(t)
+	a.CreateUser(t, "alice", "Spring2025x")
+
+	user, dto, err := a.AuthService.Login("alice", "Spring2025x", "192.0.2.1")
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if user.Username != "alice" || dto.Username != "alice" {
+		t.Errorf("Login returned %q / %q", user.Username, dto.Username)
+	}
+
+	// 用户不存在和密码错误返回同一个错误
+	if _, _, err := a.AuthService.Login("alice", "wrong-password1", "192.0.2.1"); !errors.Is(err, service.ErrInvalidCredentials) {
+		t.Errorf("wrong password: got %v, want ErrInvalidCredentials", err)
+	}
+	if _, _, err := a.AuthService.Login("nobody", "Spring2025x", "192.0.2.1"); !errors.Is(err, service.ErrInvalidCredentials) {
+		t.Errorf("unknown user: got %v, want ErrInvalidCredentials", err)
+	}
+}
+
+func TestLoginBannedUser(t *testing.T) {
+	a := apptest.New(t)
+	a.CreateUser(t, "alice", "Spring2025x")
+	if err := a.UserService.SetBanned("alice", true); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := a.AuthService.Login("alice", "Spring2025x", "192.0.2.1"); !errors.Is(err, service.ErrUserBanned) {
+		t.Errorf("banned user: got %v, want ErrUserBanned", err)
+	}
+	// 密码错误时不透露账号被封禁
+	if _, _, err := a.AuthService.Login("alice", "wrong-password1", "192.0.2.1"); !errors.Is(err, service.ErrInvalidCredentials) {
+		t.Errorf("banned user with wrong password: got %v, want ErrInvalidCredentials", err)
+	}
+}
+
+func TestLoginLocksAccountAfterRepeatedFailures(t *testing.T) {
+	a := apptest.New(t, func(cfg *config.Config) {
+		cfg.Auth.LoginMaxFailures = 2
+		cfg.Auth.LoginBackoff = 0
+	})
+	a.CreateUser(t, "alice", "Spring2025x")
+
+	if _, _, err := a.AuthService.Login("alice", "wrong-password1", "192.0.2.1"); !errors.Is(err, service.ErrInvalidCredentials) {
+		t.Fatalf("first failure: got %v", err)
+	}
+	var blocked *service.LoginBlockedError
+	if _, _, err := a.AuthService.Login("alice", "wrong-password2", "192.0.2.2"); !errors.As(err, &blocked) || !blocked.Locked || blocked.Scope != "account" {
+		t.Fatalf("second failure: got %v, want account lockout", err)
+	}
+	// 锁定期间正确的密码也被拒绝，不再校验密码
+	if _, _, err := a.AuthService.Login("alice", "Spring2025x", "192.0.2.3"); !errors.As(err, &blocked) {
+		t.Errorf("correct password while locked: got %v, want *LoginBlockedError", err)
+	}
+}
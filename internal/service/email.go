@@ -0,0 +1,29 @@
+package service
+
+import (
+	"errors"
+	"net/mail"
+	"strings"
+)
+
+// ErrInvalidEmail 邮箱格式不正确
+var ErrInvalidEmail = errors.New("invalid email address")
+
+// NormalizeEmail 校验邮箱格式，去掉首尾空白并转为小写
+// 只接受纯地址（user@example.com），不接受带显示名的形式
+func NormalizeEmail(email string) (string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email || len(email) > 255 {
+		return "", ErrInvalidEmail
+	}
+	return email, nil
+}
+
+// stringValue 返回指针指向的字符串，nil返回空字符串
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
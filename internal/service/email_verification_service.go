@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"math/big"
+	"my-social-platform/internal/model"
+	"my-social-platform/internal/pkg/mail"
+	"my-social-platform/internal/repository"
+	"strings"
+	"time"
+)
+
+const (
+	// verificationCodeDigits 验证码位数
+	verificationCodeDigits = 6
+	// maxVerificationAttempts 一个验证码最多允许输错的次数，超过后需要重新发送
+	maxVerificationAttempts = 5
+	// verificationResendInterval 两次发送验证码的最小间隔
+	verificationResendInterval = time.Minute
+	// maxVerificationsPerDay 同一用户24小时内最多发送的验证码数量，限制通过修改邮箱来绕过发送间隔
+	maxVerificationsPerDay = 10
+)
+
+var (
+	// ErrNoEmail 用户还没有填写邮箱
+	ErrNoEmail = errors.New("no email address on the account")
+	// ErrEmailAlreadyVerified 当前邮箱已经验证过
+	ErrEmailAlreadyVerified = errors.New("email address already verified")
+	// ErrVerificationTooFrequent 发送验证码过于频繁
+	ErrVerificationTooFrequent = errors.New("verification code requested too frequently")
+	// ErrInvalidVerificationCode 验证码错误、已过期、已使用或输错次数过多
+	ErrInvalidVerificationCode = errors.New("invalid or expired verification code")
+)
+
+// EmailVerificationService 通过邮件验证码确认用户拥有该邮箱
+// 验证的邮箱属于学校邮箱域名时，用户成为认证学生
+type EmailVerificationService struct {
+	users         repository.UserRepository
+	verifications repository.EmailVerificationRepository
+	mailer        mail.Mailer
+	ttl           time.Duration
+	domains       []string
+}
+
+// NewEmailVerificationService 创建EmailVerificationService
+// domains为学校邮箱域名列表，子域名同样有效（如 scut.edu.cn 包括 mail.scut.edu.cn）
+func NewEmailVerificationService(users repository.UserRepository, verifications repository.EmailVerificationRepository, mailer mail.Mailer, ttl time.Duration, domains []string) *EmailVerificationService {
+	normalized := make([]string, 0, len(domains))
+	for _, d := range domains {
+		normalized = append(normalized, strings.ToLower(strings.Trim(strings.TrimSpace(d), ".")))
+	}
+	return &EmailVerificationService{users: users, verifications: verifications, mailer: mailer, ttl: ttl, domains: normalized}
+}
+
+// IsStudentEmail 邮箱是否属于学校邮箱域名
+func (s *EmailVerificationService) IsStudentEmail(email string) bool {
+	at := strings.LastIndexByte(email, '@')
+	if at < 0 {
+		return false
+	}
+	domain := strings.ToLower(email[at+1:])
+	for _, d := range s.domains {
+		if domain == d || strings.HasSuffix(domain, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+// Send 向用户当前的邮箱发送验证码，之前发送的验证码作废
+// 同一邮箱每分钟最多一封，同一用户每天最多 maxVerificationsPerDay 封
+func (s *EmailVerificationService) Send(ctx context.Context, userID uint) error {
+	user, err := s.users.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	if user.Email == nil {
+		return ErrNoEmail
+	}
+	if user.EmailVerifiedAt != nil {
+		return ErrEmailAlreadyVerified
+	}
+
+	now := time.Now()
+	latest, err := s.verifications.GetLatest(userID)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return err
+	}
+	if latest != nil && latest.Email == *user.Email && now.Sub(latest.CreatedAt) < verificationResendInterval {
+		return ErrVerificationTooFrequent
+	}
+	today, err := s.verifications.CountSince(userID, now.Add(-24*time.Hour))
+	if err != nil {
+		return err
+	}
+	if today >= maxVerificationsPerDay {
+		return ErrVerificationTooFrequent
+	}
+
+	code, err := randomCode(verificationCodeDigits)
+	if err != nil {
+		return err
+	}
+	if err := s.verifications.InvalidateUser(userID, now); err != nil {
+		return err
+	}
+	if err := s.verifications.Create(&model.EmailVerification{
+		UserID:    userID,
+		Email:     *user.Email,
+		CodeHash:  hashCode(*user.Email, code),
+		ExpiresAt: now.Add(s.ttl),
+	}); err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf("%s，你好：\n\n"+
+		"你的邮箱验证码是：%s\n\n"+
+		"验证码 %d 分钟内有效。如果这不是你本人的操作，请忽略这封邮件。\n",
+		user.Username, code, int(s.ttl.Minutes()))
+	return s.mailer.Send(ctx, mail.Message{To: *user.Email, Subject: "邮箱验证码", Body: body})
+}
+
+// Verify 校验验证码，成功后标记邮箱已验证，邮箱属于学校域名时同时成为认证学生
+func (s *EmailVerificationService) Verify(userID uint, code string) (*model.User, error) {
+	user, err := s.users.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Email == nil {
+		return nil, ErrNoEmail
+	}
+	if user.EmailVerifiedAt != nil {
+		return nil, ErrEmailAlreadyVerified
+	}
+
+	v, err := s.verifications.GetLatest(userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidVerificationCode
+	}
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if v.UsedAt != nil || now.After(v.ExpiresAt) || v.Email != *user.Email {
+		return nil, ErrInvalidVerificationCode
+	}
+	// 比较验证码之前先用条件更新占用一次尝试次数：并发提交时也最多只有 maxVerificationAttempts 次比较
+	ok, err := s.verifications.IncrementAttempts(v.ID, maxVerificationAttempts)
+	if err != nil {
+		return nil, err
+	}
+	if !ok || subtle.ConstantTimeCompare([]byte(hashCode(v.Email, strings.TrimSpace(code))), []byte(v.CodeHash)) != 1 {
+		return nil, ErrInvalidVerificationCode
+	}
+
+	// 条件更新，同一个验证码并发使用时只有一个请求能成功
+	ok, err = s.verifications.MarkUsed(v.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrInvalidVerificationCode
+	}
+
+	user.EmailVerifiedAt = &now
+	user.StudentVerified = s.IsStudentEmail(*user.Email)
+	if err := s.users.Update(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// randomCode 生成指定位数的随机数字验证码
+func randomCode(digits int) (string, error) {
+	max := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(digits)), nil)
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", digits, n), nil
+}
+
+// hashCode 计算验证码的哈希，和邮箱绑定在一起
+func hashCode(email, code string) string {
+	return hashToken(email + ":" + code)
+}
@@ -0,0 +1,123 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"my-social-platform/internal/app/apptest"
+	"my-social-platform/internal/config"
+	"my-social-platform/internal/service"
+	"regexp"
+	"sync"
+	"testing"
+)
+
+// lastCode 最后一封邮件中的验证码
+func lastCode(t *testing.T, a *apptest.App) string {
+	t.Helper()
+	messages := a.Mailbox.Messages()
+	if len(messages) == 0 {
+		t.Fatal("no verification email sent")
+	}
+	code := regexp.MustCompile(`\d{6}`).FindString(messages[len(messages)-1].Body)
+	if code == "" {
+		t.Fatalf("no code in %q", messages[len(messages)-1].Body)
+	}
+	return code
+}
+
+// otherCode 与code不同的6位验证码
+func otherCode(code string) string {
+	if code == "000000" {
+		return "111111"
+	}
+	return "000000"
+}
+
+func TestEmailVerification(t *testing.T) {
+	a := apptest.New(t, func(cfg *config.Config) {
+		cfg.Auth.StudentEmailDomains = []string{"scut.edu.cn"}
+	})
+	user, err := a.AuthService.Register("alice", "Spring2025x", "alice@mail.scut.edu.cn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.VerifyService.Send(context.Background(), user.ID); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if err := a.VerifyService.Send(context.Background(), user.ID); !errors.Is(err, service.ErrVerificationTooFrequent) {
+		t.Errorf("second send within a minute: got %v", err)
+	}
+	code := lastCode(t, a)
+
+	if _, err := a.VerifyService.Verify(user.ID, otherCode(code)); !errors.Is(err, service.ErrInvalidVerificationCode) {
+		t.Errorf("wrong code: got %v", err)
+	}
+	verified, err := a.VerifyService.Verify(user.ID, code)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if verified.EmailVerifiedAt == nil || !verified.StudentVerified {
+		t.Errorf("verified user: %+v", verified)
+	}
+}
+
+func TestEmailVerificationConcurrentGuessesAreCapped(t *testing.T) {
+	a := apptest.New(t)
+	user, err := a.AuthService.Register("alice", "Spring2025x", "alice@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.VerifyService.Send(context.Background(), user.ID); err != nil {
+		t.Fatal(err)
+	}
+	code := lastCode(t, a)
+
+	// 并发提交大量错误的验证码，总的比较次数不能超过上限
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			a.VerifyService.Verify(user.ID, otherCode(code))
+		}()
+	}
+	wg.Wait()
+
+	if _, err := a.VerifyService.Verify(user.ID, code); !errors.Is(err, service.ErrInvalidVerificationCode) {
+		t.Errorf("correct code after the attempts were used up: got %v", err)
+	}
+	v, err := a.Verifications.GetLatest(user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Attempts != 5 {
+		t.Errorf("attempts = %d, want 5", v.Attempts)
+	}
+}
+
+func TestEmailVerificationDailyLimit(t *testing.T) {
+	a := apptest.New(t)
+	if _, err := a.AuthService.Register("alice", "Spring2025x", "alice0@example.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 每次修改邮箱后都可以立即发送，但每天的总数有限制
+	emails := []string{"alice0@example.com", "alice1@example.com"}
+	user := a.User(t, "alice")
+	for i := 0; i < 10; i++ {
+		user.Email = &emails[i%2]
+		if err := a.Users.Update(user); err != nil {
+			t.Fatal(err)
+		}
+		if err := a.VerifyService.Send(context.Background(), user.ID); err != nil {
+			t.Fatalf("send %d: %v", i+1, err)
+		}
+	}
+	user.Email = &emails[0]
+	if err := a.Users.Update(user); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.VerifyService.Send(context.Background(), user.ID); !errors.Is(err, service.ErrVerificationTooFrequent) {
+		t.Errorf("eleventh send: got %v, want ErrVerificationTooFrequent", err)
+	}
+}
@@ -0,0 +1,284 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"my-social-platform/internal/config"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxLockout 连续锁定时翻倍的上限
+	maxLockout = 24 * time.Hour
+	// guardSweepInterval 清理过期记录的间隔，避免大量随机用户名占用内存
+	guardSweepInterval = time.Minute
+	// pendingRetryAfter 因为还有尝试正在校验而被拒绝时，建议客户端等待的时间
+	pendingRetryAfter = time.Second
+)
+
+var (
+	// ErrInvalidCredentials 用户名或密码错误
+	// 用户不存在和密码错误返回同一个错误，避免通过登录接口探测哪些用户名已注册
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	// ErrUserBanned 账号已被封禁，只在密码正确时返回
+	ErrUserBanned = errors.New("user is banned")
+)
+
+// LoginBlockedError 登录失败次数过多，在RetryAfter之后才能再次尝试
+type LoginBlockedError struct {
+	RetryAfter time.Duration
+	// Scope 被限制的维度：account 或 ip
+	Scope string
+	// Locked 本次失败触发了锁定（而不是锁定期间的再次尝试）
+	Locked bool
+}
+
+func (e *LoginBlockedError) Error() string {
+	return fmt.Sprintf("too many failed login attempts (%s), retry after %s", e.Scope, e.RetryAfter.Round(time.Second))
+}
+
+// LoginGuard 按IP和账号统计登录失败次数，防止暴力破解
+//
+//   - 滑动窗口内失败次数超过上限的一半后，每次失败都要等待一段时间才能再次尝试，等待时间按指数增长
+//   - 达到上限后锁定一段时间，每次锁定的时长是上一次的两倍，最长24小时
+//   - 锁定期间不再校验密码，直接拒绝
+//   - 正在校验的尝试同样计数，超过上限的一半后同一时间只允许一次尝试，并发请求不能绕过退避和锁定
+//
+// 账号维度按用户名统计，不区分用户名是否存在，这样锁定与否不会泄露用户名是否注册
+// 失败记录保存在内存中，服务重启后清空；多个实例部署时各自统计（见 config.AuthConfig.LoginFailureWindow）
+type LoginGuard struct {
+	window     time.Duration
+	backoff    time.Duration
+	maxBackoff time.Duration
+	lockout    time.Duration
+	maxAccount int
+	maxIP      int
+
+	mu        sync.Mutex
+	entries   map[string]*guardEntry
+	lastSweep time.Time
+}
+
+// guardEntry 一个IP或账号的失败记录
+type guardEntry struct {
+	failures     []time.Time // 窗口内每次失败的时间
+	pending      int         // 已通过Check、还没有结算的尝试
+	blockedUntil time.Time
+	lockouts     int // 连续锁定的次数，决定下次锁定的时长
+	lastLockout  time.Time
+}
+
+// NewLoginGuard 创建LoginGuard
+func NewLoginGuard(cfg config.AuthConfig) *LoginGuard {
+	return &LoginGuard{
+		window:     cfg.LoginFailureWindow,
+		backoff:    cfg.LoginBackoff,
+		maxBackoff: cfg.LoginMaxBackoff,
+		lockout:    cfg.LoginLockout,
+		maxAccount: cfg.LoginMaxFailures,
+		maxIP:      cfg.LoginIPMaxFailures,
+		entries:    make(map[string]*guardEntry),
+	}
+}
+
+// LoginAttempt 一次已通过Check的尝试，校验完成后调用 Fail、Succeed 或 Release 之一结算
+// 重复结算不起作用，调用方可以先 defer Release 再按结果调用 Fail 或 Succeed
+type LoginAttempt struct {
+	guard    *LoginGuard
+	ip       string
+	username string
+	done     bool
+}
+
+// Check 检查是否允许这次登录尝试，不允许时返回*LoginBlockedError
+// 允许时在同一把锁内预留这次尝试，校验密码期间并发的请求也会计入
+func (g *LoginGuard) Check(ip, username string) (*LoginAttempt, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := time.Now()
+	g.sweep(now)
+
+	keys := g.keys(ip, username)
+	for _, k := range keys {
+		e := g.entries[k.key]
+		if e == nil {
+			continue
+		}
+		if now.Before(e.blockedUntil) {
+			return nil, &LoginBlockedError{RetryAfter: e.blockedUntil.Sub(now), Scope: k.scope}
+		}
+		// 正在校验的尝试都失败后会进入退避或锁定，等它们结算后再放行
+		if e.pending > 0 && len(prune(e.failures, now.Add(-g.window)))+e.pending > k.max/2 {
+			return nil, &LoginBlockedError{RetryAfter: pendingRetryAfter, Scope: k.scope}
+		}
+	}
+	for _, k := range keys {
+		g.entry(k.key).pending++
+	}
+	return &LoginAttempt{guard: g, ip: ip, username: username}, nil
+}
+
+// Record 检查并记录一次请求，不区分成功失败，用于限制找回密码等接口的请求次数
+// 被限制时返回*LoginBlockedError；达到上限的这次请求仍然允许，从下一次开始限制
+func (g *LoginGuard) Record(ip, key string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := time.Now()
+	g.sweep(now)
+
+	for _, k := range g.keys(ip, key) {
+		if e := g.entries[k.key]; e != nil && now.Before(e.blockedUntil) {
+			return &LoginBlockedError{RetryAfter: e.blockedUntil.Sub(now), Scope: k.scope}
+		}
+	}
+	g.fail(ip, key, now, false)
+	return nil
+}
+
+// Fail 记录一次失败；触发锁定时返回*LoginBlockedError，其中Locked为true
+func (a *LoginAttempt) Fail() error {
+	if a.done {
+		return nil
+	}
+	a.done = true
+	g := a.guard
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if blocked := g.fail(a.ip, a.username, time.Now(), true); blocked != nil {
+		return blocked
+	}
+	return nil
+}
+
+// Succeed 登录成功后清除该账号的失败记录
+// IP的记录保留，否则攻击者可以穿插登录自己的账号来重置IP计数
+func (a *LoginAttempt) Succeed() {
+	if a.done {
+		return
+	}
+	a.done = true
+	g := a.guard
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.release(a.ip, a.username)
+	if e := g.entries[accountKey(a.username)]; e != nil {
+		if e.pending == 0 {
+			delete(g.entries, accountKey(a.username))
+		} else {
+			*e = guardEntry{pending: e.pending}
+		}
+	}
+}
+
+// Release 结束尝试但不计入失败也不清除记录，用于校验过程出错、或密码正确但还需要两步验证的情况
+func (a *LoginAttempt) Release() {
+	if a.done {
+		return
+	}
+	a.done = true
+	g := a.guard
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.release(a.ip, a.username)
+}
+
+// fail 记录一次失败，reserved表示这次尝试之前通过Check预留过；调用方持有锁
+func (g *LoginGuard) fail(ip, username string, now time.Time, reserved bool) *LoginBlockedError {
+	var blocked *LoginBlockedError
+	for _, k := range g.keys(ip, username) {
+		e := g.entry(k.key)
+		if reserved && e.pending > 0 {
+			e.pending--
+		}
+		e.failures = append(prune(e.failures, now.Add(-g.window)), now)
+		n := len(e.failures)
+
+		var wait time.Duration
+		if n >= k.max {
+			// 一段时间没有再被锁定时，锁定时长重新从初始值开始
+			if now.Sub(e.lastLockout) > maxLockout {
+				e.lockouts = 0
+			}
+			wait = g.lockout << e.lockouts
+			if wait > maxLockout || wait <= 0 {
+				wait = maxLockout
+			}
+			e.lockouts++
+			e.lastLockout = now
+			e.failures = nil
+			if blocked == nil || wait > blocked.RetryAfter {
+				blocked = &LoginBlockedError{RetryAfter: wait, Scope: k.scope, Locked: true}
+			}
+		} else if free := k.max / 2; n > free {
+			wait = g.backoff << (n - free - 1)
+			if wait > g.maxBackoff || wait <= 0 {
+				wait = g.maxBackoff
+			}
+		}
+		if until := now.Add(wait); until.After(e.blockedUntil) {
+			e.blockedUntil = until
+		}
+	}
+	return blocked
+}
+
+// release 归还Check预留的尝试；调用方持有锁
+func (g *LoginGuard) release(ip, username string) {
+	for _, k := range g.keys(ip, username) {
+		if e := g.entries[k.key]; e != nil && e.pending > 0 {
+			e.pending--
+		}
+	}
+}
+
+// entry 返回key的记录，不存在时创建；调用方持有锁
+func (g *LoginGuard) entry(key string) *guardEntry {
+	e := g.entries[key]
+	if e == nil {
+		e = &guardEntry{}
+		g.entries[key] = e
+	}
+	return e
+}
+
+type guardKey struct {
+	key   string
+	scope string
+	max   int
+}
+
+func (g *LoginGuard) keys(ip, username string) []guardKey {
+	return []guardKey{
+		{key: "ip:" + ip, scope: "ip", max: g.maxIP},
+		{key: accountKey(username), scope: "account", max: g.maxAccount},
+	}
+}
+
+func accountKey(username string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(username))
+}
+
+// sweep 删除已经没有作用的记录，调用方持有锁
+func (g *LoginGuard) sweep(now time.Time) {
+	if now.Sub(g.lastSweep) < guardSweepInterval {
+		return
+	}
+	g.lastSweep = now
+	for k, e := range g.entries {
+		e.failures = prune(e.failures, now.Add(-g.window))
+		if len(e.failures) == 0 && e.pending == 0 && now.After(e.blockedUntil) && now.Sub(e.lastLockout) > maxLockout {
+			delete(g.entries, k)
+		}
+	}
+}
+
+// prune 去掉since之前的失败记录
+func prune(failures []time.Time, since time.Time) []time.Time {
+	i := 0
+	for i < len(failures) && failures[i].Before(since) {
+		i++
+	}
+	return failures[i:]
+}
@@ -0,0 +1,200 @@
+package service_test
+
+import (
+	"errors"
+	"my-social-platform/internal/config"
+	"my-social-platform/internal/service"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newGuard 账号上限为max、IP上限为ipMax的LoginGuard，退避时间可以由configure修改
+func newGuard(max, ipMax int, configure ...func(*config.AuthConfig)) *service.LoginGuard {
+	cfg := config.Default().Auth
+	cfg.LoginMaxFailures = max
+	cfg.LoginIPMaxFailures = ipMax
+	cfg.LoginBackoff = time.Nanosecond
+	cfg.LoginMaxBackoff = time.Nanosecond
+	cfg.LoginLockout = 15 * time.Minute
+	for _, f := range configure {
+		f(&cfg)
+	}
+	return service.NewLoginGuard(cfg)
+}
+
+// fail 通过Check后记录一次失败，返回Fail的结果
+func fail(t *testing.T, g *service.LoginGuard, ip, username string) error {
+	t.Helper()
+	attempt, err := g.Check(ip, username)
+	if err != nil {
+		t.Fatalf("Check(%s, %s): %v", ip, username, err)
+	}
+	return attempt.Fail()
+}
+
+func TestLoginGuardBackoff(t *testing.T) {
+	g := newGuard(4, 100, func(cfg *config.AuthConfig) {
+		cfg.LoginBackoff = time.Hour
+		cfg.LoginMaxBackoff = 4 * time.Hour
+	})
+
+	// 上限的一半以内不需要等待
+	for i := 0; i < 2; i++ {
+		if err := fail(t, g, "192.0.2.1", "alice"); err != nil {
+			t.Fatalf("failure %d: %v", i+1, err)
+		}
+	}
+	if err := fail(t, g, "192.0.2.1", "alice"); err != nil {
+		t.Fatalf("third failure: %v", err)
+	}
+
+	var blocked *service.LoginBlockedError
+	if _, err := g.Check("192.0.2.2", "Alice"); !errors.As(err, &blocked) {
+		t.Fatalf("Check during backoff: got %v, want *LoginBlockedError", err)
+	}
+	if blocked.Locked || blocked.Scope != "account" || blocked.RetryAfter <= 59*time.Minute || blocked.RetryAfter > time.Hour {
+		t.Errorf("backoff: %+v, want account blocked for about an hour", blocked)
+	}
+	if _, err := g.Check("192.0.2.1", "bob"); err != nil {
+		t.Errorf("another account from the same IP: %v", err)
+	}
+}
+
+func TestLoginGuardLockout(t *testing.T) {
+	g := newGuard(3, 100)
+
+	for i := 0; i < 2; i++ {
+		if err := fail(t, g, "192.0.2.1", "alice"); err != nil {
+			t.Fatalf("failure %d: %v", i+1, err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	var blocked *service.LoginBlockedError
+	if err := fail(t, g, "192.0.2.1", "alice"); !errors.As(err, &blocked) || !blocked.Locked || blocked.Scope != "account" || blocked.RetryAfter != 15*time.Minute {
+		t.Fatalf("third failure: got %v, want account locked for 15m", err)
+	}
+
+	// 锁定期间换IP也被拒绝，Retry-After是剩余的锁定时间
+	if _, err := g.Check("192.0.2.2", "alice"); !errors.As(err, &blocked) || blocked.Locked || blocked.RetryAfter > 15*time.Minute || blocked.RetryAfter < 14*time.Minute {
+		t.Errorf("Check while locked: got %v", err)
+	}
+}
+
+func TestLoginGuardIPLimit(t *testing.T) {
+	g := newGuard(100, 2)
+
+	if err := fail(t, g, "192.0.2.1", "alice"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond)
+	var blocked *service.LoginBlockedError
+	if err := fail(t, g, "192.0.2.1", "bob"); !errors.As(err, &blocked) || !blocked.Locked || blocked.Scope != "ip" {
+		t.Fatalf("second failure from the IP: got %v, want ip lockout", err)
+	}
+	if _, err := g.Check("192.0.2.1", "carol"); !errors.As(err, &blocked) || blocked.Scope != "ip" {
+		t.Errorf("another account from a locked IP: got %v", err)
+	}
+	if _, err := g.Check("192.0.2.2", "alice"); err != nil {
+		t.Errorf("same account from another IP: %v", err)
+	}
+}
+
+func TestLoginGuardSucceedResetsAccount(t *testing.T) {
+	g := newGuard(3, 4)
+
+	for i := 0; i < 2; i++ {
+		if err := fail(t, g, "192.0.2.1", "alice"); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	attempt, err := g.Check("192.0.2.1", "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	attempt.Succeed()
+
+	// 账号的失败次数清零，再失败一次不会锁定
+	if err := fail(t, g, "192.0.2.1", "alice"); err != nil {
+		t.Fatalf("failure after success: %v", err)
+	}
+	time.Sleep(time.Millisecond)
+	// IP的失败次数保留，第四次失败锁定IP
+	var blocked *service.LoginBlockedError
+	if err := fail(t, g, "192.0.2.1", "alice"); !errors.As(err, &blocked) || blocked.Scope != "ip" {
+		t.Errorf("fourth failure from the IP: got %v, want ip lockout", err)
+	}
+}
+
+func TestLoginGuardConcurrentAttempts(t *testing.T) {
+	g := newGuard(5, 100)
+
+	// 正在校验的尝试同样计数：没有结算之前最多放行上限的一半加一次
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		attempts []*service.LoginAttempt
+	)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if attempt, err := g.Check("192.0.2.1", "alice"); err == nil {
+				mu.Lock()
+				attempts = append(attempts, attempt)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if len(attempts) != 3 {
+		t.Fatalf("%d concurrent attempts allowed, want 3", len(attempts))
+	}
+	var blocked *service.LoginBlockedError
+	if _, err := g.Check("192.0.2.1", "alice"); !errors.As(err, &blocked) || blocked.RetryAfter <= 0 {
+		t.Errorf("Check with attempts in flight: got %v, want *LoginBlockedError", err)
+	}
+
+	// 放弃的尝试归还名额，重复结算不起作用
+	attempts[0].Release()
+	attempts[0].Release()
+	attempts[0].Fail()
+	next, err := g.Check("192.0.2.1", "alice")
+	if err != nil {
+		t.Fatalf("Check after Release: %v", err)
+	}
+	if _, err := g.Check("192.0.2.1", "alice"); err == nil {
+		t.Error("Release was counted twice")
+	}
+
+	// 结算后的失败照常计数，第五次失败锁定
+	for _, attempt := range append(attempts[1:], next) {
+		if err := attempt.Fail(); err != nil {
+			t.Fatalf("Fail: %v", err)
+		}
+	}
+	time.Sleep(time.Millisecond)
+	if err := fail(t, g, "192.0.2.1", "alice"); err != nil {
+		t.Fatalf("fourth failure: %v", err)
+	}
+	time.Sleep(time.Millisecond)
+	if err := fail(t, g, "192.0.2.1", "alice"); !errors.As(err, &blocked) || !blocked.Locked {
+		t.Errorf("fifth failure: got %v, want lockout", err)
+	}
+}
+
+func TestLoginGuardRecord(t *testing.T) {
+	g := newGuard(2, 100)
+
+	// 达到上限的这次请求仍然允许，从下一次开始限制
+	for i := 0; i < 2; i++ {
+		if err := g.Record("192.0.2.1", "alice@example.com"); err != nil {
+			t.Fatalf("request %d: %v", i+1, err)
+		}
+	}
+	var blocked *service.LoginBlockedError
+	if err := g.Record("192.0.2.2", "alice@example.com"); !errors.As(err, &blocked) || blocked.Scope != "account" {
+		t.Errorf("third request: got %v, want *LoginBlockedError", err)
+	}
+}
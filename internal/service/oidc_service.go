@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"my-social-platform/internal/config"
+	"my-social-platform/internal/model"
+	"my-social-platform/internal/pkg/oidc"
+	"my-social-platform/internal/repository"
+	"strings"
+	"time"
+)
+
+// ErrNoLinkedAccount 统一身份认证登录成功，但没有关联的用户且没有开启自动创建
+var ErrNoLinkedAccount = errors.New("no account is linked to this identity")
+
+// OIDCFlow 一次统一身份认证登录的临时参数，跳转到身份提供方前生成，回调时校验
+// 由handler保存在短期的HttpOnly Cookie中，服务端不需要保存状态
+type OIDCFlow struct {
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"` // PKCE的code_verifier
+}
+
+// OIDCService 学校统一身份认证（OpenID Connect）登录
+// 身份提供方只负责确认"这个人是谁"，登录成功后签发的仍然是本平台自己的令牌
+type OIDCService struct {
+	client     *oidc.Client
+	users      repository.UserRepository
+	identities repository.UserIdentityRepository
+	usernames  *UsernamePolicy
+	verify     *EmailVerificationService
+	cfg        config.OIDCConfig
+}
+
+// NewOIDCService 创建OIDCService
+// verify 用于判断身份提供方确认过的邮箱是否属于学校邮箱域名
+func NewOIDCService(client *oidc.Client, users repository.UserRepository, identities repository.UserIdentityRepository, usernames *UsernamePolicy, verify *EmailVerificationService, cfg config.OIDCConfig) *OIDCService {
+	return &OIDCService{client: client, users: users, identities: identities, usernames: usernames, verify: verify, cfg: cfg}
+}
+
+// Begin 开始登录：生成state、nonce和PKCE参数，返回身份提供方的登录地址
+func (s *OIDCService) Begin(ctx context.Context) (string, *OIDCFlow, error) {
+	flow := &OIDCFlow{}
+	for _, field := range []*string{&flow.State, &flow.Nonce, &flow.Verifier} {
+		v, err := oidc.RandomString(32)
+		if err != nil {
+			return "", nil, err
+		}
+		*field = v
+	}
+	authURL, err := s.client.AuthCodeURL(ctx, flow.State, flow.Nonce, oidc.S256Challenge(flow.Verifier))
+	if err != nil {
+		return "", nil, err
+	}
+	return authURL, flow, nil
+}
+
+// Complete 回调：用授权码换取并验证ID令牌，再找到或创建对应的用户
+//
+//  1. (iss, sub) 已关联用户时直接使用该用户
+//  2. 开启 link_by_email 且身份提供方确认过邮箱（email_verified）时，关联到邮箱相同且已在本平台验证过邮箱的用户
+//  3. 开启 auto_provision 时创建新用户，用户名取 preferred_username 或邮箱前缀，冲突时加数字后缀
+//
+// 返回的bool表示是否新建了用户；被封禁的用户返回ErrUserBanned
+func (s *OIDCService) Complete(ctx context.Context, flow *OIDCFlow, code string) (*model.User, bool, error) {
+	claims, err := s.client.Exchange(ctx, code, flow.Verifier, flow.Nonce)
+	if err != nil {
+		return nil, false, err
+	}
+
+	user, created, err := s.resolve(claims)
+	if err != nil {
+		return nil, false, err
+	}
+	if user.BannedAt != nil {
+		return nil, false, ErrUserBanned
+	}
+	return user, created, nil
+}
+
+// resolve 按上面的顺序找到或创建用户
+func (s *OIDCService) resolve(claims *oidc.Claims) (*model.User, bool, error) {
+	issuer := s.client.Issuer()
+	identity, err := s.identities.Get(issuer, claims.Subject)
+	if err == nil {
+		user, err := s.users.GetByID(identity.UserID)
+		return user, false, err
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return nil, false, err
+	}
+
+	// 只信任身份提供方确认过的邮箱，否则任何人都可以在身份提供方填写别人的邮箱来接管账号
+	var email string
+	if claims.EmailVerified && claims.Email != "" {
+		if email, err = NormalizeEmail(claims.Email); err != nil {
+			email = ""
+		}
+	}
+
+	var user *model.User
+	created := false
+	if s.cfg.LinkByEmail && email != "" {
+		user, err = s.users.GetByEmail(email)
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return nil, false, err
+		}
+		// 本地用户没有验证过邮箱时不关联：否则攻击者可以先用别人的学校邮箱注册（不验证），
+		// 等对方第一次统一身份认证登录后，用自己设置的密码登录同一个账号
+		if user != nil && user.EmailVerifiedAt == nil {
+			user = nil
+		}
+	}
+	if user == nil {
+		if !s.cfg.AutoProvision {
+			return nil, false, ErrNoLinkedAccount
+		}
+		if user, err = s.provision(claims, email); err != nil {
+			return nil, false, err
+		}
+		created = true
+	}
+
+	if err := s.identities.Create(&model.UserIdentity{
+		UserID:  user.ID,
+		Issuer:  issuer,
+		Subject: claims.Subject,
+		Email:   claims.Email,
+	}); err != nil {
+		// 同一个身份并发登录，另一个请求已经关联
+		if errors.Is(err, repository.ErrDuplicate) && !created {
+			return s.resolve(claims)
+		}
+		return nil, false, err
+	}
+	return user, created, nil
+}
+
+// provision 为第一次登录的身份创建用户
+// 密码设置为随机值（相当于没有密码），用户需要密码登录时可以通过找回密码设置
+func (s *OIDCService) provision(claims *oidc.Claims, email string) (*model.User, error) {
+	random, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	hashedPassword, err := HashPassword(random)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &model.User{Password: hashedPassword, Nickname: claims.Name}
+	if email != "" {
+		// 身份提供方确认过的邮箱视为已验证，学校邮箱同时成为认证学生
+		now := time.Now()
+		user.Email = &email
+		user.EmailVerifiedAt = &now
+		user.StudentVerified = s.verify.IsStudentEmail(email)
+		// 邮箱已被其他用户使用（没有开启link_by_email时），新用户不填写邮箱
+		if _, err := s.users.GetByEmail(email); err == nil {
+			user.Email, user.EmailVerifiedAt, user.StudentVerified = nil, nil, false
+		} else if !errors.Is(err, repository.ErrNotFound) {
+			return nil, err
+		}
+	}
+
+	base := s.usernameBase(claims, email)
+	for i := 0; i < 20; i++ {
+		user.Username = s.withSuffix(base, i)
+		if s.usernames.Validate(user.Username) != "" {
+			continue
+		}
+		err := s.users.Create(user)
+		if err == nil {
+			return user, nil
+		}
+		if !errors.Is(err, repository.ErrDuplicate) {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("could not find a free username for %q", base)
+}
+
+// usernameBase 从preferred_username或邮箱前缀生成候选用户名，只保留字母、数字和下划线
+func (s *OIDCService) usernameBase(claims *oidc.Claims, email string) string {
+	for _, candidate := range []string{claims.PreferredUsername, strings.Split(email, "@")[0]} {
+		var b strings.Builder
+		for _, r := range candidate {
+			switch {
+			case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+				b.WriteRune(r)
+			case r == '.' || r == '-':
+				b.WriteRune('_')
+			}
+		}
+		if name := b.String(); len(name) >= s.usernames.min {
+			if len(name) > s.usernames.max {
+				name = name[:s.usernames.max]
+			}
+			return name
+		}
+	}
+	return "user"
+}
+
+// withSuffix 第i次尝试的用户名：第一次使用原名，之后依次加 _2、_3……，超出长度时截断原名
+// 多次冲突后改用随机后缀，避免大量同名用户时逐个尝试
+func (s *OIDCService) withSuffix(base string, i int) string {
+	if i == 0 {
+		return base
+	}
+	suffix := fmt.Sprintf("_%d", i+1)
+	if i >= 5 {
+		n, err := randomCode(6)
+		if err != nil {
+			n = fmt.Sprint(time.Now().UnixNano() % 1000000)
+		}
+		suffix = "_" + n
+	}
+	if len(base)+len(suffix) > s.usernames.max {
+		base = base[:max(s.usernames.max-len(suffix), 0)]
+	}
+	return base + suffix
+}
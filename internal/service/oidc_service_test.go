@@ -0,0 +1,213 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"my-social-platform/internal/app/apptest"
+	"my-social-platform/internal/config"
+	"my-social-platform/internal/model"
+	"my-social-platform/internal/pkg/oidc/oidctest"
+	"my-social-platform/internal/service"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// newOIDCApp 开启统一身份认证，身份提供方是进程内的oidctest
+func newOIDCApp(t *testing.T, configure ...func(*config.Config)) (*apptest.App, *oidctest.Provider) {
+	t.Helper()
+	p, srv := oidctest.NewServer()
+	t.Cleanup(srv.Close)
+	p.ClientID, p.ClientSecret = "social", "secret"
+	a := apptest.New(t, append([]func(*config.Config){func(cfg *config.Config) {
+		cfg.OIDC.Enabled = true
+		cfg.OIDC.Issuer = srv.URL
+		cfg.OIDC.ClientID = "social"
+		cfg.OIDC.ClientSecret = "secret"
+	}}, configure...)...)
+	return a, p
+}
+
+// oidcLogin 以身份提供方当前的Identity走完一次登录：Begin → 授权页自动登录 → Complete
+func oidcLogin(t *testing.T, a *apptest.App, p *oidctest.Provider, identity oidctest.Identity) (*model.User, bool, error) {
+	t.Helper()
+	p.Identity = &identity
+	authURL, flow, err := a.OIDC.Begin(context.Background())
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	loc, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil || loc.Query().Get("state") != flow.State {
+		t.Fatalf("authorize redirected to %q", resp.Header.Get("Location"))
+	}
+	return a.OIDC.Complete(context.Background(), flow, loc.Query().Get("code"))
+}
+
+// verifyEmail 把用户的邮箱标记为已验证
+func verifyEmail(t *testing.T, a *apptest.App, username string) {
+	t.Helper()
+	user := a.User(t, username)
+	now := time.Now()
+	user.EmailVerifiedAt = &now
+	if err := a.Users.Update(user); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOIDCProvisionsAndLinksBySubject(t *testing.T) {
+	a, p := newOIDCApp(t)
+
+	user, created, err := oidcLogin(t, a, p, oidctest.Identity{
+		Subject: "student-001", Email: "zhangsan@mail.scut.edu.cn", EmailVerified: true, PreferredUsername: "zhangsan", Name: "张三",
+	})
+	if err != nil {
+		t.Fatalf("first login: %v", err)
+	}
+	if !created || user.Username != "zhangsan" || user.Nickname != "张三" {
+		t.Fatalf("first login: created %v, user %+v", created, user)
+	}
+	if user.Email == nil || *user.Email != "zhangsan@mail.scut.edu.cn" || user.EmailVerifiedAt == nil {
+		t.Errorf("provisioned email: %v, verified %v", user.Email, user.EmailVerifiedAt)
+	}
+
+	// 之后按 (iss, sub) 找到同一个用户，身份提供方上的邮箱和用户名变化不影响
+	again, created, err := oidcLogin(t, a, p, oidctest.Identity{Subject: "student-001", Email: "new@mail.scut.edu.cn", EmailVerified: true, PreferredUsername: "renamed"})
+	if err != nil {
+		t.Fatalf("second login: %v", err)
+	}
+	if created || again.ID != user.ID {
+		t.Errorf("second login: created %v, user %d, want %d", created, again.ID, user.ID)
+	}
+}
+
+func TestOIDCLinksByVerifiedEmail(t *testing.T) {
+	a, p := newOIDCApp(t, func(cfg *config.Config) { cfg.OIDC.LinkByEmail = true })
+	if _, err := a.AuthService.Register("alice", "Spring2025x", "alice@mail.scut.edu.cn"); err != nil {
+		t.Fatal(err)
+	}
+	verifyEmail(t, a, "alice")
+
+	// 身份提供方没有确认邮箱：不关联
+	user, created, err := oidcLogin(t, a, p, oidctest.Identity{Subject: "sub-unverified", Email: "alice@mail.scut.edu.cn", PreferredUsername: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !created || user.Username == "alice" || user.Email != nil {
+		t.Errorf("unverified IdP email: created %v, user %q, email %v", created, user.Username, user.Email)
+	}
+
+	user, created, err = oidcLogin(t, a, p, oidctest.Identity{Subject: "sub-alice", Email: "Alice@mail.scut.edu.cn", EmailVerified: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created || user.Username != "alice" {
+		t.Errorf("verified email: created %v, user %q, want alice", created, user.Username)
+	}
+}
+
+func TestOIDCDoesNotLinkUnverifiedLocalEmail(t *testing.T) {
+	a, p := newOIDCApp(t, func(cfg *config.Config) { cfg.OIDC.LinkByEmail = true })
+	// 攻击者先用别人的学校邮箱注册，但没有验证邮箱
+	if _, err := a.AuthService.Register("mallory", "Spring2025x", "bob@mail.scut.edu.cn"); err != nil {
+		t.Fatal(err)
+	}
+
+	user, created, err := oidcLogin(t, a, p, oidctest.Identity{Subject: "sub-bob", Email: "bob@mail.scut.edu.cn", EmailVerified: true, PreferredUsername: "bob"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !created || user.Username != "bob" {
+		t.Fatalf("created %v, user %q: SSO identity was linked to an unverified account", created, user.Username)
+	}
+	// 邮箱已被占用，新用户不填写邮箱
+	if user.Email != nil {
+		t.Errorf("new user got email %q", *user.Email)
+	}
+}
+
+func TestOIDCProvisionResolvesUsernameCollisions(t *testing.T) {
+	a, p := newOIDCApp(t)
+	a.CreateUser(t, "student001", "Spring2025x")
+
+	want := []string{"student001_2", "student001_3"}
+	for i, sub := range []string{"sub-1", "sub-2"} {
+		user, created, err := oidcLogin(t, a, p, oidctest.Identity{Subject: sub, PreferredUsername: "student001"})
+		if err != nil {
+			t.Fatalf("login %s: %v", sub, err)
+		}
+		if !created || user.Username != want[i] {
+			t.Errorf("login %s: created %v, username %q, want %q", sub, created, user.Username, want[i])
+		}
+	}
+
+	// 没有preferred_username时使用邮箱前缀，不允许的字符替换为下划线
+	user, _, err := oidcLogin(t, a, p, oidctest.Identity{Subject: "sub-3", Email: "zhang.san@mail.scut.edu.cn", EmailVerified: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Username != "zhang_san" {
+		t.Errorf("username from email: got %q, want zhang_san", user.Username)
+	}
+}
+
+func TestOIDCWithoutAutoProvision(t *testing.T) {
+	a, p := newOIDCApp(t, func(cfg *config.Config) { cfg.OIDC.AutoProvision = false })
+
+	if _, _, err := oidcLogin(t, a, p, oidctest.Identity{Subject: "sub-1", PreferredUsername: "newcomer"}); !errors.Is(err, service.ErrNoLinkedAccount) {
+		t.Errorf("got %v, want ErrNoLinkedAccount", err)
+	}
+	if _, err := a.Users.GetByUsername("newcomer"); err == nil {
+		t.Error("user was created although auto_provision is off")
+	}
+}
+
+func TestOIDCBannedUser(t *testing.T) {
+	a, p := newOIDCApp(t)
+	identity := oidctest.Identity{Subject: "sub-1", PreferredUsername: "zhangsan"}
+	if _, _, err := oidcLogin(t, a, p, identity); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.UserService.SetBanned("zhangsan", true); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := oidcLogin(t, a, p, identity); !errors.Is(err, service.ErrUserBanned) {
+		t.Errorf("banned user: got %v, want ErrUserBanned", err)
+	}
+}
+
+func TestOIDCRejectsCodeFromAnotherFlow(t *testing.T) {
+	a, p := newOIDCApp(t)
+	p.Identity = &oidctest.Identity{Subject: "sub-1", PreferredUsername: "zhangsan"}
+
+	// 攻击者的授权码被注入到受害者的回调中：nonce和code_verifier都属于另一次登录
+	authURL, _, err := a.OIDC.Begin(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	loc, _ := url.Parse(resp.Header.Get("Location"))
+
+	_, victim, err := a.OIDC.Begin(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := a.OIDC.Complete(context.Background(), victim, loc.Query().Get("code")); err == nil {
+		t.Error("code issued for another flow was accepted")
+	}
+	if _, err := a.Users.GetByUsername("zhangsan"); err == nil {
+		t.Error("user was created from a rejected login")
+	}
+}
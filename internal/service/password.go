@@ -0,0 +1,76 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 密码策略
+const (
+	// MinPasswordLength 最少字符数，按Unicode字符计算（一个汉字算一个字符）
+	MinPasswordLength = 8
+	// MaxPasswordLength 最多字节数：bcrypt只使用前72个字节，更长的部分会被忽略
+	MaxPasswordLength = 72
+)
+
+var (
+	// ErrPasswordPolicy 新密码不符合密码策略，具体原因包含在错误信息中
+	ErrPasswordPolicy = errors.New("password does not meet the policy")
+	// ErrWrongPassword 当前密码不正确
+	ErrWrongPassword = errors.New("current password is incorrect")
+)
+
+// ValidatePassword 检查密码是否符合密码策略：
+//   - 至少8个字符、最多72个字节（UTF-8编码），至少包含一个字母和一个数字
+//   - 不能是常见弱密码
+//   - 不能包含用户名（正序或倒序，不区分大小写）
+func ValidatePassword(username, password string) error {
+	if utf8.RuneCountInString(password) < MinPasswordLength {
+		return fmt.Errorf("%w: must be at least %d characters", ErrPasswordPolicy, MinPasswordLength)
+	}
+	if len(password) > MaxPasswordLength {
+		return fmt.Errorf("%w: must be at most %d bytes", ErrPasswordPolicy, MaxPasswordLength)
+	}
+
+	var letter, digit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLetter(r):
+			letter = true
+		case unicode.IsDigit(r):
+			digit = true
+		}
+	}
+	if !letter || !digit {
+		return fmt.Errorf("%w: must contain at least one letter and one digit", ErrPasswordPolicy)
+	}
+
+	lower := strings.ToLower(password)
+	if commonPasswords[lower] {
+		return fmt.Errorf("%w: is too common", ErrPasswordPolicy)
+	}
+	// 用户名太短时（如"ab"）包含关系没有意义
+	if name := strings.ToLower(username); utf8.RuneCountInString(name) >= 3 {
+		if strings.Contains(lower, name) || strings.Contains(lower, reverse(name)) {
+			return fmt.Errorf("%w: must not contain the username", ErrPasswordPolicy)
+		}
+	}
+	return nil
+}
+
+// reverse 倒序字符串
+func reverse(s string) string {
+	r := []rune(s)
+	for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+		r[i], r[j] = r[j], r[i]
+	}
+	return string(r)
+}
+
+// passwordFieldError 去掉ErrPasswordPolicy前缀，用作字段错误描述
+func passwordFieldError(err error) string {
+	return strings.TrimPrefix(err.Error(), ErrPasswordPolicy.Error()+": ")
+}
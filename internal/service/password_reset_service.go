@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"my-social-platform/internal/model"
+	"my-social-platform/internal/pkg/mail"
+	"my-social-platform/internal/repository"
+	"net/url"
+	"time"
+)
+
+const (
+	// passwordResetResendInterval 同一用户两封重置邮件的最小间隔
+	passwordResetResendInterval = time.Minute
+	// maxPasswordResetsPerDay 同一用户24小时内最多发送的重置邮件数量
+	maxPasswordResetsPerDay = 5
+)
+
+var (
+	// ErrInvalidResetToken 找回密码令牌不存在、已过期或已使用
+	ErrInvalidResetToken = errors.New("invalid or expired reset token")
+	// ErrPasswordResetTooFrequent 该用户最近已经发送过重置邮件，这次不再发送
+	// 只写入日志，接口的响应与发送成功时相同
+	ErrPasswordResetTooFrequent = errors.New("password reset email sent too frequently")
+)
+
+// ResetLinkFunc 根据原始令牌生成邮件中的重置链接
+type ResetLinkFunc func(token string) string
+
+// PasswordResetService 忘记密码后通过邮件链接重置密码
+type PasswordResetService struct {
+	users  repository.UserRepository
+	resets repository.PasswordResetRepository
+	tokens *TokenService
+	mailer mail.Mailer
+	link   ResetLinkFunc
+	ttl    time.Duration
+	guard  *LoginGuard
+}
+
+// NewPasswordResetService 创建PasswordResetService
+// guard 限制同一IP和同一邮箱的请求次数，应该是单独的实例，不与登录共用失败次数
+func NewPasswordResetService(users repository.UserRepository, resets repository.PasswordResetRepository, tokens *TokenService, mailer mail.Mailer, link ResetLinkFunc, ttl time.Duration, guard *LoginGuard) *PasswordResetService {
+	return &PasswordResetService{users: users, resets: resets, tokens: tokens, mailer: mailer, link: link, ttl: ttl, guard: guard}
+}
+
+// Allow 检查并记录一次找回密码请求，请求过多时返回*LoginBlockedError
+// 每次请求都计数，与邮箱是否注册无关，防止用这个接口给别人的邮箱发送大量邮件
+func (s *PasswordResetService) Allow(clientIP, email string) error {
+	return s.guard.Record(clientIP, email)
+}
+
+// Request 为邮箱对应的用户生成重置令牌并发送邮件
+// 邮箱不存在或账号被封禁时什么也不做、也不返回错误，避免通过这个接口探测哪些邮箱已注册
+// 同一用户每分钟最多一封、每天最多 maxPasswordResetsPerDay 封，多个实例部署时同样有效
+// 发送邮件比查询数据库慢得多，handler在后台调用，响应时间不会泄露邮箱是否已注册
+func (s *PasswordResetService) Request(ctx context.Context, email string) error {
+	email, err := NormalizeEmail(email)
+	if err != nil {
+		return nil
+	}
+	user, err := s.users.GetByEmail(email)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if user.BannedAt != nil {
+		return nil
+	}
+
+	now := time.Now()
+	recent, err := s.resets.CountSince(user.ID, now.Add(-passwordResetResendInterval))
+	if err != nil {
+		return err
+	}
+	today, err := s.resets.CountSince(user.ID, now.Add(-24*time.Hour))
+	if err != nil {
+		return err
+	}
+	if recent > 0 || today >= maxPasswordResetsPerDay {
+		return ErrPasswordResetTooFrequent
+	}
+
+	raw, err := randomToken(32)
+	if err != nil {
+		return err
+	}
+	if err := s.resets.Create(&model.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: hashToken(raw),
+		ExpiresAt: now.Add(s.ttl),
+	}); err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf("%s，你好：\n\n"+
+		"我们收到了重置你的账号密码的请求。请在 %d 分钟内打开下面的链接设置新密码：\n\n%s\n\n"+
+		"如果这不是你本人的操作，请忽略这封邮件，你的密码不会被修改。\n",
+		user.Username, int(s.ttl.Minutes()), s.link(raw))
+	return s.mailer.Send(ctx, mail.Message{To: email, Subject: "重置密码", Body: body})
+}
+
+// Reset 使用邮件中的令牌设置新密码
+// 令牌只能使用一次；成功后该用户其他未使用的重置令牌和所有登录会话都会失效
+func (s *PasswordResetService) Reset(raw, newPassword string) (*model.User, error) {
+	token, err := s.resets.GetByHash(hashToken(raw))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidResetToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if token.UsedAt != nil || now.After(token.ExpiresAt) {
+		return nil, ErrInvalidResetToken
+	}
+	user, err := s.users.GetByID(token.UserID)
+	if err != nil {
+		return nil, err
+	}
+	// 先校验密码再标记令牌已使用，密码不符合要求时用户可以用同一个链接重试
+	if err := ValidatePassword(user.Username, newPassword); err != nil {
+		return nil, err
+	}
+
+	// 条件更新，同一个令牌并发使用时只有一个请求能成功
+	ok, err := s.resets.MarkUsed(token.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrInvalidResetToken
+	}
+	hashedPassword, err := HashPassword(newPassword)
+	if err != nil {
+		return nil, err
+	}
+	user.Password = hashedPassword
+	if err := s.users.Update(user); err != nil {
+		return nil, err
+	}
+
+	if err := s.resets.InvalidateUser(user.ID, now); err != nil {
+		return nil, err
+	}
+	return user, s.tokens.LogoutAll(user.ID)
+}
+
+// ResetLink 生成指向前端重置密码页面的链接
+func ResetLink(frontendLink func(path string) string) ResetLinkFunc {
+	return func(token string) string {
+		return frontendLink("/reset-password?token=" + url.QueryEscape(token))
+	}
+}
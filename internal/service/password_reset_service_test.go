@@ -0,0 +1,108 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"my-social-platform/internal/app/apptest"
+	"my-social-platform/internal/config"
+	"my-social-platform/internal/service"
+	"net/url"
+	"regexp"
+	"testing"
+)
+
+// resetToken 从重置邮件的链接中取出令牌
+func resetToken(t *testing.T, body string) string {
+	t.Helper()
+	link := regexp.MustCompile(`http\S+reset-password\?token=\S+`).FindString(body)
+	u, err := url.Parse(link)
+	if err != nil || u.Query().Get("token") == "" {
+		t.Fatalf("no reset link in %q", body)
+	}
+	return u.Query().Get("token")
+}
+
+func TestPasswordReset(t *testing.T) {
+	a := apptest.New(t)
+	if _, err := a.AuthService.Register("alice", "Spring2025x", "alice@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	alice := a.User(t, "alice")
+	pair, err := a.TokenService.Issue(alice, apptest.Client)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 未注册的邮箱不发送邮件，也不返回错误
+	if err := a.ResetService.Request(context.Background(), "nobody@example.com"); err != nil {
+		t.Fatalf("unknown email: %v", err)
+	}
+	if n := len(a.Mailbox.Messages()); n != 0 {
+		t.Fatalf("%d messages sent for an unknown email", n)
+	}
+
+	if err := a.ResetService.Request(context.Background(), "Alice@Example.com"); err != nil {
+		t.Fatalf("Request: %v", err)
+	}
+	messages := a.Mailbox.Messages()
+	if len(messages) != 1 || messages[0].To != "alice@example.com" {
+		t.Fatalf("sent %+v", messages)
+	}
+	token := resetToken(t, messages[0].Body)
+
+	if _, err := a.ResetService.Reset(token, "short"); !errors.Is(err, service.ErrPasswordPolicy) {
+		t.Errorf("weak password: got %v", err)
+	}
+	// 密码不符合要求时链接仍然有效
+	if _, err := a.ResetService.Reset(token, "Autumn2025y"); err != nil {
+		t.Fatalf("Reset: %v", err)
+	}
+	if _, _, err := a.AuthService.Login("alice", "Autumn2025y", "192.0.2.1"); err != nil {
+		t.Errorf("login with new password: %v", err)
+	}
+	if _, err := a.ResetService.Reset(token, "Winter2025z"); !errors.Is(err, service.ErrInvalidResetToken) {
+		t.Errorf("reuse of reset token: got %v, want ErrInvalidResetToken", err)
+	}
+	// 所有设备退出登录
+	if _, _, err := a.TokenService.Refresh(pair.RefreshToken, apptest.Client); !errors.Is(err, service.ErrInvalidRefreshToken) {
+		t.Errorf("refresh after reset: got %v, want ErrInvalidRefreshToken", err)
+	}
+}
+
+func TestPasswordResetLimitsEmailsPerUser(t *testing.T) {
+	a := apptest.New(t)
+	if _, err := a.AuthService.Register("alice", "Spring2025x", "alice@example.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := a.ResetService.Request(context.Background(), "alice@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.ResetService.Request(context.Background(), "alice@example.com"); !errors.Is(err, service.ErrPasswordResetTooFrequent) {
+		t.Errorf("second request within a minute: got %v, want ErrPasswordResetTooFrequent", err)
+	}
+	if n := len(a.Mailbox.Messages()); n != 1 {
+		t.Errorf("%d messages sent, want 1", n)
+	}
+}
+
+func TestPasswordResetAllowThrottles(t *testing.T) {
+	a := apptest.New(t, func(cfg *config.Config) {
+		cfg.Auth.LoginMaxFailures = 3
+		cfg.Auth.LoginMaxBackoff = 0
+	})
+
+	// 不管邮箱是否注册，同一邮箱的请求次数都受限制
+	var blocked *service.LoginBlockedError
+	for i := 0; i < 3; i++ {
+		if err := a.ResetService.Allow("192.0.2.1", "victim@example.com"); err != nil {
+			t.Fatalf("request %d: %v", i+1, err)
+		}
+	}
+	if err := a.ResetService.Allow("192.0.2.2", "Victim@example.com"); !errors.As(err, &blocked) || blocked.Scope != "account" {
+		t.Errorf("fourth request: got %v, want blocked by email", err)
+	}
+	if err := a.ResetService.Allow("192.0.2.2", "other@example.com"); err != nil {
+		t.Errorf("other email: %v", err)
+	}
+}
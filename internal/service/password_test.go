@@ -0,0 +1,39 @@
+package service_test
+
+import (
+	"errors"
+	"my-social-platform/internal/service"
+	"strings"
+	"testing"
+)
+
+func TestValidatePassword(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		ok       bool
+	}{
+		{"valid", "Spring2025x", true},
+		{"too short", "abc123", false},
+		// 7个汉字占21个字节，但只有7个字符
+		{"short multibyte", "春夏秋冬1二三", false},
+		{"multibyte", "春夏秋冬东南西北1", true},
+		{"72 bytes", strings.Repeat("a1", 36), true},
+		{"73 bytes", strings.Repeat("a1", 36) + "b", false},
+		// 24个汉字刚好72个字节，再多一个字符就超过bcrypt的上限
+		{"multibyte over 72 bytes", strings.Repeat("春", 24) + "1", false},
+		{"no digit", "SpringSummer", false},
+		{"common", "password123", false},
+		{"contains username", "xAlice2025", false},
+		{"contains reversed username", "ecila2025x", false},
+	}
+	for _, tt := range tests {
+		err := service.ValidatePassword("alice", tt.password)
+		if tt.ok && err != nil {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		}
+		if !tt.ok && !errors.Is(err, service.ErrPasswordPolicy) {
+			t.Errorf("%s: got %v, want ErrPasswordPolicy", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"my-social-platform/internal/model"
+	"my-social-platform/internal/repository"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// maxPersonalTokens 每个用户最多同时拥有的个人访问令牌数量
+	maxPersonalTokens = 20
+	// personalTokenTouchInterval 最后使用时间的记录间隔，和会话一样避免脚本频繁调用时每个请求都写数据库
+	personalTokenTouchInterval = sessionTouchInterval
+)
+
+var (
+	// ErrInvalidPersonalToken 个人访问令牌不存在、已撤销、已过期，或者所属用户已被封禁
+	ErrInvalidPersonalToken = errors.New("invalid personal access token")
+	// ErrPersonalTokenNotFound 令牌不存在、不属于当前用户或已撤销
+	ErrPersonalTokenNotFound = errors.New("personal access token not found")
+	// ErrTooManyPersonalTokens 令牌数量达到上限
+	ErrTooManyPersonalTokens = fmt.Errorf("at most %d personal access tokens are allowed", maxPersonalTokens)
+)
+
+// InvalidScopeError 创建令牌时指定了不存在的权限范围
+type InvalidScopeError struct {
+	Scope string
+}
+
+func (e *InvalidScopeError) Error() string {
+	return fmt.Sprintf("unknown scope %q, valid scopes: %s", e.Scope, strings.Join(model.Scopes, ", "))
+}
+
+// PersonalTokenInfo 返回给用户的令牌信息，不包含令牌本身
+type PersonalTokenInfo struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"` // 令牌开头的几个字符，如 "msp_AbCd1234"
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	LastUsedIP string     `json:"last_used_ip"`
+	Expired    bool       `json:"expired"`
+}
+
+// PersonalTokenService 个人访问令牌，供社团账号的脚本、机器人等调用API
+// 令牌不会过期（或在用户指定的时间过期），不需要刷新；只能访问权限范围内的接口（见 model.Scopes）
+// 数据库只保存哈希，原始令牌只在创建时返回一次，丢失后只能撤销再重新创建
+type PersonalTokenService struct {
+	tokens repository.PersonalAccessTokenRepository
+	users  repository.UserRepository
+}
+
+// NewPersonalTokenService 创建PersonalTokenService
+func NewPersonalTokenService(tokens repository.PersonalAccessTokenRepository, users repository.UserRepository) *PersonalTokenService {
+	return &PersonalTokenService{tokens: tokens, users: users}
+}
+
+// Create 创建令牌，返回令牌信息和原始令牌；ttl为0表示永不过期
+// 权限范围去重后按 model.Scopes 的顺序保存
+func (s *PersonalTokenService) Create(userID uint, name string, scopes []string, ttl time.Duration) (*PersonalTokenInfo, string, error) {
+	for _, scope := range scopes {
+		if !slices.Contains(model.Scopes, scope) {
+			return nil, "", &InvalidScopeError{Scope: scope}
+		}
+	}
+	var granted []string
+	for _, scope := range model.Scopes {
+		if slices.Contains(scopes, scope) {
+			granted = append(granted, scope)
+		}
+	}
+
+	count, err := s.tokens.CountByUser(userID)
+	if err != nil {
+		return nil, "", err
+	}
+	if count >= maxPersonalTokens {
+		return nil, "", ErrTooManyPersonalTokens
+	}
+
+	random, err := randomToken(32)
+	if err != nil {
+		return nil, "", err
+	}
+	raw := model.PersonalAccessTokenPrefix + random
+	token := &model.PersonalAccessToken{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    raw[:len(model.PersonalAccessTokenPrefix)+8],
+		TokenHash: hashToken(raw),
+		Scopes:    strings.Join(granted, ","),
+	}
+	if ttl > 0 {
+		expiresAt := time.Now().Add(ttl)
+		token.ExpiresAt = &expiresAt
+	}
+	if err := s.tokens.Create(token); err != nil {
+		return nil, "", err
+	}
+	return personalTokenInfo(token, time.Now()), raw, nil
+}
+
+// List 用户的令牌，包括已过期但还没有撤销的
+func (s *PersonalTokenService) List(userID uint) ([]*PersonalTokenInfo, error) {
+	tokens, err := s.tokens.ListByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	infos := make([]*PersonalTokenInfo, 0, len(tokens))
+	for _, token := range tokens {
+		infos = append(infos, personalTokenInfo(token, now))
+	}
+	return infos, nil
+}
+
+// Revoke 撤销用户的一个令牌，立即生效
+func (s *PersonalTokenService) Revoke(userID, tokenID uint) error {
+	ok, err := s.tokens.Revoke(tokenID, userID, time.Now())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrPersonalTokenNotFound
+	}
+	return nil
+}
+
+// RevokeAll 撤销用户的所有令牌，在退出所有设备、修改或重置密码时调用
+func (s *PersonalTokenService) RevokeAll(userID uint) error {
+	return s.tokens.RevokeUser(userID, time.Now())
+}
+
+// Authenticate 验证请求中的个人访问令牌，返回令牌和所属用户，并记录最后使用时间和IP
+// 每次请求都查询数据库，撤销和封禁立即生效
+func (s *PersonalTokenService) Authenticate(raw, ip string) (*model.PersonalAccessToken, *model.User, error) {
+	token, err := s.tokens.GetByHash(hashToken(raw))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil, ErrInvalidPersonalToken
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	if token.ExpiresAt != nil && now.After(*token.ExpiresAt) {
+		return nil, nil, ErrInvalidPersonalToken
+	}
+
+	user, err := s.users.GetByID(token.UserID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil, ErrInvalidPersonalToken
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if user.BannedAt != nil {
+		return nil, nil, ErrInvalidPersonalToken
+	}
+
+	if token.LastUsedAt == nil || token.LastUsedIP != ip || now.Sub(*token.LastUsedAt) >= personalTokenTouchInterval {
+		// 记录失败不影响请求，下一次使用时再记录
+		s.tokens.Touch(token.ID, ip, now)
+	}
+	return token, user, nil
+}
+
+// personalTokenInfo 转换为返回给用户的令牌信息
+func personalTokenInfo(token *model.PersonalAccessToken, now time.Time) *PersonalTokenInfo {
+	return &PersonalTokenInfo{
+		ID:         token.ID,
+		Name:       token.Name,
+		Prefix:     token.Prefix,
+		Scopes:     strings.Split(token.Scopes, ","),
+		CreatedAt:  token.CreatedAt,
+		ExpiresAt:  token.ExpiresAt,
+		LastUsedAt: token.LastUsedAt,
+		LastUsedIP: token.LastUsedIP,
+		Expired:    token.ExpiresAt != nil && now.After(*token.ExpiresAt),
+	}
+}
@@ -0,0 +1,133 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"my-social-platform/internal/app/apptest"
+	"my-social-platform/internal/model"
+	"my-social-platform/internal/service"
+	"testing"
+	"time"
+)
+
+func TestPersonalTokenAuthenticate(t *testing.T) {
+	a := apptest.New(t)
+	alice := a.CreateUser(t, "alice", "Spring2025x")
+
+	// 权限范围去重后按 model.Scopes 的顺序保存
+	info, raw, err := a.PersonalTokenService.Create(alice.ID, "bot", []string{model.ScopePostsWrite, model.ScopeProfileRead, model.ScopePostsWrite}, 0)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if info.Prefix == "" || raw[:len(info.Prefix)] != info.Prefix {
+		t.Errorf("prefix %q does not match token %q", info.Prefix, raw)
+	}
+	if len(info.Scopes) != 2 || info.Scopes[0] != model.ScopeProfileRead || info.Scopes[1] != model.ScopePostsWrite {
+		t.Errorf("scopes: %v", info.Scopes)
+	}
+
+	token, user, err := a.PersonalTokenService.Authenticate(raw, apptest.Client.IP)
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if user.ID != alice.ID || token.ID != info.ID {
+		t.Errorf("Authenticate returned user %d token %d", user.ID, token.ID)
+	}
+	if _, _, err := a.PersonalTokenService.Authenticate(raw+"x", apptest.Client.IP); !errors.Is(err, service.ErrInvalidPersonalToken) {
+		t.Errorf("unknown token: got %v, want ErrInvalidPersonalToken", err)
+	}
+
+	var scopeErr *service.InvalidScopeError
+	if _, _, err := a.PersonalTokenService.Create(alice.ID, "bot", []string{"admin"}, 0); !errors.As(err, &scopeErr) {
+		t.Errorf("unknown scope: got %v, want InvalidScopeError", err)
+	}
+}
+
+func TestPersonalTokenExpiry(t *testing.T) {
+	a := apptest.New(t)
+	alice := a.CreateUser(t, "alice", "Spring2025x")
+	_, raw, err := a.PersonalTokenService.Create(alice.ID, "bot", []string{model.ScopeProfileRead}, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	if _, _, err := a.PersonalTokenService.Authenticate(raw, apptest.Client.IP); !errors.Is(err, service.ErrInvalidPersonalToken) {
+		t.Errorf("expired token: got %v, want ErrInvalidPersonalToken", err)
+	}
+	// 已过期的令牌仍然列出，由用户撤销
+	infos, err := a.PersonalTokenService.List(alice.ID)
+	if err != nil || len(infos) != 1 || !infos[0].Expired {
+		t.Errorf("List: %+v, %v", infos, err)
+	}
+}
+
+func TestPersonalTokenRevoke(t *testing.T) {
+	a := apptest.New(t)
+	alice := a.CreateUser(t, "alice", "Spring2025x")
+	bob := a.CreateUser(t, "bob", "Spring2025x")
+	info, raw, err := a.PersonalTokenService.Create(alice.ID, "bot", []string{model.ScopeProfileRead}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := a.PersonalTokenService.Revoke(bob.ID, info.ID); !errors.Is(err, service.ErrPersonalTokenNotFound) {
+		t.Errorf("revoke another user's token: got %v, want ErrPersonalTokenNotFound", err)
+	}
+	if err := a.PersonalTokenService.Revoke(alice.ID, info.ID); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	if _, _, err := a.PersonalTokenService.Authenticate(raw, apptest.Client.IP); !errors.Is(err, service.ErrInvalidPersonalToken) {
+		t.Errorf("revoked token: got %v, want ErrInvalidPersonalToken", err)
+	}
+	if err := a.PersonalTokenService.Revoke(alice.ID, info.ID); !errors.Is(err, service.ErrPersonalTokenNotFound) {
+		t.Errorf("revoke twice: got %v, want ErrPersonalTokenNotFound", err)
+	}
+}
+
+func TestPersonalTokensRevokedWithCredentials(t *testing.T) {
+	tests := []struct {
+		name   string
+		action func(t *testing.T, a *apptest.App, alice *model.User) error
+	}{
+		{"logout all", func(t *testing.T, a *apptest.App, alice *model.User) error { return a.TokenService.LogoutAll(alice.ID) }},
+		{"change password", func(t *testing.T, a *apptest.App, alice *model.User) error {
+			if err := a.UserService.ChangePassword(alice.ID, "Spring2025x", "Autumn2025y"); err != nil {
+				return err
+			}
+			_, err := a.TokenService.ResetSessions(alice.ID, apptest.Client)
+			return err
+		}},
+		{"forgot password", func(t *testing.T, a *apptest.App, alice *model.User) error {
+			if _, err := a.UserService.UpdateEmail(alice.ID, "alice@example.com"); err != nil {
+				return err
+			}
+			if err := a.ResetService.Request(context.Background(), "alice@example.com"); err != nil {
+				return err
+			}
+			_, err := a.ResetService.Reset(resetToken(t, a.Mailbox.Messages()[0].Body), "Autumn2025y")
+			return err
+		}},
+		{"admin reset password", func(t *testing.T, a *apptest.App, _ *model.User) error {
+			return a.UserService.ResetPassword("alice", "Autumn2025y")
+		}},
+		{"admin reset two-factor", func(t *testing.T, a *apptest.App, _ *model.User) error { return a.TwoFactor.Reset("alice") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := apptest.New(t)
+			alice := a.CreateUser(t, "alice", "Spring2025x")
+			_, raw, err := a.PersonalTokenService.Create(alice.ID, "bot", []string{model.ScopeProfileRead}, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := tt.action(t, a, alice); err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+			if _, _, err := a.PersonalTokenService.Authenticate(raw, apptest.Client.IP); !errors.Is(err, service.ErrInvalidPersonalToken) {
+				t.Errorf("token after %s: got %v, want ErrInvalidPersonalToken", tt.name, err)
+			}
+		})
+	}
+}
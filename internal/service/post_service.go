@@ -1,27 +1,85 @@
 package service
 
 import (
+	"errors"
+	"my-social-platform/internal/config"
 	"my-social-platform/internal/model"
 	"my-social-platform/internal/repository"
 )
 
+var (
+	// ErrNotVerified 账号未达到发帖需要的认证级别
+	ErrNotVerified = errors.New("account is not verified")
+	// ErrNotPostAuthor 只有作者（或有 post.delete.any 权限的用户）可以删除帖子
+	ErrNotPostAuthor = errors.New("only the author can delete this post")
+)
+
+// PostService 帖子相关的业务逻辑
+type PostService struct {
+	posts    repository.PostRepository
+	users    repository.UserRepository
+	requires string // 发帖需要的认证级别，见 config.AuthConfig.PostRequires
+}
+
+// NewPostService 创建PostService
+func NewPostService(posts repository.PostRepository, users repository.UserRepository, requires string) *PostService {
+	return &PostService{posts: posts, users: users, requires: requires}
+}
+
 // 发帖的业务逻辑
-func CreatePostService(post *model.Post) error {
+func (s *PostService) Create(post *model.Post) error {
+	if err := s.checkVerified(post.UserID); err != nil {
+		return err
+	}
 	// 可加参数校验，内容审核等
-	return repository.CreatePost(post)
+	return s.posts.Create(post)
+}
+
+// checkVerified 检查用户是否达到发帖需要的认证级别
+func (s *PostService) checkVerified(userID uint) error {
+	if s.requires == "" || s.requires == config.VerificationNone {
+		return nil
+	}
+	user, err := s.users.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	switch {
+	case s.requires == config.VerificationEmail && user.EmailVerifiedAt != nil:
+		return nil
+	case s.requires == config.VerificationStudent && user.StudentVerified:
+		return nil
+	}
+	return ErrNotVerified
 }
 
 // 根据id查找帖子
-func GetPostByIDService(id uint) (*model.Post, error) {
-	return repository.GetPostByID(id)
+func (s *PostService) GetByID(id uint) (*model.Post, error) {
+	return s.posts.GetByID(id)
 }
 
 // 获取所有帖子
-func GetAllPostsService() ([]*model.Post, error) {
-	return repository.GetAllPosts()
+func (s *PostService) List() ([]*model.Post, error) {
+	return s.posts.List()
 }
 
 // 根据用户ID获取帖子
-func GetPostsByUserIDService(userID uint) ([]*model.Post, error) {
-	return repository.GetPostsByUserID(userID)
+func (s *PostService) ListByUserID(userID uint) ([]*model.Post, error) {
+	return s.posts.ListByUserID(userID)
+}
+
+// Delete 删除帖子，返回被删除的帖子
+// 作者可以删除自己的帖子；deleteAny 为true（有 post.delete.any 权限）时可以删除任何人的帖子
+func (s *PostService) Delete(postID, userID uint, deleteAny bool) (*model.Post, error) {
+	post, err := s.posts.GetByID(postID)
+	if err != nil {
+		return nil, err
+	}
+	if post.UserID != userID && !deleteAny {
+		return nil, ErrNotPostAuthor
+	}
+	if err := s.posts.Delete(post.ID); err != nil {
+		return nil, err
+	}
+	return post, nil
 }
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"my-social-platform/internal/model"
+	"my-social-platform/internal/repository"
+	"sync"
+)
+
+// RoleInfo 角色及其权限，管理接口返回
+type RoleInfo struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+// RBACService 基于角色的访问控制
+// 角色和权限保存在 role、role_permission 表中，启动时调用Load读入内存；
+// 签发访问令牌时把用户角色的权限写入令牌，处理请求时只检查令牌，不查询数据库
+// 修改role_permission表后需要重启服务，已签发的令牌在过期前仍然带着原来的权限
+type RBACService struct {
+	roles repository.RoleRepository
+
+	mu          sync.RWMutex
+	permissions map[string][]string // 角色 -> 权限
+}
+
+// NewRBACService 创建RBACService
+func NewRBACService(roles repository.RoleRepository) *RBACService {
+	return &RBACService{roles: roles, permissions: make(map[string][]string)}
+}
+
+// Load 从数据库加载各角色的权限
+func (s *RBACService) Load() error {
+	permissions, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.permissions = permissions
+	return nil
+}
+
+// Permissions 角色拥有的权限，未知角色（或还没有Load）没有任何权限
+func (s *RBACService) Permissions(role string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.permissions[role]
+}
+
+// Roles 从数据库读取全部角色及其权限
+func (s *RBACService) Roles() ([]RoleInfo, error) {
+	roles, err := s.roles.List()
+	if err != nil {
+		return nil, err
+	}
+	permissions, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]RoleInfo, 0, len(roles))
+	for _, r := range roles {
+		info := RoleInfo{Name: r.Name, Description: r.Description, Permissions: permissions[r.Name]}
+		if info.Permissions == nil {
+			info.Permissions = []string{}
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// load 从数据库读取权限，按角色分组
+func (s *RBACService) load() (map[string][]string, error) {
+	rows, err := s.roles.ListPermissions()
+	if err != nil {
+		return nil, err
+	}
+	permissions := make(map[string][]string)
+	for _, p := range rows {
+		permissions[p.Role] = append(permissions[p.Role], p.Permission)
+	}
+	return permissions, nil
+}
+
+// roleNames 角色名列表，用于错误提示
+func roleNames(roles []*model.Role) []string {
+	names := make([]string, 0, len(roles))
+	for _, r := range roles {
+		names = append(names, r.Name)
+	}
+	return names
+}
@@ -0,0 +1,119 @@
+package service_test
+
+import (
+	"errors"
+	"my-social-platform/internal/app/apptest"
+	"my-social-platform/internal/model"
+	"my-social-platform/internal/repository"
+	"my-social-platform/internal/service"
+	"slices"
+	"testing"
+)
+
+func TestRBACPermissions(t *testing.T) {
+	a := apptest.New(t)
+
+	tests := []struct {
+		role string
+		want []string
+	}{
+		{model.RoleUser, nil},
+		{model.RoleModerator, []string{model.PermPostDeleteAny}},
+		{model.RoleAdmin, []string{model.PermPostDeleteAny, model.PermUserRoleManage}},
+		{"unknown", nil},
+	}
+	for _, tt := range tests {
+		got := slices.Clone(a.RBAC.Permissions(tt.role))
+		slices.Sort(got)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("permissions of %s: %v, want %v", tt.role, got, tt.want)
+		}
+	}
+
+	roles, err := a.RBAC.Roles()
+	if err != nil {
+		t.Fatalf("Roles: %v", err)
+	}
+	var names []string
+	for _, r := range roles {
+		names = append(names, r.Name)
+		if r.Permissions == nil {
+			t.Errorf("role %s has nil permissions", r.Name)
+		}
+	}
+	slices.Sort(names)
+	if !slices.Equal(names, []string{model.RoleAdmin, model.RoleModerator, model.RoleUser}) {
+		t.Errorf("roles: %v", names)
+	}
+}
+
+func TestSetRole(t *testing.T) {
+	a := apptest.New(t)
+	a.CreateUser(t, "alice", "Spring2025x")
+
+	user, err := a.UserService.SetRole("alice", model.RoleModerator)
+	if err != nil {
+		t.Fatalf("grant: %v", err)
+	}
+	if user.Role != model.RoleModerator || a.User(t, "alice").Role != model.RoleModerator {
+		t.Errorf("role after grant: %s", a.User(t, "alice").Role)
+	}
+	if _, err := a.UserService.SetRole("alice", model.RoleUser); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	if role := a.User(t, "alice").Role; role != model.RoleUser {
+		t.Errorf("role after revoke: %s", role)
+	}
+
+	if _, err := a.UserService.SetRole("alice", "superuser"); !errors.Is(err, service.ErrInvalidRole) {
+		t.Errorf("unknown role: got %v, want ErrInvalidRole", err)
+	}
+	if _, err := a.UserService.SetRole("nobody", model.RoleModerator); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("unknown user: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestPermissionClaimFollowsRole(t *testing.T) {
+	a := apptest.New(t)
+	alice := a.CreateUser(t, "alice", "Spring2025x")
+	before, err := a.TokenService.Issue(alice, apptest.Client)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := a.UserService.SetRole("alice", model.RoleModerator); err != nil {
+		t.Fatal(err)
+	}
+	// 修改角色前签发的令牌版本已经过期，不能继续使用旧的权限
+	claims, err := a.JWT.ParseJWT(before.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version, err := a.Revocations.TokenVersion(alice.ID); err != nil || claims.TokenVersion == version {
+		t.Errorf("token issued before the role change is still current (version %d, %v)", version, err)
+	}
+
+	// 重新登录后令牌中带有新角色的权限
+	after, err := a.TokenService.Issue(a.User(t, "alice"), apptest.Client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err = a.JWT.ParseJWT(after.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Role != model.RoleModerator || !slices.Contains(claims.Permissions, model.PermPostDeleteAny) {
+		t.Errorf("claims after grant: role %q, permissions %v", claims.Role, claims.Permissions)
+	}
+
+	if _, err := a.UserService.SetRole("alice", model.RoleUser); err != nil {
+		t.Fatal(err)
+	}
+	revoked, err := a.TokenService.Issue(a.User(t, "alice"), apptest.Client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims, err = a.JWT.ParseJWT(revoked.AccessToken); err != nil || len(claims.Permissions) != 0 {
+		t.Errorf("permissions after revoke: %v, %v", claims.Permissions, err)
+	}
+}
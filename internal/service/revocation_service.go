@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"log"
+	"my-social-platform/internal/model"
+	"my-social-platform/internal/repository"
+	"sync"
+	"time"
+)
+
+// versionCacheTTL 用户令牌版本在内存中的缓存时间
+// user ban 等管理命令在另一个进程中修改版本号，最多延迟这么久生效
+const versionCacheTTL = 30 * time.Second
+
+// RevocationReloadInterval 从数据库重新加载吊销记录的间隔
+// 多个实例部署时，在其他实例上退出登录的访问令牌最多延迟这么久失效，和令牌版本缓存一致
+const RevocationReloadInterval = versionCacheTTL
+
+type cachedVersion struct {
+	version  int
+	loadedAt time.Time
+}
+
+// RevocationService 访问令牌吊销
+// 两种方式使访问令牌失效：
+//   - 按jti吊销单个令牌（退出登录），记录保存在数据库，同时缓存在内存中，每个请求只查内存
+//   - 用户令牌版本加一（退出所有设备），版本号小于当前版本的令牌全部失效
+//
+// 内存缓存只在当前进程有效，启动时调用Load从数据库恢复，之后由Watch定期重新加载其他实例的吊销记录
+type RevocationService struct {
+	revoked repository.RevokedTokenRepository
+	users   repository.UserRepository
+
+	mu       sync.RWMutex
+	jtis     map[string]time.Time // jti -> 令牌过期时间
+	versions map[uint]cachedVersion
+}
+
+// NewRevocationService 创建RevocationService
+func NewRevocationService(revoked repository.RevokedTokenRepository, users repository.UserRepository) *RevocationService {
+	return &RevocationService{
+		revoked:  revoked,
+		users:    users,
+		jtis:     make(map[string]time.Time),
+		versions: make(map[uint]cachedVersion),
+	}
+}
+
+// Load 清理已过期的吊销记录，并把其余记录加载到内存
+// 吊销不会撤回，只合并数据库中的记录，加载期间本进程新吊销的令牌不会丢失
+func (s *RevocationService) Load() error {
+	now := time.Now()
+	if err := s.revoked.DeleteExpired(now); err != nil {
+		return err
+	}
+	tokens, err := s.revoked.ListActive(now)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, t := range tokens {
+		s.jtis[t.JTI] = t.ExpiresAt
+	}
+	for k, exp := range s.jtis {
+		if now.After(exp) {
+			delete(s.jtis, k)
+		}
+	}
+	return nil
+}
+
+// Watch 每隔interval从数据库重新加载吊销记录，直到ctx结束
+// 多个实例共用一个数据库时，其他实例上退出登录的令牌在本实例也会失效
+func (s *RevocationService) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Load(); err != nil {
+				log.Println("Revoked tokens reload failed, keeping current records:", err)
+			}
+		}
+	}
+}
+
+// Revoke 吊销单个访问令牌，expiresAt为令牌本身的过期时间
+func (s *RevocationService) Revoke(jti string, userID uint, expiresAt time.Time) error {
+	if err := s.revoked.Create(&model.RevokedToken{JTI: jti, UserID: userID, ExpiresAt: expiresAt}); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jtis[jti] = expiresAt
+	// 顺便清理内存中已过期的记录，避免无限增长
+	now := time.Now()
+	for k, exp := range s.jtis {
+		if now.After(exp) {
+			delete(s.jtis, k)
+		}
+	}
+	return nil
+}
+
+// IsRevoked 判断令牌是否已被吊销
+func (s *RevocationService) IsRevoked(jti string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.jtis[jti]
+	return ok
+}
+
+// RevokeAll 使用户之前签发的所有访问令牌失效
+func (s *RevocationService) RevokeAll(userID uint) error {
+	if err := s.users.IncrementTokenVersion(userID); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.versions, userID)
+	return nil
+}
+
+// TokenVersion 用户当前的令牌版本
+func (s *RevocationService) TokenVersion(userID uint) (int, error) {
+	s.mu.RLock()
+	cached, ok := s.versions[userID]
+	s.mu.RUnlock()
+	if ok && time.Since(cached.loadedAt) < versionCacheTTL {
+		return cached.version, nil
+	}
+
+	user, err := s.users.GetByID(userID)
+	if err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.versions[userID] = cachedVersion{version: user.TokenVersion, loadedAt: time.Now()}
+	return user.TokenVersion, nil
+}
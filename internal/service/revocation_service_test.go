@@ -0,0 +1,56 @@
+package service_test
+
+import (
+	"context"
+	"my-social-platform/internal/app/apptest"
+	"my-social-platform/internal/service"
+	"testing"
+	"time"
+)
+
+func TestRevocationsReloadFromOtherInstances(t *testing.T) {
+	a := apptest.New(t)
+	alice := a.CreateUser(t, "alice", "Spring2025x")
+	// 另一个实例：同一个数据库，各自的内存缓存
+	other := service.NewRevocationService(a.RevokedTokens, a.Users)
+	if err := other.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := a.Revocations.Revoke("jti-1", alice.ID, time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if !a.Revocations.IsRevoked("jti-1") {
+		t.Fatal("token not revoked in the revoking instance")
+	}
+	if other.IsRevoked("jti-1") {
+		t.Fatal("other instance saw the revocation before reloading")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go other.Watch(ctx, 10*time.Millisecond)
+	deadline := time.Now().Add(5 * time.Second)
+	for !other.IsRevoked("jti-1") {
+		if time.Now().After(deadline) {
+			t.Fatal("Watch did not load the revocation from the database")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRevocationsLoadDropsExpired(t *testing.T) {
+	a := apptest.New(t)
+	alice := a.CreateUser(t, "alice", "Spring2025x")
+	if err := a.Revocations.Revoke("expired", alice.ID, time.Now().Add(10*time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	if err := a.Revocations.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if a.Revocations.IsRevoked("expired") {
+		t.Error("expired revocation still kept in memory")
+	}
+}
@@ -0,0 +1,293 @@
+package service
+
+import (
+	"errors"
+	"my-social-platform/internal/model"
+	"my-social-platform/internal/repository"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// ErrSessionNotFound 会话不存在、不属于当前用户或已经结束
+var ErrSessionNotFound = errors.New("session not found")
+
+const (
+	// sessionCacheTTL 会话状态在内存中的缓存时间，和令牌版本一样，其他进程结束的会话最多延迟这么久生效
+	sessionCacheTTL = versionCacheTTL
+	// sessionTouchInterval 最后访问时间的记录间隔，避免每个请求都写数据库
+	sessionTouchInterval = time.Minute
+)
+
+// ClientInfo 发起登录或请求的客户端
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+// SessionInfo 返回给用户的会话信息
+type SessionInfo struct {
+	ID         uint      `json:"id"`
+	Device     string    `json:"device"` // 根据User-Agent识别的浏览器和系统，如 "Chrome · Windows"
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	Current    bool      `json:"current"` // 是否是发起请求的会话
+}
+
+type cachedSession struct {
+	userID   uint
+	revoked  bool
+	ip       string
+	lastSeen time.Time
+	loadedAt time.Time
+}
+
+// SessionService 登录会话（设备）管理
+// 登录时由TokenService创建会话，每次签发令牌更新会话；用户可以查看自己的会话并结束其中任意一个
+// 中间件通过Active检查访问令牌所属的会话是否仍然有效，会话状态在内存中缓存 sessionCacheTTL
+type SessionService struct {
+	sessions repository.SessionRepository
+	refresh  repository.RefreshTokenRepository
+
+	mu    sync.Mutex
+	cache map[uint]*cachedSession
+}
+
+// NewSessionService 创建SessionService
+func NewSessionService(sessions repository.SessionRepository, refresh repository.RefreshTokenRepository) *SessionService {
+	return &SessionService{sessions: sessions, refresh: refresh, cache: make(map[uint]*cachedSession)}
+}
+
+// Start 登录成功后为新的刷新令牌家族创建会话
+func (s *SessionService) Start(userID uint, family string, client ClientInfo) (*model.Session, error) {
+	now := time.Now()
+	session := &model.Session{
+		UserID:     userID,
+		FamilyID:   family,
+		UserAgent:  truncate(client.UserAgent, 512),
+		IP:         client.IP,
+		LastSeenAt: now,
+		ExpiresAt:  now,
+	}
+	if err := s.sessions.Create(session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// ForFamily 刷新令牌时查找所属的会话
+// 本功能上线前签发的刷新令牌没有会话，第一次刷新时补建
+func (s *SessionService) ForFamily(userID uint, family string, client ClientInfo) (*model.Session, error) {
+	session, err := s.sessions.GetByFamily(family)
+	if errors.Is(err, repository.ErrNotFound) {
+		return s.Start(userID, family, client)
+	}
+	return session, err
+}
+
+// Issued 会话签发了新的访问令牌和刷新令牌
+func (s *SessionService) Issued(session *model.Session, jti string, client ClientInfo, refreshExpiresAt time.Time) error {
+	now := time.Now()
+	if err := s.sessions.Issued(session.ID, jti, client.IP, now, refreshExpiresAt); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if entry, ok := s.cache[session.ID]; ok {
+		entry.ip, entry.lastSeen = client.IP, now
+	}
+	return nil
+}
+
+// Active 访问令牌所属的会话是否仍然有效，有效时顺便记录最后访问时间和IP（每个会话最多每分钟写一次数据库）
+// 查询数据库出错时按无效处理
+func (s *SessionService) Active(sessionID uint, ip string) bool {
+	now := time.Now()
+	s.mu.Lock()
+	entry, ok := s.cache[sessionID]
+	s.mu.Unlock()
+
+	if !ok || now.Sub(entry.loadedAt) >= sessionCacheTTL {
+		session, err := s.sessions.GetByID(sessionID)
+		if err != nil {
+			return false
+		}
+		entry = &cachedSession{
+			userID:   session.UserID,
+			revoked:  session.RevokedAt != nil,
+			ip:       session.IP,
+			lastSeen: session.LastSeenAt,
+			loadedAt: now,
+		}
+		s.mu.Lock()
+		s.prune(now)
+		s.cache[sessionID] = entry
+		s.mu.Unlock()
+	}
+
+	s.mu.Lock()
+	revoked := entry.revoked
+	touch := !revoked && (entry.ip != ip || now.Sub(entry.lastSeen) >= sessionTouchInterval)
+	if touch {
+		entry.ip, entry.lastSeen = ip, now
+	}
+	s.mu.Unlock()
+
+	if touch {
+		// 记录失败不影响请求，下一次超过间隔时再记录
+		s.sessions.Touch(sessionID, ip, now)
+	}
+	return !revoked
+}
+
+// List 用户当前有效的会话，currentID为发起请求的会话
+func (s *SessionService) List(userID, currentID uint) ([]*SessionInfo, error) {
+	sessions, err := s.sessions.ListActive(userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]*SessionInfo, 0, len(sessions))
+	for _, session := range sessions {
+		infos = append(infos, &SessionInfo{
+			ID:         session.ID,
+			Device:     DescribeUserAgent(session.UserAgent),
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+			Current:    session.ID == currentID,
+		})
+	}
+	return infos, nil
+}
+
+// Revoke 用户结束自己的一个会话：会话签发的访问令牌和刷新令牌立即失效
+func (s *SessionService) Revoke(userID, sessionID uint) error {
+	session, err := s.sessions.GetByID(sessionID)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && session.UserID != userID) {
+		return ErrSessionNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	ok, err := s.revoke(session)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// RevokeFamily 结束刷新令牌家族所属的会话，用于检测到刷新令牌重放时
+// 会话已签发的访问令牌同时失效；家族没有会话（本功能上线前签发）或会话已经结束时只吊销刷新令牌
+func (s *SessionService) RevokeFamily(family string) error {
+	session, err := s.sessions.GetByFamily(family)
+	if errors.Is(err, repository.ErrNotFound) {
+		return s.refresh.RevokeFamily(family, time.Now())
+	}
+	if err != nil {
+		return err
+	}
+	_, err = s.revoke(session)
+	return err
+}
+
+// revoke 结束会话并吊销它的刷新令牌家族，更新缓存使访问令牌立即失效
+// 返回false表示会话已经结束
+func (s *SessionService) revoke(session *model.Session) (bool, error) {
+	now := time.Now()
+	ok, err := s.sessions.Revoke(session.ID, now)
+	if err != nil {
+		return false, err
+	}
+	if err := s.refresh.RevokeFamily(session.FamilyID, now); err != nil {
+		return false, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cache[session.ID] = &cachedSession{userID: session.UserID, revoked: true, loadedAt: now}
+	return ok, nil
+}
+
+// RevokeAll 结束用户的所有会话（退出所有设备）
+func (s *SessionService) RevokeAll(userID uint) error {
+	if err := s.sessions.RevokeUser(userID, time.Now()); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, entry := range s.cache {
+		if entry.userID == userID {
+			entry.revoked = true
+		}
+	}
+	return nil
+}
+
+// prune 清理已过期的缓存，调用方持有锁
+func (s *SessionService) prune(now time.Time) {
+	for id, entry := range s.cache {
+		if now.Sub(entry.loadedAt) >= sessionCacheTTL {
+			delete(s.cache, id)
+		}
+	}
+}
+
+// DescribeUserAgent 从User-Agent中识别浏览器和操作系统，识别不了的部分省略
+func DescribeUserAgent(ua string) string {
+	browsers := []struct{ token, name string }{
+		// 顺序很重要：Edge、Opera、微信的UA中同时包含Chrome和Safari
+		{"MicroMessenger", "微信"},
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+		{"curl/", "curl"},
+	}
+	systems := []struct{ token, name string }{
+		{"Windows", "Windows"},
+		{"iPhone", "iOS"},
+		{"iPad", "iPadOS"},
+		{"Android", "Android"},
+		{"Mac OS X", "macOS"},
+		{"Linux", "Linux"},
+	}
+
+	var parts []string
+	for _, b := range browsers {
+		if strings.Contains(ua, b.token) {
+			parts = append(parts, b.name)
+			break
+		}
+	}
+	for _, o := range systems {
+		if strings.Contains(ua, o.token) {
+			parts = append(parts, o.name)
+			break
+		}
+	}
+	if len(parts) == 0 {
+		return "未知设备"
+	}
+	return strings.Join(parts, " · ")
+}
+
+// truncate 截断字符串到最多n字节，不截断在多字节字符中间
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
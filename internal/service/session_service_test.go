@@ -0,0 +1,108 @@
+package service_test
+
+import (
+	"errors"
+	"my-social-platform/internal/app/apptest"
+	"my-social-platform/internal/service"
+	"testing"
+)
+
+func TestSessionListAndRevoke(t *testing.T) {
+	a := apptest.New(t)
+	alice := a.CreateUser(t, "alice", "Spring2025x")
+	bob := a.CreateUser(t, "bob", "Autumn2025y")
+
+	laptop, err := a.TokenService.Issue(alice, apptest.Client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	phone, err := a.TokenService.Issue(alice, service.ClientInfo{IP: "198.51.100.7", UserAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0) Safari/604.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	laptopClaims, _ := a.JWT.ParseJWT(laptop.AccessToken)
+	phoneClaims, _ := a.JWT.ParseJWT(phone.AccessToken)
+
+	sessions, err := a.SessionService.List(alice.ID, laptopClaims.SessionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("List returned %d sessions, want 2", len(sessions))
+	}
+	for _, s := range sessions {
+		if s.Current != (s.ID == laptopClaims.SessionID) {
+			t.Errorf("session %d: current = %v", s.ID, s.Current)
+		}
+		if s.ID == phoneClaims.SessionID && (s.Device != "Safari · iOS" || s.IP != "198.51.100.7") {
+			t.Errorf("phone session: device %q, ip %q", s.Device, s.IP)
+		}
+	}
+
+	// 不能结束别人的会话
+	if err := a.SessionService.Revoke(bob.ID, phoneClaims.SessionID); !errors.Is(err, service.ErrSessionNotFound) {
+		t.Errorf("revoke another user's session: got %v, want ErrSessionNotFound", err)
+	}
+
+	if err := a.SessionService.Revoke(alice.ID, phoneClaims.SessionID); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	if a.SessionService.Active(phoneClaims.SessionID, apptest.Client.IP) {
+		t.Error("revoked session is still active")
+	}
+	if !a.SessionService.Active(laptopClaims.SessionID, apptest.Client.IP) {
+		t.Error("other session was revoked too")
+	}
+	if _, _, err := a.TokenService.Refresh(phone.RefreshToken, apptest.Client); !errors.Is(err, service.ErrInvalidRefreshToken) {
+		t.Errorf("refresh on revoked session: got %v, want ErrInvalidRefreshToken", err)
+	}
+	if err := a.SessionService.Revoke(alice.ID, phoneClaims.SessionID); !errors.Is(err, service.ErrSessionNotFound) {
+		t.Errorf("revoke twice: got %v, want ErrSessionNotFound", err)
+	}
+
+	sessions, err = a.SessionService.List(alice.ID, laptopClaims.SessionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 1 || sessions[0].ID != laptopClaims.SessionID {
+		t.Errorf("List after revoke: %+v", sessions)
+	}
+}
+
+func TestSessionRevokeAll(t *testing.T) {
+	a := apptest.New(t)
+	alice := a.CreateUser(t, "alice", "Spring2025x")
+	pair, err := a.TokenService.Issue(alice, apptest.Client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, _ := a.JWT.ParseJWT(pair.AccessToken)
+	// 先让会话状态进入缓存，RevokeAll需要同时更新缓存
+	if !a.SessionService.Active(claims.SessionID, apptest.Client.IP) {
+		t.Fatal("new session is not active")
+	}
+
+	if err := a.SessionService.RevokeAll(alice.ID); err != nil {
+		t.Fatal(err)
+	}
+	if a.SessionService.Active(claims.SessionID, apptest.Client.IP) {
+		t.Error("session is still active after RevokeAll")
+	}
+}
+
+func TestDescribeUserAgent(t *testing.T) {
+	tests := []struct {
+		ua, want string
+	}{
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 Chrome/126.0 Safari/537.36 Edg/126.0", "Edge · Windows"},
+		{"Mozilla/5.0 (Macintosh; Intel Mac OS X 14_5) AppleWebKit/605.1.15 Version/17.5 Safari/605.1.15", "Safari · macOS"},
+		{"Mozilla/5.0 (Linux; Android 14) Chrome/126.0 Mobile Safari/537.36 MicroMessenger/8.0", "微信 · Android"},
+		{"curl/8.5.0", "curl"},
+		{"", "未知设备"},
+	}
+	for _, tt := range tests {
+		if got := service.DescribeUserAgent(tt.ua); got != tt.want {
+			t.Errorf("DescribeUserAgent(%q) = %q, want %q", tt.ua, got, tt.want)
+		}
+	}
+}
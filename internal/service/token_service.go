@@ -0,0 +1,224 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"my-social-platform/internal/model"
+	"my-social-platform/internal/repository"
+	"time"
+)
+
+var (
+	// ErrInvalidRefreshToken 刷新令牌不存在、已过期或已被吊销
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	// ErrRefreshTokenReused 已使用过的刷新令牌被再次使用，整个令牌家族和所属会话已被吊销
+	ErrRefreshTokenReused = errors.New("refresh token reused")
+)
+
+// AccessTokenIssuer 签发访问令牌(JWT)
+// GenerateJWT 返回令牌和它的jti，sessionID写入令牌的sid声明
+type AccessTokenIssuer interface {
+	GenerateJWT(user model.User, sessionID uint) (string, string, error)
+	TTL() time.Duration
+}
+
+// TokenPair 登录或刷新后返回给客户端的令牌
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"` // 访问令牌剩余有效秒数
+}
+
+// TokenService 访问令牌和刷新令牌的签发、轮换和吊销
+// 每次登录创建一个会话（见SessionService），同一会话的令牌共享一个刷新令牌家族
+type TokenService struct {
+	refresh     repository.RefreshTokenRepository
+	users       repository.UserRepository
+	access      AccessTokenIssuer
+	revocations *RevocationService
+	sessions    *SessionService
+	personal    *PersonalTokenService
+	refreshTTL  time.Duration
+}
+
+// NewTokenService 创建TokenService
+func NewTokenService(refresh repository.RefreshTokenRepository, users repository.UserRepository, access AccessTokenIssuer, revocations *RevocationService, sessions *SessionService, personal *PersonalTokenService, refreshTTL time.Duration) *TokenService {
+	return &TokenService{refresh: refresh, users: users, access: access, revocations: revocations, sessions: sessions, personal: personal, refreshTTL: refreshTTL}
+}
+
+// Issue 登录成功后创建会话，签发访问令牌和一个新家族的刷新令牌
+func (s *TokenService) Issue(user *model.User, client ClientInfo) (*TokenPair, error) {
+	family, err := randomToken(16)
+	if err != nil {
+		return nil, err
+	}
+	session, err := s.sessions.Start(user.ID, family, client)
+	if err != nil {
+		return nil, err
+	}
+	return s.issue(user, session, client)
+}
+
+// Refresh 用刷新令牌换取新的访问令牌和刷新令牌
+// 旧的刷新令牌立即作废；如果旧令牌已经被用过，说明令牌泄露，结束整个会话
+func (s *TokenService) Refresh(raw string, client ClientInfo) (*model.User, *TokenPair, error) {
+	token, err := s.refresh.GetByHash(hashToken(raw))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, nil, ErrInvalidRefreshToken
+		}
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	if token.RevokedAt != nil || now.After(token.ExpiresAt) {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+	if token.UsedAt != nil {
+		return nil, nil, s.reused(token)
+	}
+
+	// 条件更新，并发使用同一个令牌时只有一个请求能成功
+	ok, err := s.refresh.MarkUsed(token.ID, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !ok {
+		return nil, nil, s.reused(token)
+	}
+
+	user, err := s.users.GetByID(token.UserID)
+	if err != nil || user.BannedAt != nil {
+		s.refresh.RevokeFamily(token.FamilyID, now)
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	session, err := s.sessions.ForFamily(user.ID, token.FamilyID, client)
+	if err != nil {
+		return nil, nil, err
+	}
+	if session.RevokedAt != nil {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+	pair, err := s.issue(user, session, client)
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, pair, nil
+}
+
+// Logout 退出当前登录：吊销当前访问令牌并结束当前会话（会话的刷新令牌随之失效），
+// 同时吊销客户端提交的刷新令牌所在的家族
+// sessionID为0（本功能上线前签发的令牌）时不结束会话；refreshRaw可以为空；不属于该用户的刷新令牌会被忽略
+func (s *TokenService) Logout(userID, sessionID uint, jti string, expiresAt time.Time, refreshRaw string) error {
+	if err := s.revocations.Revoke(jti, userID, expiresAt); err != nil {
+		return err
+	}
+	if sessionID != 0 {
+		if err := s.sessions.Revoke(userID, sessionID); err != nil && !errors.Is(err, ErrSessionNotFound) {
+			return err
+		}
+	}
+	if refreshRaw == "" {
+		return nil
+	}
+
+	token, err := s.refresh.GetByHash(hashToken(refreshRaw))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	if token.UserID != userID {
+		return nil
+	}
+	return s.refresh.RevokeFamily(token.FamilyID, time.Now())
+}
+
+// LogoutAll 退出所有设备：结束所有会话，之前签发的访问令牌、刷新令牌和个人访问令牌全部失效
+func (s *TokenService) LogoutAll(userID uint) error {
+	if err := s.refresh.RevokeUser(userID, time.Now()); err != nil {
+		return err
+	}
+	if err := s.sessions.RevokeAll(userID); err != nil {
+		return err
+	}
+	if err := s.personal.RevokeAll(userID); err != nil {
+		return err
+	}
+	return s.revocations.RevokeAll(userID)
+}
+
+// ResetSessions 吊销用户的所有令牌，再为当前设备创建新会话并签发一对新令牌
+// 用于修改密码等场景：其他设备需要重新登录，当前设备不受影响
+func (s *TokenService) ResetSessions(userID uint, client ClientInfo) (*TokenPair, error) {
+	if err := s.LogoutAll(userID); err != nil {
+		return nil, err
+	}
+	// 重新读取用户，新令牌需要带上加一后的令牌版本
+	user, err := s.users.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.Issue(user, client)
+}
+
+// reused 处理刷新令牌重放：结束所属的会话，整个家族的刷新令牌和会话已签发的访问令牌都立即失效
+// 返回的错误包含用户ID，供handler写入安全日志
+func (s *TokenService) reused(token *model.RefreshToken) error {
+	if err := s.sessions.RevokeFamily(token.FamilyID); err != nil {
+		return err
+	}
+	return fmt.Errorf("%w for user %d", ErrRefreshTokenReused, token.UserID)
+}
+
+// issue 为会话签发新的令牌对，刷新令牌属于会话的家族
+func (s *TokenService) issue(user *model.User, session *model.Session, client ClientInfo) (*TokenPair, error) {
+	access, jti, err := s.access.GenerateJWT(*user, session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	expiresAt := time.Now().Add(s.refreshTTL)
+	if err := s.refresh.Create(&model.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  session.FamilyID,
+		TokenHash: hashToken(raw),
+		ExpiresAt: expiresAt,
+	}); err != nil {
+		return nil, err
+	}
+	if err := s.sessions.Issued(session, jti, client, expiresAt); err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  access,
+		RefreshToken: raw,
+		ExpiresIn:    int64(s.access.TTL().Seconds()),
+	}, nil
+}
+
+// randomToken 生成n字节的随机令牌，使用URL安全的Base64编码
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken 计算令牌的SHA-256哈希，数据库中只保存哈希
+func hashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
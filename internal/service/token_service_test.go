@@ -0,0 +1,195 @@
+package service_test
+
+import (
+	"errors"
+	"my-social-platform/internal/app/apptest"
+	"my-social-platform/internal/service"
+	"testing"
+)
+
+func TestRefreshRotatesTokens(t *testing.T) {
+	a := apptest.New(t)
+	alice := a.CreateUser(t, "alice", "Spring2025x")
+
+	first, err := a.TokenService.Issue(alice, apptest.Client)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	if first.AccessToken == "" || first.RefreshToken == "" || first.ExpiresIn <= 0 {
+		t.Fatalf("Issue returned %+v", first)
+	}
+
+	user, second, err := a.TokenService.Refresh(first.RefreshToken, apptest.Client)
+	if err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if user.ID != alice.ID {
+		t.Errorf("Refresh returned user %d, want %d", user.ID, alice.ID)
+	}
+	if second.RefreshToken == first.RefreshToken || second.AccessToken == first.AccessToken {
+		t.Error("Refresh did not rotate the tokens")
+	}
+
+	// 轮换后仍然是同一个会话
+	firstClaims, _ := a.JWT.ParseJWT(first.AccessToken)
+	secondClaims, err := a.JWT.ParseJWT(second.AccessToken)
+	if err != nil {
+		t.Fatalf("ParseJWT: %v", err)
+	}
+	if secondClaims.SessionID == 0 || secondClaims.SessionID != firstClaims.SessionID {
+		t.Errorf("session changed on refresh: %d -> %d", firstClaims.SessionID, secondClaims.SessionID)
+	}
+
+	if _, _, err := a.TokenService.Refresh("not-a-token", apptest.Client); !errors.Is(err, service.ErrInvalidRefreshToken) {
+		t.Errorf("unknown token: got %v, want ErrInvalidRefreshToken", err)
+	}
+}
+
+func TestRefreshTokenReuseRevokesSession(t *testing.T) {
+	a := apptest.New(t)
+	alice := a.CreateUser(t, "alice", "Spring2025x")
+
+	first, err := a.TokenService.Issue(alice, apptest.Client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, second, err := a.TokenService.Refresh(first.RefreshToken, apptest.Client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := a.JWT.ParseJWT(second.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !a.SessionService.Active(claims.SessionID, apptest.Client.IP) {
+		t.Fatal("session should be active before reuse")
+	}
+
+	// 旧的刷新令牌被再次使用：整个家族和会话都失效
+	if _, _, err := a.TokenService.Refresh(first.RefreshToken, apptest.Client); !errors.Is(err, service.ErrRefreshTokenReused) {
+		t.Fatalf("reuse: got %v, want ErrRefreshTokenReused", err)
+	}
+	if _, _, err := a.TokenService.Refresh(second.RefreshToken, apptest.Client); !errors.Is(err, service.ErrInvalidRefreshToken) {
+		t.Errorf("latest token after reuse: got %v, want ErrInvalidRefreshToken", err)
+	}
+	if a.SessionService.Active(claims.SessionID, apptest.Client.IP) {
+		t.Error("session is still active after refresh token reuse")
+	}
+	sessions, err := a.SessionService.List(alice.ID, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 0 {
+		t.Errorf("revoked session still listed: %+v", sessions)
+	}
+}
+
+func TestRefreshBannedUser(t *testing.T) {
+	a := apptest.New(t)
+	alice := a.CreateUser(t, "alice", "Spring2025x")
+	pair, err := a.TokenService.Issue(alice, apptest.Client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.UserService.SetBanned("alice", true); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := a.TokenService.Refresh(pair.RefreshToken, apptest.Client); !errors.Is(err, service.ErrInvalidRefreshToken) {
+		t.Errorf("banned user: got %v, want ErrInvalidRefreshToken", err)
+	}
+}
+
+func TestLogout(t *testing.T) {
+	a := apptest.New(t)
+	alice := a.CreateUser(t, "alice", "Spring2025x")
+	pair, err := a.TokenService.Issue(alice, apptest.Client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := a.JWT.ParseJWT(pair.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := a.TokenService.Logout(alice.ID, claims.SessionID, claims.ID, claims.ExpiresAt.Time, pair.RefreshToken); err != nil {
+		t.Fatalf("Logout: %v", err)
+	}
+	if !a.Revocations.IsRevoked(claims.ID) {
+		t.Error("access token is not revoked")
+	}
+	if a.SessionService.Active(claims.SessionID, apptest.Client.IP) {
+		t.Error("session is still active")
+	}
+	if _, _, err := a.TokenService.Refresh(pair.RefreshToken, apptest.Client); !errors.Is(err, service.ErrInvalidRefreshToken) {
+		t.Errorf("refresh after logout: got %v, want ErrInvalidRefreshToken", err)
+	}
+}
+
+func TestLogoutAll(t *testing.T) {
+	a := apptest.New(t)
+	alice := a.CreateUser(t, "alice", "Spring2025x")
+	laptop, err := a.TokenService.Issue(alice, apptest.Client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	phone, err := a.TokenService.Issue(alice, apptest.Client)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := a.TokenService.LogoutAll(alice.ID); err != nil {
+		t.Fatalf("LogoutAll: %v", err)
+	}
+	for _, pair := range []*service.TokenPair{laptop, phone} {
+		if _, _, err := a.TokenService.Refresh(pair.RefreshToken, apptest.Client); !errors.Is(err, service.ErrInvalidRefreshToken) {
+			t.Errorf("refresh after LogoutAll: got %v, want ErrInvalidRefreshToken", err)
+		}
+		claims, err := a.JWT.ParseJWT(pair.AccessToken)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// 令牌版本加一，之前签发的访问令牌全部失效
+		version, err := a.Revocations.TokenVersion(alice.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if claims.TokenVersion == version {
+			t.Error("access token version was not bumped")
+		}
+	}
+}
+
+func TestAdminActionsEndSessions(t *testing.T) {
+	tests := []struct {
+		name   string
+		action func(a *apptest.App) error
+	}{
+		{"ban", func(a *apptest.App) error { return a.UserService.SetBanned("alice", true) }},
+		{"reset password", func(a *apptest.App) error { return a.UserService.ResetPassword("alice", "Autumn2025y") }},
+		{"set role", func(a *apptest.App) error {
+			_, err := a.UserService.SetRole("alice", "moderator")
+			return err
+		}},
+		{"reset two-factor", func(a *apptest.App) error { return a.TwoFactor.Reset("alice") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := apptest.New(t)
+			alice := a.CreateUser(t, "alice", "Spring2025x")
+			pair, err := a.TokenService.Issue(alice, apptest.Client)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := tt.action(a); err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+			if _, _, err := a.TokenService.Refresh(pair.RefreshToken, apptest.Client); !errors.Is(err, service.ErrInvalidRefreshToken) {
+				t.Errorf("refresh after %s: got %v, want ErrInvalidRefreshToken", tt.name, err)
+			}
+			if sessions, err := a.SessionService.List(alice.ID, 0); err != nil || len(sessions) != 0 {
+				t.Errorf("sessions after %s: %v, %v", tt.name, sessions, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,366 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"my-social-platform/internal/model"
+	"my-social-platform/internal/pkg/totp"
+	"my-social-platform/internal/repository"
+	"strings"
+	"time"
+)
+
+// recoveryCodeCount 每次生成的恢复码数量
+const recoveryCodeCount = 10
+
+var (
+	// ErrTwoFactorAlreadyEnabled 已经开启了两步验证
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	// ErrTwoFactorNotEnabled 还没有开启两步验证
+	ErrTwoFactorNotEnabled = errors.New("two-factor authentication is not enabled")
+	// ErrTwoFactorNotSetUp 确认前需要先调用setup生成密钥
+	ErrTwoFactorNotSetUp = errors.New("two-factor setup has not been started")
+	// ErrTwoFactorRequired 用户的角色要求两步验证，不能关闭
+	ErrTwoFactorRequired = errors.New("two-factor authentication is required for your role")
+	// ErrInvalidTwoFactorCode 验证码或恢复码错误
+	ErrInvalidTwoFactorCode = errors.New("invalid two-factor code")
+	// ErrInvalidChallenge 挑战令牌无效、已过期，或签发后用户已退出所有设备
+	ErrInvalidChallenge = errors.New("invalid or expired challenge token")
+)
+
+// ChallengeIssuer 签发和验证两步验证的挑战令牌，由middleware.JWTManager实现
+type ChallengeIssuer interface {
+	GenerateChallenge(user model.User) (string, time.Duration, error)
+	ParseChallenge(token string) (userID uint, tokenVersion int, err error)
+	Requires2FA(role string) bool
+}
+
+// TwoFactorSetup 开始开启两步验证时返回的密钥
+// URI 由前端显示为二维码；Secret 供无法扫码时手动输入
+type TwoFactorSetup struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+// TwoFactorStatus 用户的两步验证状态
+type TwoFactorStatus struct {
+	Enabled           bool  `json:"enabled"`
+	Required          bool  `json:"required"` // 用户的角色要求两步验证
+	RecoveryCodesLeft int64 `json:"recovery_codes_left"`
+}
+
+// Challenge 密码正确但还需要两步验证时返回给客户端
+type Challenge struct {
+	Token     string
+	ExpiresIn time.Duration
+}
+
+// TwoFactorService 基于TOTP的两步验证
+//
+// 开启流程：Setup 生成密钥 → 用户在认证器应用中添加 → Confirm 提交一个验证码，确认后才真正开启并返回恢复码
+// 登录流程：AuthService.Login 密码正确后，开启了两步验证的用户得到挑战令牌，再由 CompleteLogin 提交验证码或恢复码
+type TwoFactorService struct {
+	users      repository.UserRepository
+	codes      repository.RecoveryCodeRepository
+	challenges ChallengeIssuer
+	guard      *LoginGuard
+	tokens     *TokenService
+	issuer     string
+}
+
+// NewTwoFactorService 创建TwoFactorService
+// issuer 为认证器应用中显示的名称；guard 与登录共用，验证码输错同样计入失败次数
+// 管理员重置两步验证后通过 tokens 让该用户所有设备退出登录
+func NewTwoFactorService(users repository.UserRepository, codes repository.RecoveryCodeRepository, challenges ChallengeIssuer, guard *LoginGuard, tokens *TokenService, issuer string) *TwoFactorService {
+	return &TwoFactorService{users: users, codes: codes, challenges: challenges, guard: guard, tokens: tokens, issuer: issuer}
+}
+
+// Status 查询用户的两步验证状态
+func (s *TwoFactorService) Status(userID uint) (*TwoFactorStatus, error) {
+	user, err := s.users.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	status := &TwoFactorStatus{Enabled: user.TOTPEnabledAt != nil, Required: s.challenges.Requires2FA(user.Role)}
+	if status.Enabled {
+		if status.RecoveryCodesLeft, err = s.codes.CountUnused(userID); err != nil {
+			return nil, err
+		}
+	}
+	return status, nil
+}
+
+// Setup 生成新的TOTP密钥，需要提供当前密码
+// 密钥先保存下来，Confirm 成功之前两步验证不会生效；重复调用会生成新的密钥
+func (s *TwoFactorService) Setup(userID uint, password, clientIP string) (*TwoFactorSetup, error) {
+	user, err := s.users.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attempt(user, clientIP, func() error { return s.checkPassword(user, password) }); err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabledAt != nil {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	user.TOTPSecret = secret
+	if err := s.users.Update(user); err != nil {
+		return nil, err
+	}
+	return &TwoFactorSetup{Secret: secret, URI: totp.URI(s.issuer, user.Username, secret)}, nil
+}
+
+// Confirm 提交认证器应用生成的验证码，正确时开启两步验证并返回一组新的恢复码
+// 恢复码只在这里返回一次，数据库中只保存哈希
+func (s *TwoFactorService) Confirm(userID uint, code, clientIP string) ([]string, error) {
+	user, err := s.users.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabledAt != nil {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, ErrTwoFactorNotSetUp
+	}
+	if err := s.attempt(user, clientIP, func() error { return s.verifyTOTP(user, code) }); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	user.TOTPEnabledAt = &now
+	if err := s.users.Update(user); err != nil {
+		return nil, err
+	}
+	return s.replaceRecoveryCodes(user.ID)
+}
+
+// Disable 关闭两步验证，需要当前密码和一个验证码（或恢复码）
+// 角色要求两步验证的用户不能关闭，丢失手机时由管理员用 user reset-2fa 重置
+func (s *TwoFactorService) Disable(userID uint, password, code, clientIP string) error {
+	user, err := s.users.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	if user.TOTPEnabledAt == nil {
+		return ErrTwoFactorNotEnabled
+	}
+	if s.challenges.Requires2FA(user.Role) {
+		return ErrTwoFactorRequired
+	}
+	err = s.attempt(user, clientIP, func() error {
+		if err := s.checkPassword(user, password); err != nil {
+			return err
+		}
+		_, err := s.verify(user, code)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return s.clear(user)
+}
+
+// RegenerateRecoveryCodes 作废原有的恢复码并生成一组新的，需要提供一个验证码
+func (s *TwoFactorService) RegenerateRecoveryCodes(userID uint, code, clientIP string) ([]string, error) {
+	user, err := s.users.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabledAt == nil {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	if err := s.attempt(user, clientIP, func() error { return s.verifyTOTP(user, code) }); err != nil {
+		return nil, err
+	}
+	return s.replaceRecoveryCodes(user.ID)
+}
+
+// Reset 管理员为丢失手机的用户关闭两步验证，该用户所有设备退出登录
+// 角色要求两步验证时，用户下次登录后需要重新开启
+func (s *TwoFactorService) Reset(username string) error {
+	user, err := s.users.GetByUsername(username)
+	if err != nil {
+		return err
+	}
+	if err := s.clear(user); err != nil {
+		return err
+	}
+	return s.tokens.LogoutAll(user.ID)
+}
+
+// Challenge 为密码正确、开启了两步验证的用户签发挑战令牌
+func (s *TwoFactorService) Challenge(user *model.User) (*Challenge, error) {
+	token, ttl, err := s.challenges.GenerateChallenge(*user)
+	if err != nil {
+		return nil, err
+	}
+	return &Challenge{Token: token, ExpiresIn: ttl}, nil
+}
+
+// CompleteLogin 登录的第二步：校验挑战令牌和验证码（或恢复码），成功时返回用户，由调用方签发令牌
+// 返回的bool表示是否使用了恢复码；输错验证码和输错密码一样计入失败次数，达到上限后锁定
+// 挑战令牌有效但验证码错误或被限制时，同样返回用户，供调用方记录安全日志
+func (s *TwoFactorService) CompleteLogin(challenge, code, clientIP string) (*model.User, bool, error) {
+	userID, version, err := s.challenges.ParseChallenge(challenge)
+	if err != nil {
+		return nil, false, ErrInvalidChallenge
+	}
+	user, err := s.users.GetByID(userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, false, ErrInvalidChallenge
+		}
+		return nil, false, err
+	}
+	// 签发挑战令牌后修改了密码、退出了所有设备或被管理员重置了两步验证
+	if user.TokenVersion != version || user.TOTPEnabledAt == nil {
+		return nil, false, ErrInvalidChallenge
+	}
+	if user.BannedAt != nil {
+		return nil, false, ErrUserBanned
+	}
+
+	attempt, err := s.guard.Check(clientIP, user.Username)
+	if err != nil {
+		return user, false, err
+	}
+	defer attempt.Release()
+	recovery, err := s.verify(user, code)
+	if errors.Is(err, ErrInvalidTwoFactorCode) {
+		if blocked := attempt.Fail(); blocked != nil {
+			return user, false, blocked
+		}
+		return user, false, err
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	attempt.Succeed()
+	return user, recovery, nil
+}
+
+// verify 校验6位验证码或恢复码，返回是否使用了恢复码
+func (s *TwoFactorService) verify(user *model.User, code string) (bool, error) {
+	code = strings.TrimSpace(code)
+	if len(code) == totp.Digits {
+		ok, err := s.checkTOTP(user, code)
+		if err != nil {
+			return false, err
+		}
+		if !ok {
+			return false, ErrInvalidTwoFactorCode
+		}
+		return false, nil
+	}
+
+	ok, err := s.codes.Use(user.ID, hashRecoveryCode(user.ID, code), time.Now())
+	if err != nil {
+		return false, err
+	}
+	if !ok {
+		return false, ErrInvalidTwoFactorCode
+	}
+	return true, nil
+}
+
+// attempt 在登录失败限制下执行一次密码或验证码校验，输错和登录一样计入失败次数，被限制时返回*LoginBlockedError
+// 否则持有被盗访问令牌的人可以在设置接口上无限次猜测验证码，再生成新的恢复码
+func (s *TwoFactorService) attempt(user *model.User, clientIP string, check func() error) error {
+	attempt, err := s.guard.Check(clientIP, user.Username)
+	if err != nil {
+		return err
+	}
+	defer attempt.Release()
+	err = check()
+	if errors.Is(err, ErrWrongPassword) || errors.Is(err, ErrInvalidTwoFactorCode) {
+		if blocked := attempt.Fail(); blocked != nil {
+			return blocked
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	attempt.Succeed()
+	return nil
+}
+
+// checkPassword 校验当前密码
+func (s *TwoFactorService) checkPassword(user *model.User, password string) error {
+	if !VerifyPassword(user.Password, password) {
+		return ErrWrongPassword
+	}
+	return nil
+}
+
+// verifyTOTP 只接受验证码，不接受恢复码
+func (s *TwoFactorService) verifyTOTP(user *model.User, code string) error {
+	ok, err := s.checkTOTP(user, code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// checkTOTP 校验验证码，并记录它所在的周期，同一个验证码不能使用两次
+func (s *TwoFactorService) checkTOTP(user *model.User, code string) (bool, error) {
+	step, ok := totp.Validate(user.TOTPSecret, strings.TrimSpace(code), time.Now())
+	if !ok {
+		return false, nil
+	}
+	// 条件更新，并发提交同一个验证码时只有一个请求能成功
+	advanced, err := s.users.AdvanceTOTPStep(user.ID, step)
+	if err != nil || !advanced {
+		return false, err
+	}
+	user.TOTPLastStep = step
+	return true, nil
+}
+
+// clear 清除用户的两步验证密钥和恢复码
+func (s *TwoFactorService) clear(user *model.User) error {
+	user.TOTPSecret = ""
+	user.TOTPEnabledAt = nil
+	user.TOTPLastStep = 0
+	if err := s.users.Update(user); err != nil {
+		return err
+	}
+	return s.codes.DeleteAll(user.ID)
+}
+
+// replaceRecoveryCodes 生成一组新的恢复码替换原有的，返回明文
+func (s *TwoFactorService) replaceRecoveryCodes(userID uint) ([]string, error) {
+	plain := make([]string, 0, recoveryCodeCount)
+	rows := make([]*model.RecoveryCode, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		raw := hex.EncodeToString(b)
+		code := fmt.Sprintf("%s-%s", raw[:5], raw[5:])
+		plain = append(plain, code)
+		rows = append(rows, &model.RecoveryCode{UserID: userID, CodeHash: hashRecoveryCode(userID, code)})
+	}
+	if err := s.codes.Replace(userID, rows); err != nil {
+		return nil, err
+	}
+	return plain, nil
+}
+
+// hashRecoveryCode 计算恢复码的哈希，忽略大小写、空格和连字符，和用户ID绑定在一起
+func hashRecoveryCode(userID uint, code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	return hashToken(fmt.Sprintf("%d:%s", userID, normalized))
+}
@@ -0,0 +1,129 @@
+package service_test
+
+import (
+	"errors"
+	"my-social-platform/internal/app/apptest"
+	"my-social-platform/internal/config"
+	"my-social-platform/internal/pkg/totp"
+	"my-social-platform/internal/service"
+	"testing"
+	"time"
+)
+
+// currentCode 当前周期的验证码
+func currentCode(t *testing.T, secret string) string {
+	t.Helper()
+	code, err := totp.Code(secret, totp.Step(time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+// wrongCode 与当前前后周期都不同的6位验证码
+func wrongCode(t *testing.T, secret string) string {
+	t.Helper()
+	for _, candidate := range []string{"000000", "111111", "222222", "333333"} {
+		if _, ok := totp.Validate(secret, candidate, time.Now()); !ok {
+			return candidate
+		}
+	}
+	t.Fatal("no wrong code found")
+	return ""
+}
+
+func TestTwoFactorEnableAndLogin(t *testing.T) {
+	a := apptest.New(t)
+	alice := a.CreateUser(t, "alice", "Spring2025x")
+
+	if _, err := a.TwoFactor.Setup(alice.ID, "wrong-password1", "192.0.2.1"); !errors.Is(err, service.ErrWrongPassword) {
+		t.Fatalf("Setup with wrong password: got %v", err)
+	}
+	setup, err := a.TwoFactor.Setup(alice.ID, "Spring2025x", "192.0.2.1")
+	if err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+	codes, err := a.TwoFactor.Confirm(alice.ID, currentCode(t, setup.Secret), "192.0.2.1")
+	if err != nil {
+		t.Fatalf("Confirm: %v", err)
+	}
+	if len(codes) != 10 {
+		t.Fatalf("got %d recovery codes", len(codes))
+	}
+
+	alice = a.User(t, "alice")
+	challenge, err := a.TwoFactor.Challenge(alice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 同一个验证码不能使用两次，用恢复码完成登录
+	if _, _, err := a.TwoFactor.CompleteLogin(challenge.Token, currentCode(t, setup.Secret), "192.0.2.1"); !errors.Is(err, service.ErrInvalidTwoFactorCode) {
+		t.Errorf("reused TOTP code: got %v, want ErrInvalidTwoFactorCode", err)
+	}
+	user, recovery, err := a.TwoFactor.CompleteLogin(challenge.Token, codes[0], "192.0.2.1")
+	if err != nil || !recovery || user.ID != alice.ID {
+		t.Fatalf("recovery code login: user %v, recovery %v, err %v", user, recovery, err)
+	}
+	if _, _, err := a.TwoFactor.CompleteLogin(challenge.Token, codes[0], "192.0.2.1"); !errors.Is(err, service.ErrInvalidTwoFactorCode) {
+		t.Errorf("reused recovery code: got %v, want ErrInvalidTwoFactorCode", err)
+	}
+}
+
+func TestTwoFactorSettingsAreRateLimited(t *testing.T) {
+	a := apptest.New(t, func(cfg *config.Config) {
+		cfg.Auth.LoginMaxFailures = 3
+		cfg.Auth.LoginMaxBackoff = 0
+	})
+	alice := a.CreateUser(t, "alice", "Spring2025x")
+	setup, err := a.TwoFactor.Setup(alice.ID, "Spring2025x", "192.0.2.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.TwoFactor.Confirm(alice.ID, currentCode(t, setup.Secret), "192.0.2.1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 持有访问令牌的人猜测验证码来重新生成恢复码：输错计入失败次数，达到上限后锁定
+	wrong := wrongCode(t, setup.Secret)
+	for i := 0; i < 2; i++ {
+		if _, err := a.TwoFactor.RegenerateRecoveryCodes(alice.ID, wrong, "192.0.2.1"); !errors.Is(err, service.ErrInvalidTwoFactorCode) {
+			t.Fatalf("guess %d: got %v, want ErrInvalidTwoFactorCode", i+1, err)
+		}
+	}
+	var blocked *service.LoginBlockedError
+	if _, err := a.TwoFactor.RegenerateRecoveryCodes(alice.ID, wrong, "192.0.2.1"); !errors.As(err, &blocked) || !blocked.Locked {
+		t.Fatalf("third guess: got %v, want lockout", err)
+	}
+	// 锁定期间即使验证码正确也被拒绝，其他设置接口和登录共用同一个计数
+	if _, err := a.TwoFactor.RegenerateRecoveryCodes(alice.ID, "123456", "192.0.2.9"); !errors.As(err, &blocked) {
+		t.Errorf("regenerate while locked: got %v, want *LoginBlockedError", err)
+	}
+	if err := a.TwoFactor.Disable(alice.ID, "Spring2025x", "123456", "192.0.2.9"); !errors.As(err, &blocked) {
+		t.Errorf("disable while locked: got %v, want *LoginBlockedError", err)
+	}
+	if _, _, err := a.AuthService.Login("alice", "Spring2025x", "192.0.2.9"); !errors.As(err, &blocked) {
+		t.Errorf("login while locked: got %v, want *LoginBlockedError", err)
+	}
+}
+
+func TestTwoFactorConfirmIsRateLimited(t *testing.T) {
+	a := apptest.New(t, func(cfg *config.Config) {
+		cfg.Auth.LoginMaxFailures = 2
+	})
+	alice := a.CreateUser(t, "alice", "Spring2025x")
+	setup, err := a.TwoFactor.Setup(alice.ID, "Spring2025x", "192.0.2.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrong := wrongCode(t, setup.Secret)
+	if _, err := a.TwoFactor.Confirm(alice.ID, wrong, "192.0.2.1"); !errors.Is(err, service.ErrInvalidTwoFactorCode) {
+		t.Fatalf("first guess: got %v", err)
+	}
+	var blocked *service.LoginBlockedError
+	if _, err := a.TwoFactor.Confirm(alice.ID, wrong, "192.0.2.1"); !errors.As(err, &blocked) {
+		t.Fatalf("second guess: got %v, want lockout", err)
+	}
+	if _, err := a.TwoFactor.Confirm(alice.ID, currentCode(t, setup.Secret), "192.0.2.1"); !errors.As(err, &blocked) {
+		t.Errorf("correct code while locked: got %v, want *LoginBlockedError", err)
+	}
+}
@@ -1,53 +1,56 @@
 package service
 
 import (
+	"errors"
+	"fmt"
 	"my-social-platform/internal/dto"
+	"my-social-platform/internal/model"
 	"my-social-platform/internal/repository"
+	"slices"
+	"time"
 )
 
-// GetUserProfileByID 根据用户ID获取完整个人资料
-func GetUserProfileByID(id uint) (*dto.UserDTO, error) {
-	user, err := repository.GetUserByID(id)
+// ErrInvalidRole 不存在的角色
+var ErrInvalidRole = errors.New("invalid role")
+
+// UserService 用户资料相关的业务逻辑
+type UserService struct {
+	users  repository.UserRepository
+	roles  repository.RoleRepository
+	tokens *TokenService
+}
+
+// NewUserService 创建UserService
+// 管理员封禁、修改角色和重置密码后通过 tokens 让该用户所有设备退出登录
+func NewUserService(users repository.UserRepository, roles repository.RoleRepository, tokens *TokenService) *UserService {
+	return &UserService{users: users, roles: roles, tokens: tokens}
+}
+
+// GetProfileByID 根据用户ID获取完整个人资料
+func (s *UserService) GetProfileByID(id uint) (*dto.UserDTO, error) {
+	user, err := s.users.GetByID(id)
 	if err != nil {
 		return nil, err
 	}
 
 	// 转换为DTO
-	return &dto.UserDTO{
-		ID:          user.ID,
-		Username:    user.Username,
-		Nickname:    user.Nickname,
-		Avatar:      user.Avatar,
-		Bio:         user.Bio,
-		FollowCount: user.FollowCount,
-		FansCount:   user.FansCount,
-		LikeCount:   user.LikeCount,
-	}, nil
+	return ToUserDTO(user), nil
 }
 
-// GetUserProfileByUsername 根据用户名获取完整个人资料
-func GetUserProfileByUsername(username string) (*dto.UserDTO, error) {
-	user, err := repository.GetUserByUsername(username)
+// GetProfileByUsername 根据用户名获取完整个人资料
+func (s *UserService) GetProfileByUsername(username string) (*dto.UserDTO, error) {
+	user, err := s.users.GetByUsername(username)
 	if err != nil {
 		return nil, err
 	}
 
 	// 转换为DTO
-	return &dto.UserDTO{
-		ID:          user.ID,
-		Username:    user.Username,
-		Nickname:    user.Nickname,
-		Avatar:      user.Avatar,
-		Bio:         user.Bio,
-		FollowCount: user.FollowCount,
-		FansCount:   user.FansCount,
-		LikeCount:   user.LikeCount,
-	}, nil
+	return ToUserDTO(user), nil
 }
 
-// UpdateUserProfile 更新用户资料
-func UpdateUserProfile(userID uint, nickname string, bio string) error {
-	user, err := repository.GetUserByID(userID)
+// UpdateProfile 更新用户资料
+func (s *UserService) UpdateProfile(userID uint, nickname string, bio string) error {
+	user, err := s.users.GetByID(userID)
 	if err != nil {
 		return err
 	}
@@ -57,10 +60,126 @@ func UpdateUserProfile(userID uint, nickname string, bio string) error {
 	user.Bio = bio
 
 	// 保存更改
-	return repository.UpdateUserProfile(user)
+	return s.users.Update(user)
+}
+
+// UpdateEmail 更新用户邮箱，返回邮箱是否有变化
+// 邮箱变化后需要重新验证，认证学生的身份同时取消
+func (s *UserService) UpdateEmail(userID uint, email string) (bool, error) {
+	normalized, err := NormalizeEmail(email)
+	if err != nil {
+		return false, err
+	}
+	user, err := s.users.GetByID(userID)
+	if err != nil {
+		return false, err
+	}
+	if user.Email != nil && *user.Email == normalized {
+		return false, nil
+	}
+	user.Email = &normalized
+	user.EmailVerifiedAt = nil
+	user.StudentVerified = false
+	if err := s.users.Update(user); err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			return false, ErrEmailTaken
+		}
+		return false, err
+	}
+	return true, nil
 }
 
 // UpdateAvatar 更新用户头像
-func UpdateAvatar(userID uint, avatarURL string) error {
-	return repository.UpdateAvatar(userID, avatarURL)
+func (s *UserService) UpdateAvatar(userID uint, avatarURL string) error {
+	return s.users.UpdateAvatar(userID, avatarURL)
+}
+
+// SetBanned 封禁或解封用户，封禁后该用户无法登录，所有会话结束，已签发的令牌全部失效
+func (s *UserService) SetBanned(username string, banned bool) error {
+	user, err := s.users.GetByUsername(username)
+	if err != nil {
+		return err
+	}
+
+	if !banned {
+		user.BannedAt = nil
+		return s.users.Update(user)
+	}
+	now := time.Now()
+	user.BannedAt = &now
+	if err := s.users.Update(user); err != nil {
+		return err
+	}
+	return s.tokens.LogoutAll(user.ID)
+}
+
+// SetRole 设置用户的角色，角色必须存在于role表中；返回修改后的用户
+// 该用户所有设备退出登录，重新登录后令牌中的权限和两步验证要求按新角色计算
+func (s *UserService) SetRole(username, role string) (*model.User, error) {
+	roles, err := s.roles.List()
+	if err != nil {
+		return nil, err
+	}
+	if !slices.ContainsFunc(roles, func(r *model.Role) bool { return r.Name == role }) {
+		return nil, fmt.Errorf("%w %q, must be one of %v", ErrInvalidRole, role, roleNames(roles))
+	}
+	user, err := s.users.GetByUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	user.Role = role
+	if err := s.users.Update(user); err != nil {
+		return nil, err
+	}
+	if err := s.tokens.LogoutAll(user.ID); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// ChangePassword 用户修改自己的密码，必须提供正确的当前密码
+// 吊销其他会话由调用方通过TokenService.ResetSessions完成
+func (s *UserService) ChangePassword(userID uint, currentPassword, newPassword string) error {
+	user, err := s.users.GetByID(userID)
+	if err != nil {
+		return err
+	}
+
+	if !VerifyPassword(user.Password, currentPassword) {
+		return ErrWrongPassword
+	}
+	if err := ValidatePassword(user.Username, newPassword); err != nil {
+		return err
+	}
+	if newPassword == currentPassword {
+		return fmt.Errorf("%w: must differ from the current password", ErrPasswordPolicy)
+	}
+
+	hashedPassword, err := HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+	user.Password = hashedPassword
+	return s.users.Update(user)
+}
+
+// ResetPassword 管理员直接为用户设置新密码，该用户所有设备退出登录
+func (s *UserService) ResetPassword(username, password string) error {
+	user, err := s.users.GetByUsername(username)
+	if err != nil {
+		return err
+	}
+	if err := ValidatePassword(user.Username, password); err != nil {
+		return err
+	}
+
+	hashedPassword, err := HashPassword(password)
+	if err != nil {
+		return err
+	}
+	user.Password = hashedPassword
+	if err := s.users.Update(user); err != nil {
+		return err
+	}
+	return s.tokens.LogoutAll(user.ID)
 }
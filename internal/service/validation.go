@@ -0,0 +1,116 @@
+package service
+
+import (
+	"bufio"
+	_ "embed"
+	"errors"
+	"fmt"
+	"my-social-platform/internal/config"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// ErrUsernameTaken 用户名已被注册
+	ErrUsernameTaken = errors.New("username is already taken")
+	// ErrEmailTaken 邮箱已被其他账号使用
+	ErrEmailTaken = errors.New("email is already in use")
+)
+
+// ValidationError 请求参数校验失败
+// Fields 为字段名到错误描述的映射，handler原样返回给前端，前端显示在对应的输入框下
+type ValidationError struct {
+	Fields map[string]string
+}
+
+func (e *ValidationError) Error() string {
+	names := make([]string, 0, len(e.Fields))
+	for name := range e.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, name+": "+e.Fields[name])
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+// add 记录一个字段的错误，同一字段只保留第一个错误
+func (e *ValidationError) add(field, message string) {
+	if e.Fields == nil {
+		e.Fields = make(map[string]string)
+	}
+	if _, ok := e.Fields[field]; !ok {
+		e.Fields[field] = message
+	}
+}
+
+// orNil 没有错误时返回nil，避免返回值为非nil的接口
+func (e *ValidationError) orNil() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+// UsernamePolicy 用户名规则：长度、可用字符和保留用户名
+type UsernamePolicy struct {
+	min, max int
+	pattern  *regexp.Regexp
+	reserved map[string]bool
+}
+
+// NewUsernamePolicy 根据配置创建UsernamePolicy，配置已在启动时校验过
+func NewUsernamePolicy(cfg config.AuthConfig) *UsernamePolicy {
+	reserved := make(map[string]bool, len(cfg.ReservedUsernames))
+	for _, name := range cfg.ReservedUsernames {
+		reserved[strings.ToLower(name)] = true
+	}
+	return &UsernamePolicy{
+		min:      cfg.UsernameMinLength,
+		max:      cfg.UsernameMaxLength,
+		pattern:  regexp.MustCompile(cfg.UsernamePattern),
+		reserved: reserved,
+	}
+}
+
+// Validate 检查用户名是否符合规则，返回给用户看的错误描述，符合时返回空字符串
+func (p *UsernamePolicy) Validate(username string) string {
+	n := utf8.RuneCountInString(username)
+	switch {
+	case username == "":
+		return "username is required"
+	case strings.TrimSpace(username) != username || strings.ContainsFunc(username, isSpace):
+		return "must not contain whitespace"
+	case n < p.min || n > p.max:
+		return fmt.Sprintf("must be %d to %d characters", p.min, p.max)
+	case !p.pattern.MatchString(username):
+		return "contains characters that are not allowed"
+	case p.reserved[strings.ToLower(username)]:
+		return "is reserved"
+	}
+	return ""
+}
+
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == '　'
+}
+
+//go:embed common_passwords.txt
+var commonPasswordList string
+
+// commonPasswords 常见弱密码集合，小写
+var commonPasswords = func() map[string]bool {
+	set := make(map[string]bool)
+	scanner := bufio.NewScanner(strings.NewReader(commonPasswordList))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			set[strings.ToLower(line)] = true
+		}
+	}
+	return set
+}()